@@ -3,6 +3,7 @@ package context
 import (
 	"context"
 	"fmt"
+	"math/rand"
 
 	"github.com/vladimirvivien/automi/api"
 )
@@ -12,6 +13,9 @@ type ctxKey int
 var (
 	logFuncKey ctxKey = 1
 	errFuncKey ctxKey = 2
+	marshalKey ctxKey = 3
+	seedKey    ctxKey = 4
+	stopKey    ctxKey = 5
 )
 
 // WithLogFunc sets the function to handle logging from runtime components
@@ -21,8 +25,8 @@ func WithLogFunc(ctx context.Context, logFunc api.LogFunc) context.Context {
 
 // GetLogFunc returns the log function stored in the context.
 func GetLogFunc(ctx context.Context) func(interface{}) {
-	fn, ok := ctx.Value(logFuncKey).(func(interface{}))
-	if !ok {
+	fn, ok := ctx.Value(logFuncKey).(api.LogFunc)
+	if !ok || fn == nil {
 		return nil
 	}
 	return fn
@@ -79,3 +83,69 @@ func Err(fn api.ErrorFunc, err api.StreamError) {
 		fn(err)
 	}
 }
+
+// WithMarshalErrorPolicy sets the policy applied by emitters and collectors
+// to items they fail to decode or encode
+func WithMarshalErrorPolicy(ctx context.Context, policy api.MarshalErrorPolicy) context.Context {
+	return context.WithValue(ctx, marshalKey, policy)
+}
+
+// GetMarshalErrorPolicy returns the marshal error policy stored in the
+// context, api.MarshalError if none is set.
+func GetMarshalErrorPolicy(ctx context.Context) api.MarshalErrorPolicy {
+	policy, _ := ctx.Value(marshalKey).(api.MarshalErrorPolicy)
+	return policy
+}
+
+// MarshalErr handles err, raised while decoding or encoding item, according
+// to the marshal error policy stored in ctx.  The error is logged with logf
+// and, unless the policy is api.MarshalSkip, sent to errf (along with item
+// if the policy is api.MarshalDeadLetter).
+func MarshalErr(ctx context.Context, logf api.LogFunc, errf api.ErrorFunc, err error, item interface{}) {
+	if logf != nil {
+		logf(err)
+	}
+	switch GetMarshalErrorPolicy(ctx) {
+	case api.MarshalSkip:
+	case api.MarshalDeadLetter:
+		Err(errf, api.ErrorWithItem(err.Error(), &api.StreamItem{Item: item}))
+	default:
+		Err(errf, api.Error(err.Error()))
+	}
+}
+
+// WithSeed sets the seed used by randomized runtime components
+func WithSeed(ctx context.Context, seed int64) context.Context {
+	return context.WithValue(ctx, seedKey, seed)
+}
+
+// GetRand returns a new *rand.Rand seeded with the seed stored in the
+// context, or nil if no seed is set.  A *rand.Rand is not safe for
+// concurrent use, so each component gets its own source which produces
+// the same sequence for a given seed.
+func GetRand(ctx context.Context) *rand.Rand {
+	seed, ok := ctx.Value(seedKey).(int64)
+	if !ok {
+		return nil
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
+// WithSourceStop sets the function that stops the source of the stream
+func WithSourceStop(ctx context.Context, stop func()) context.Context {
+	return context.WithValue(ctx, stopKey, stop)
+}
+
+// StopSource stops the source of the stream, using the function stored in
+// the context, for operators that need no more items (i.e. limits).  The
+// source closes its output and the operators upstream complete once their
+// input is closed, items already in-flight must still be drained.  It
+// returns false if no stop function is set.
+func StopSource(ctx context.Context) bool {
+	stop, ok := ctx.Value(stopKey).(func())
+	if !ok || stop == nil {
+		return false
+	}
+	stop()
+	return true
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"time"
 )
 
 type Emitter interface {
@@ -107,6 +108,20 @@ func CancellationError(msg string) CancelStreamError {
 	return CancelStreamError(Error(msg))
 }
 
+// MarshalErrorPolicy determines how emitters and collectors handle items
+// they fail to decode or encode (i.e. a malformed record).
+type MarshalErrorPolicy int
+
+const (
+	// MarshalError reports the failure on the error path and drops the item (default)
+	MarshalError MarshalErrorPolicy = iota
+	// MarshalSkip drops the item, the failure is only logged
+	MarshalSkip
+	// MarshalDeadLetter reports the failure along with the malformed item
+	// (see StreamError.Item) so it can be routed to a dead letter sink
+	MarshalDeadLetter
+)
+
 // StreamItem can be used to provide a rich repressentation of streaming data.
 // Stream data can be wrapped in StreamItem carry additional information downstream
 // including context, metadata, and error.
@@ -115,4 +130,117 @@ type StreamItem struct {
 	Item     interface{}       // data item being stream
 	MetaData map[string]string // user-provided stream metadat
 	Context  context.Context   // stream context
+	Position interface{}       // opaque source position (i.e. offset) used for checkpointing
+}
+
+// Checkpointer is implemented by components that can durably record
+// source positions (i.e. offsets) once the items associated with them
+// have been successfully written by a sink.  Sources that want their
+// positions committed emit StreamItem values with the Position field set.
+// Operators unwrap positioned items before applying user functions and
+// carry the position onto their results (batches and reductions carry the
+// position of their last item).  Positions are committed in order as long
+// as operators run with a single worker.
+type Checkpointer interface {
+	Commit(position interface{}) error
+}
+
+// CheckpointFunc is a function adapter that implements Checkpointer
+type CheckpointFunc func(interface{}) error
+
+// Commit implements Checkpointer.Commit
+func (f CheckpointFunc) Commit(position interface{}) error {
+	return f(position)
+}
+
+// CheckpointSink is implemented by sinks that can commit source positions
+// of items they have successfully written using an api.Checkpointer.
+type CheckpointSink interface {
+	Sink
+	SetCheckpointer(Checkpointer)
+}
+
+// Resumable is implemented by sources that can resume emitting items
+// located after the specified (previously committed) position.
+type Resumable interface {
+	Resume(position interface{}) error
+}
+
+// ItemBatch is a group of items sent as a single value by an emitter, to
+// save channel operations at high item rates.  Streams unbatch items
+// before they reach operators (see BatchEmitter).
+type ItemBatch []interface{}
+
+// BatchEmitter is implemented by emitters that can send their items
+// grouped as ItemBatch values.  EmitsBatches reports whether they do.
+type BatchEmitter interface {
+	EmitsBatches() bool
+}
+
+// Snapshotter is implemented by operators with internal state that
+// can be captured in a checkpoint and restored after a restart.
+type Snapshotter interface {
+	Snapshot() (interface{}, error)
+	Restore(state interface{}) error
+}
+
+// Checkpoint captures the last committed source position along
+// with the snapshots of stateful operators (keyed by operator index).
+type Checkpoint struct {
+	Position interface{}
+	States   map[int]interface{}
+}
+
+// CheckpointStore is a user-provided store used to persist
+// and retrieve the latest stream checkpoint.
+type CheckpointStore interface {
+	Save(Checkpoint) error
+	Load() (Checkpoint, bool, error)
+}
+
+// UnwrapPosition returns the data item and source position of item when
+// it is a StreamItem carrying a position. Otherwise, item is returned as is
+// with a nil position.
+func UnwrapPosition(item interface{}) (interface{}, interface{}) {
+	if posItem, ok := item.(StreamItem); ok && posItem.Position != nil {
+		return posItem.Item, posItem.Position
+	}
+	return item, nil
+}
+
+// WithPosition wraps item as a StreamItem carrying the source position pos.
+// If pos is nil or item is nil, item is returned as is.  Items of StreamError
+// values are updated with the position so that it can still be committed.
+// Other errors are returned as is.
+func WithPosition(item interface{}, pos interface{}) interface{} {
+	if pos == nil {
+		return item
+	}
+	switch val := item.(type) {
+	case nil:
+		return nil
+	case StreamError:
+		if val.item == nil || val.item.Position != nil {
+			return val
+		}
+		posItem := *val.item
+		posItem.Position = pos
+		return ErrorWithItem(val.err, &posItem)
+	case error:
+		return val
+	case StreamItem:
+		if val.Position == nil {
+			val.Position = pos
+		}
+		return val
+	default:
+		return StreamItem{Item: val, Position: pos}
+	}
+}
+
+// TimedItem associates a data item with an event time.  It is used by
+// event-time operators such as time windows.
+type TimedItem struct {
+	Time time.Time   // event time of the item
+	Item interface{} // data item being streamed
 }
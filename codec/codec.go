@@ -0,0 +1,46 @@
+package codec
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Codec compresses and decompresses byte payloads
+type Codec interface {
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+var (
+	mutex  sync.RWMutex
+	codecs = make(map[string]Codec)
+)
+
+// Register makes codec c available under the name algo, replacing any
+// codec previously registered with that name
+func Register(algo string, c Codec) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	codecs[algo] = c
+}
+
+// Get returns the codec registered under the name algo
+func Get(algo string) (Codec, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	c, ok := codecs[algo]
+	if !ok {
+		return nil, fmt.Errorf("codec %q not registered (available: %v)", algo, names())
+	}
+	return c, nil
+}
+
+func names() []string {
+	var result []string
+	for name := range codecs {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,38 @@
+package codec
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCodec_Gzip(t *testing.T) {
+	c, err := Get(Gzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(strings.Repeat("automi ", 100))
+	compressed, err := c.Compress(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compressed) >= len(data) {
+		t.Fatal("expecting compressed payload smaller than", len(data), "got", len(compressed))
+	}
+	result, err := c.Decompress(compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(result, data) {
+		t.Fatal("unexpected decompressed payload", string(result))
+	}
+	if _, err := c.Decompress([]byte("not gzip")); err == nil {
+		t.Fatal("expecting error for invalid payload")
+	}
+}
+
+func TestCodec_Get(t *testing.T) {
+	if _, err := Get("lz77"); err == nil {
+		t.Fatal("expecting error for unregistered codec")
+	}
+}
@@ -0,0 +1,7 @@
+// Package codec provides the compression algorithms used to compress and
+// decompress []byte payloads in a stream.  Gzip is always available,
+// snappy and zstd are registered when built with the snappy and zstd
+// build tags (i.e. go build -tags "snappy zstd").  Building with those
+// tags requires the github.com/golang/snappy and
+// github.com/klauspost/compress modules respectively.
+package codec
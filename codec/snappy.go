@@ -0,0 +1,23 @@
+//go:build snappy
+// +build snappy
+
+package codec
+
+import "github.com/golang/snappy"
+
+// Snappy is the name of the snappy codec
+const Snappy = "snappy"
+
+func init() {
+	Register(Snappy, snappyCodec{})
+}
+
+type snappyCodec struct{}
+
+func (snappyCodec) Compress(data []byte) ([]byte, error) {
+	return snappy.Encode(nil, data), nil
+}
+
+func (snappyCodec) Decompress(data []byte) ([]byte, error) {
+	return snappy.Decode(nil, data)
+}
@@ -0,0 +1,36 @@
+//go:build zstd
+// +build zstd
+
+package codec
+
+import "github.com/klauspost/compress/zstd"
+
+// Zstd is the name of the zstd codec
+const Zstd = "zstd"
+
+func init() {
+	enc, err := zstd.NewWriter(nil)
+	if err != nil {
+		panic(err)
+	}
+	dec, err := zstd.NewReader(nil)
+	if err != nil {
+		panic(err)
+	}
+	Register(Zstd, &zstdCodec{enc: enc, dec: dec})
+}
+
+// zstdCodec uses a shared encoder and decoder, EncodeAll and DecodeAll
+// are safe for concurrent use
+type zstdCodec struct {
+	enc *zstd.Encoder
+	dec *zstd.Decoder
+}
+
+func (c *zstdCodec) Compress(data []byte) ([]byte, error) {
+	return c.enc.EncodeAll(data, nil), nil
+}
+
+func (c *zstdCodec) Decompress(data []byte) ([]byte, error) {
+	return c.dec.DecodeAll(data, nil)
+}
@@ -0,0 +1,139 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// ByTypeCollector is a collector that routes each streamed item to the sink
+// registered for the dynamic type of the item (i.e. to write different
+// record types to different destinations).  Items of other types go to the
+// default sink.  All sinks are opened with the collector and closed when
+// the stream completes.  A sink that returns early (i.e. fails to open) is
+// reported on the error path and the items of its type are dropped.
+type ByTypeCollector struct {
+	sinks       map[reflect.Type]api.Sink
+	defaultSink api.Sink
+	input       <-chan interface{}
+	logf        api.LogFunc
+	errf        api.ErrorFunc
+}
+
+// ByType creates a *ByTypeCollector routing items to sinks by type.  If
+// defaultSink is nil, items with no registered type are dropped and
+// reported on the error path.
+func ByType(sinks map[reflect.Type]api.Sink, defaultSink api.Sink) *ByTypeCollector {
+	return &ByTypeCollector{sinks: sinks, defaultSink: defaultSink}
+}
+
+// SetInput sets the channel input
+func (c *ByTypeCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Open is the starting point that starts the collector
+func (c *ByTypeCollector) Open(ctx context.Context) <-chan error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	c.errf = autoctx.GetErrFunc(ctx)
+
+	util.Logfn(c.logf, "Opening by-type collector")
+	result := make(chan error)
+
+	if len(c.sinks) == 0 && c.defaultSink == nil {
+		go func() { result <- errors.New("ByType collector missing sinks") }()
+		return result
+	}
+
+	// a route is stopped once its sink returns early (i.e. it failed
+	// to open), items for a stopped route are dropped
+	type route struct {
+		input   chan interface{}
+		result  <-chan error
+		stopped bool
+		err     error
+	}
+	stop := func(r *route, err error) {
+		r.stopped, r.err = true, err
+		if err != nil {
+			util.Logfn(c.logf, err)
+			autoctx.Err(c.errf, api.Error(err.Error()))
+		}
+	}
+	open := func(snk api.Sink) *route {
+		r := &route{input: make(chan interface{}, 1024)}
+		snk.SetInput(r.input)
+		r.result = snk.Open(ctx)
+		return r
+	}
+	routes := make(map[reflect.Type]*route, len(c.sinks))
+	all := make([]*route, 0, len(c.sinks)+1)
+	for typ, snk := range c.sinks {
+		routes[typ] = open(snk)
+		all = append(all, routes[typ])
+	}
+	var defaultRoute *route
+	if c.defaultSink != nil {
+		defaultRoute = open(c.defaultSink)
+		all = append(all, defaultRoute)
+	}
+
+	go func() {
+		defer func() {
+			var firstErr error
+			for _, r := range all {
+				close(r.input)
+				if !r.stopped {
+					stop(r, <-r.result)
+				}
+				if r.err != nil && firstErr == nil {
+					firstErr = r.err
+				}
+			}
+			util.Logfn(c.logf, "Closing by-type collector")
+			if firstErr != nil {
+				result <- firstErr
+			}
+			close(result)
+		}()
+
+		for {
+			select {
+			case item, opened := <-c.input:
+				if !opened {
+					return
+				}
+				_, item = position(item)
+				r, ok := routes[reflect.TypeOf(item)]
+				if !ok {
+					r = defaultRoute
+				}
+				if r == nil {
+					msg := fmt.Sprintf("ByType collector has no sink for type %T", item)
+					util.Logfn(c.logf, msg)
+					autoctx.Err(c.errf, api.Error(msg))
+					continue
+				}
+				if r.stopped {
+					continue
+				}
+				select {
+				case r.input <- item:
+				case err := <-r.result:
+					stop(r, err)
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
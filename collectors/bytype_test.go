@@ -0,0 +1,118 @@
+package collectors
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+)
+
+func TestCollector_ByType(t *testing.T) {
+	type order struct{ ID int }
+	type refund struct{ ID int }
+
+	orders, refunds, others := Slice(), Slice(), Slice()
+	snk := ByType(map[reflect.Type]api.Sink{
+		reflect.TypeOf(order{}):  orders,
+		reflect.TypeOf(refund{}): refunds,
+	}, others)
+	in := make(chan interface{})
+	go func() {
+		in <- order{1}
+		in <- refund{2}
+		in <- order{3}
+		in <- "note"
+		close(in)
+	}()
+	snk.SetInput(in)
+
+	select {
+	case err := <-snk.Open(context.Background()):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if items := orders.Get(); len(items) != 2 || items[0] != (order{1}) || items[1] != (order{3}) {
+		t.Fatal("unexpected orders", items)
+	}
+	if items := refunds.Get(); len(items) != 1 || items[0] != (refund{2}) {
+		t.Fatal("unexpected refunds", items)
+	}
+	if items := others.Get(); len(items) != 1 || items[0] != "note" {
+		t.Fatal("unexpected default items", items)
+	}
+}
+
+func TestCollector_ByType_NoDefault(t *testing.T) {
+	ints := Slice()
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		errs = append(errs, err)
+	})
+	snk := ByType(map[reflect.Type]api.Sink{reflect.TypeOf(0): ints}, nil)
+	in := make(chan interface{})
+	go func() {
+		in <- 1
+		in <- "dropped"
+		close(in)
+	}()
+	snk.SetInput(in)
+
+	select {
+	case err := <-snk.Open(ctx):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	if items := ints.Get(); len(items) != 1 || items[0] != 1 {
+		t.Fatal("unexpected items", items)
+	}
+	if len(errs) != 1 {
+		t.Fatal("expecting error for item without sink, got", errs)
+	}
+}
+
+func TestCollector_ByType_SinkFailsToOpen(t *testing.T) {
+	ints, broken := Slice(), Func(nil)
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		errs = append(errs, err)
+	})
+	snk := ByType(map[reflect.Type]api.Sink{
+		reflect.TypeOf(0):  ints,
+		reflect.TypeOf(""): broken,
+	}, nil)
+	in := make(chan interface{})
+	go func() {
+		// more items than the route buffer holds
+		for i := 0; i < 2048; i++ {
+			in <- "dropped"
+		}
+		in <- 1
+		close(in)
+	}()
+	snk.SetInput(in)
+
+	select {
+	case err := <-snk.Open(ctx):
+		if err == nil {
+			t.Fatal("expecting error from sink that failed to open")
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	if items := ints.Get(); len(items) != 1 || items[0] != 1 {
+		t.Fatal("unexpected items", items)
+	}
+	if len(errs) == 0 {
+		t.Fatal("expecting sink error on the error path")
+	}
+}
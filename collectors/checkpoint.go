@@ -0,0 +1,31 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// position unwraps items carrying a source position, whether or not a
+// checkpointer is set, so sinks always receive the data item.
+// It returns the position (or nil) and the unwrapped data item.
+func position(item interface{}) (interface{}, interface{}) {
+	data, pos := api.UnwrapPosition(item)
+	return pos, data
+}
+
+// commit commits pos using the checkpointer.  Commit failures are
+// logged and sent to the error handler.
+func commit(ctx context.Context, cp api.Checkpointer, pos interface{}) {
+	if cp == nil || pos == nil {
+		return
+	}
+	if err := cp.Commit(pos); err != nil {
+		perr := fmt.Errorf("checkpoint commit failed for position %v: %s", pos, err)
+		util.Logfn(autoctx.GetLogFunc(ctx), perr)
+		autoctx.Err(autoctx.GetErrFunc(ctx), api.Error(perr.Error()))
+	}
+}
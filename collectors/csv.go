@@ -6,7 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"strconv"
 
 	"github.com/vladimirvivien/automi/api"
 	autoctx "github.com/vladimirvivien/automi/api/context"
@@ -18,16 +21,23 @@ import (
 // io.Writer or file.
 type CsvCollector struct {
 	filepath  string   // path for the file
+	atomic    bool     // write to a temp file renamed on completion
+	tmpPath   string   // path for the temp file
 	delimChar rune     // delimiter character
 	headers   []string // optional csv headers
+	crlf      bool     // use \r\n line endings
+	lineErr   error    // invalid line ending
+	trailing  bool     // write line ending after last record
 
 	snkParam  interface{}
 	file      *os.File
 	input     <-chan interface{}
 	snkWriter io.Writer
+	lines     *lineWriter
 	csvWriter *csv.Writer
 	logf      api.LogFunc
 	errf      api.ErrorFunc
+	cp        api.Checkpointer
 }
 
 // CSV creates a *CsvCollector value
@@ -35,6 +45,7 @@ func CSV(sink interface{}) *CsvCollector {
 	csv := &CsvCollector{
 		snkParam:  sink,
 		delimChar: ',',
+		trailing:  true,
 	}
 	return csv
 }
@@ -49,6 +60,53 @@ func (c *CsvCollector) Headers(headers []string) *CsvCollector {
 	return c
 }
 
+// LineEnding sets the line ending written after each record, either
+// "\n" (the default) or "\r\n".  Any other value causes Open to fail.
+func (c *CsvCollector) LineEnding(ending string) *CsvCollector {
+	c.crlf = ending == "\r\n"
+	if ending != "\n" && ending != "\r\n" {
+		c.crlf = false
+		c.lineErr = fmt.Errorf("unsupported CSV line ending %q", ending)
+	}
+	return c
+}
+
+// Atomic sets whether records, for a file sink specified by path, are first
+// written to a temporary file (in the same directory) that is renamed to the
+// target path once the collector completes successfully.  If writing fails, or
+// the stream is cancelled, the temporary file is removed and the target
+// path is left untouched.  With a Checkpointer, the position of the last
+// record is only committed once the file is renamed.
+func (c *CsvCollector) Atomic(atomic bool) *CsvCollector {
+	c.atomic = atomic
+	return c
+}
+
+// TrailingNewline sets whether a line ending is written after the last
+// record (the default) or only between records.
+func (c *CsvCollector) TrailingNewline(trailing bool) *CsvCollector {
+	c.trailing = trailing
+	return c
+}
+
+// Checkpointer sets an api.Checkpointer used to commit the source position
+// of StreamItem values (with Position set) once their records have been
+// written and flushed to the underlying writer.
+func (c *CsvCollector) Checkpointer(cp api.Checkpointer) *CsvCollector {
+	c.cp = cp
+	return c
+}
+
+// SetCheckpointer implements api.CheckpointSink
+func (c *CsvCollector) SetCheckpointer(cp api.Checkpointer) {
+	c.cp = cp
+}
+
+// GetCheckpointer returns the api.Checkpointer set on the collector, if any
+func (c *CsvCollector) GetCheckpointer() api.Checkpointer {
+	return c.cp
+}
+
 // SetInput sets the channel input
 func (c *CsvCollector) SetInput(in <-chan interface{}) {
 	c.input = in
@@ -71,12 +129,27 @@ func (c *CsvCollector) init(ctx context.Context) error {
 		c.delimChar = ','
 	}
 
+	if c.lineErr != nil {
+		return c.lineErr
+	}
+
 	if err := c.setupSink(); err != nil {
 		return err
 	}
 
-	c.csvWriter = csv.NewWriter(c.snkWriter)
+	writer := c.snkWriter
+	if !c.trailing {
+		ending := "\n"
+		if c.crlf {
+			ending = "\r\n"
+		}
+		c.lines = newLineWriter(c.snkWriter, ending)
+		writer = c.lines
+	}
+
+	c.csvWriter = csv.NewWriter(writer)
 	c.csvWriter.Comma = c.delimChar
+	c.csvWriter.UseCRLF = c.crlf
 
 	// write headers
 	if c.headers != nil && len(c.headers) > 0 {
@@ -91,30 +164,29 @@ func (c *CsvCollector) init(ctx context.Context) error {
 func (c *CsvCollector) Open(ctx context.Context) <-chan error {
 	result := make(chan error)
 	if err := c.init(ctx); err != nil {
+		if c.file != nil {
+			c.file.Close()
+		}
+		if c.tmpPath != "" {
+			os.Remove(c.tmpPath)
+		}
 		go func() { result <- err }()
 		return result
 	}
 
 	go func() {
+		failed := false
+		var pending interface{} // last position of an atomic file, not committed yet
 		defer func() {
 			util.Logfn(c.logf, "CSV collector closing")
-			// flush remaining bits
-			c.csvWriter.Flush()
-			if e := c.csvWriter.Error(); e != nil {
+			if e := c.close(failed); e != nil {
 				util.Logfn(c.logf, e)
 				autoctx.Err(c.errf, api.Error(e.Error()))
 				go func() { result <- e }()
 				return
 			}
-
-			// close file
-			if c.file != nil {
-				if e := c.file.Close(); e != nil {
-					util.Logfn(c.logf, e)
-					autoctx.Err(c.errf, api.Error(e.Error()))
-					go func() { result <- e }()
-					return
-				}
+			if !failed {
+				commit(ctx, c.cp, pending)
 			}
 			close(result)
 		}()
@@ -125,13 +197,13 @@ func (c *CsvCollector) Open(ctx context.Context) <-chan error {
 				if !opened {
 					return
 				}
+				pos, item := position(item)
 				data, ok := item.([]string)
 
-				if !ok { // bad situation, fail fast
-					msg := fmt.Sprintf("expecting []string, got unexpected type %T", data)
-					util.Logfn(c.logf, msg)
-					autoctx.Err(c.errf, api.Error(msg))
-					panic(msg)
+				if !ok { // unable to encode, handled according to policy
+					err := fmt.Errorf("expecting []string, got unexpected type %T", item)
+					autoctx.MarshalErr(ctx, c.logf, c.errf, err, item)
+					continue
 				}
 
 				if e := c.csvWriter.Write(data); e != nil {
@@ -139,6 +211,7 @@ func (c *CsvCollector) Open(ctx context.Context) <-chan error {
 					perr := fmt.Errorf("Unable to write record to file: %s ", e)
 					util.Logfn(c.logf, perr)
 					autoctx.Err(c.errf, api.Error(perr.Error()))
+					failed = true
 					continue
 				}
 
@@ -148,9 +221,20 @@ func (c *CsvCollector) Open(ctx context.Context) <-chan error {
 					perr := fmt.Errorf("IO flush error: %s", e)
 					util.Logfn(c.logf, perr)
 					autoctx.Err(c.errf, api.Error(perr.Error()))
+					failed = true
+					continue
 				}
+				// records of an atomic file are committed once it is renamed
+				if c.tmpPath != "" {
+					if pos != nil {
+						pending = pos
+					}
+					continue
+				}
+				commit(ctx, c.cp, pos)
 
 			case <-ctx.Done():
+				failed = true
 				return
 			}
 		}
@@ -159,6 +243,36 @@ func (c *CsvCollector) Open(ctx context.Context) <-chan error {
 	return result
 }
 
+// close flushes remaining records and closes the file, if any.  An atomic
+// file is renamed to its target path unless the collector failed (or was
+// cancelled), in which case the temporary file is removed.
+func (c *CsvCollector) close(failed bool) error {
+	c.csvWriter.Flush()
+	err := c.csvWriter.Error()
+
+	// drop the last line ending
+	if err == nil && c.lines != nil {
+		err = c.lines.finish(false)
+	}
+
+	if c.file != nil {
+		if e := c.file.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	if c.tmpPath == "" {
+		return err
+	}
+	if err != nil || failed {
+		if e := os.Remove(c.tmpPath); e != nil {
+			util.Logfn(c.logf, e)
+		}
+		return err
+	}
+	return os.Rename(c.tmpPath, c.filepath)
+}
+
 func (c *CsvCollector) setupSink() error {
 	if c.snkParam == nil {
 		return errors.New("missing CSV sink")
@@ -173,7 +287,17 @@ func (c *CsvCollector) setupSink() error {
 		c.snkWriter = wtr
 	}
 
-	if wtr, ok := c.snkParam.(string); ok {
+	if wtr, ok := c.snkParam.(string); ok && c.atomic {
+		f, err := createTemp(filepath.Dir(wtr), "."+filepath.Base(wtr)+".tmp")
+		if err != nil {
+			return err
+		}
+		util.Logfn(c.logf, fmt.Sprintf("CSV sink to file %s (atomic)", wtr))
+		c.snkWriter = f
+		c.file = f
+		c.filepath = wtr
+		c.tmpPath = f.Name()
+	} else if ok {
 		f, err := os.Create(wtr)
 		if err != nil {
 			return err
@@ -187,3 +311,19 @@ func (c *CsvCollector) setupSink() error {
 	}
 	return nil
 }
+
+// createTemp creates a new temporary file in dir, named with prefix and a
+// random suffix.  Unlike ioutil.TempFile (mode 0600), the file is created
+// with mode 0666 before umask, as os.Create does, so that the file renamed
+// to the target path has the mode of a file written directly.
+func createTemp(dir, prefix string) (*os.File, error) {
+	for i := 0; i < 10000; i++ {
+		name := filepath.Join(dir, prefix+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) {
+			continue
+		}
+		return f, err
+	}
+	return nil, fmt.Errorf("cannot create temporary file for %s in %s", prefix, dir)
+}
@@ -5,10 +5,13 @@ import (
 	"context"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
 	"github.com/vladimirvivien/automi/testutil"
 )
 
@@ -134,3 +137,243 @@ func BenchmarkCsvCollector(b *testing.B) {
 		b.Fatalf("Expected %d lines, got %d", N, lines)
 	}
 }
+
+func TestCsvCollector_LineEnding(t *testing.T) {
+	tests := []struct {
+		name     string
+		ending   string
+		trailing bool
+		expected string
+	}{
+		{name: "lf", ending: "\n", trailing: true, expected: "a,b\nc,d\n"},
+		{name: "crlf", ending: "\r\n", trailing: true, expected: "a,b\r\nc,d\r\n"},
+		{name: "lf-no-trailing", ending: "\n", expected: "a,b\nc,d"},
+		{name: "crlf-no-trailing", ending: "\r\n", expected: "a,b\r\nc,d"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := make(chan interface{})
+			go func() {
+				in <- []string{"a", "b"}
+				in <- []string{"c", "d"}
+				close(in)
+			}()
+			data := bytes.NewBufferString("")
+			csv := CSV(data).LineEnding(test.ending).TrailingNewline(test.trailing)
+			csv.SetInput(in)
+
+			select {
+			case err := <-csv.Open(context.Background()):
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(50 * time.Millisecond):
+				t.Fatal("collector took too long to open")
+			}
+			if data.String() != test.expected {
+				t.Fatalf("expecting %q, got %q", test.expected, data.String())
+			}
+		})
+	}
+}
+
+func TestCsvCollector_BadLineEnding(t *testing.T) {
+	csv := CSV(bytes.NewBufferString("")).LineEnding("\r")
+	csv.SetInput(make(chan interface{}))
+	select {
+	case err := <-csv.Open(context.Background()):
+		if err == nil {
+			t.Fatal("expecting error for unsupported line ending")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("collector took too long to open")
+	}
+}
+
+func TestCsvCollector_Atomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "automi-csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	target := filepath.Join(dir, "out.csv")
+
+	in := make(chan interface{})
+	next := make(chan struct{})
+	go func() {
+		in <- []string{"Christophe", "Petion", "Dessaline"}
+		<-next
+		in <- []string{"Toussaint", "Guerrier", "Caiman"}
+		close(in)
+	}()
+
+	csv := CSV(target).Atomic(true)
+	csv.SetInput(in)
+	result := csv.Open(context.Background())
+
+	// first record is written, the target must not exist yet
+	time.Sleep(10 * time.Millisecond)
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatal("target file exists before completion")
+	}
+	close(next)
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("collector took too long to open")
+	}
+
+	data, err := ioutil.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "Christophe,Petion,Dessaline\nToussaint,Guerrier,Caiman\n" {
+		t.Fatal("unexpected content", string(data))
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Fatal("temporary file not removed")
+	}
+
+	// the renamed file has the mode of a file created directly
+	ref, err := os.Create(filepath.Join(dir, "ref.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref.Close()
+	refInfo, err := os.Stat(ref.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode() != refInfo.Mode() {
+		t.Fatalf("expecting file mode %s, got %s", refInfo.Mode(), info.Mode())
+	}
+}
+
+func TestCsvCollector_AtomicCancel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "automi-csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	target := filepath.Join(dir, "out.csv")
+
+	in := make(chan interface{})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		in <- []string{"Christophe", "Petion", "Dessaline"}
+		cancel()
+	}()
+
+	csv := CSV(target).Atomic(true)
+	csv.SetInput(in)
+	select {
+	case err := <-csv.Open(ctx):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("collector took too long to open")
+	}
+
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Fatal("expecting no file after cancel, got", len(files))
+	}
+}
+
+func TestCsvCollector_AtomicCheckpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "automi-csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	target := filepath.Join(dir, "out.csv")
+
+	run := func(cancelled bool) []interface{} {
+		var committed []interface{}
+		cp := api.CheckpointFunc(func(pos interface{}) error {
+			committed = append(committed, pos)
+			return nil
+		})
+		in := make(chan interface{})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			in <- api.StreamItem{Item: []string{"a", "b"}, Position: 1}
+			in <- api.StreamItem{Item: []string{"c", "d"}, Position: 2}
+			if cancelled {
+				cancel()
+				return
+			}
+			close(in)
+		}()
+
+		csv := CSV(target).Atomic(true).Checkpointer(cp)
+		csv.SetInput(in)
+		select {
+		case err := <-csv.Open(ctx):
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("collector took too long to open")
+		}
+		return committed
+	}
+
+	if committed := run(true); len(committed) != 0 {
+		t.Fatal("expecting no position committed for a removed file, got", committed)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatal("expecting no file after cancel")
+	}
+	if committed := run(false); len(committed) != 1 || committed[0] != 2 {
+		t.Fatal("expecting last position committed after rename, got", committed)
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCsvCollector_MarshalErrorPolicy(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- []string{"a", "b"}
+		in <- 42 // not a record
+		in <- []string{"c", "d"}
+		close(in)
+	}()
+
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		errs = append(errs, err)
+	})
+	ctx = autoctx.WithMarshalErrorPolicy(ctx, api.MarshalDeadLetter)
+
+	data := bytes.NewBufferString("")
+	csv := CSV(data)
+	csv.SetInput(in)
+	select {
+	case err := <-csv.Open(ctx):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("collector took too long to open")
+	}
+
+	if actual := strings.TrimSpace(data.String()); actual != "a,b\nc,d" {
+		t.Fatal("collector did not get expected data, got: ", actual)
+	}
+	if len(errs) != 1 || errs[0].Item() == nil || errs[0].Item().Item != 42 {
+		t.Fatal("expecting dead letter error with item, got", errs)
+	}
+}
@@ -23,6 +23,7 @@ type FuncCollector struct {
 	logf  api.LogFunc
 	errf  api.ErrorFunc
 	f     CollectorFunc
+	cp    api.Checkpointer
 }
 
 // Func creates a new value *FuncCollector that
@@ -32,6 +33,25 @@ func Func(f CollectorFunc) *FuncCollector {
 	return &FuncCollector{f: f}
 }
 
+// Checkpointer sets an api.Checkpointer used to commit the source position
+// of StreamItem values (with Position set) after the collector function
+// successfully handles them.  Such items are unwrapped before being
+// passed to the collector function.
+func (c *FuncCollector) Checkpointer(cp api.Checkpointer) *FuncCollector {
+	c.cp = cp
+	return c
+}
+
+// SetCheckpointer implements api.CheckpointSink
+func (c *FuncCollector) SetCheckpointer(cp api.Checkpointer) {
+	c.cp = cp
+}
+
+// GetCheckpointer returns the api.Checkpointer set on the collector, if any
+func (c *FuncCollector) GetCheckpointer() api.Checkpointer {
+	return c.cp
+}
+
 // SetInput sets the channel input
 func (c *FuncCollector) SetInput(in <-chan interface{}) {
 	c.input = in
@@ -70,10 +90,13 @@ func (c *FuncCollector) Open(ctx context.Context) <-chan error {
 				if !opened {
 					return
 				}
-				if err := c.f(item); err != nil {
+				pos, data := position(item)
+				if err := c.f(data); err != nil {
 					util.Logfn(c.logf, err)
 					autoctx.Err(c.errf, api.Error(err.Error()))
+					continue
 				}
+				commit(ctx, c.cp, pos)
 			case <-ctx.Done():
 				return
 			}
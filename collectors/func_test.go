@@ -2,8 +2,12 @@ package collectors
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/vladimirvivien/automi/api"
 )
 
 func TestCollector_Func(t *testing.T) {
@@ -46,3 +50,37 @@ func TestCollector_FuncErr(t *testing.T) {
 		t.Fatal("Waited too long ...")
 	}
 }
+
+func TestCollector_FuncCheckpoint(t *testing.T) {
+	var events []string
+	f := Func(func(val interface{}) error {
+		events = append(events, fmt.Sprintf("write:%v", val))
+		return nil
+	}).Checkpointer(api.CheckpointFunc(func(pos interface{}) error {
+		events = append(events, fmt.Sprintf("commit:%v", pos))
+		return nil
+	}))
+
+	in := make(chan interface{})
+	go func() {
+		in <- api.StreamItem{Item: "A", Position: 1}
+		in <- api.StreamItem{Item: "B", Position: 2}
+		in <- api.StreamItem{Item: "C", Position: 3}
+		close(in)
+	}()
+	f.SetInput(in)
+
+	select {
+	case err := <-f.Open(context.TODO()):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	expected := "write:A commit:1 write:B commit:2 write:C commit:3"
+	if actual := strings.Join(events, " "); actual != expected {
+		t.Fatalf("expecting events [%s], got [%s]", expected, actual)
+	}
+}
@@ -0,0 +1,126 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// KTableCollector is a collector that materializes a change stream into a
+// table: each streamed item is upserted under its key, as returned by the
+// key function, so that the table holds the latest item of each key.
+// Items matching the tombstone predicate remove their key instead.
+type KTableCollector struct {
+	sync.RWMutex
+	keyFn     func(interface{}) interface{}
+	tombstone func(interface{}) bool
+	table     map[interface{}]interface{}
+	input     <-chan interface{}
+	logf      api.LogFunc
+	errf      api.ErrorFunc
+}
+
+// KTable creates a *KTableCollector keying items with keyFn
+func KTable(keyFn func(interface{}) interface{}) *KTableCollector {
+	return &KTableCollector{
+		keyFn: keyFn,
+		table: make(map[interface{}]interface{}),
+	}
+}
+
+// Tombstone sets the predicate identifying items that delete their key
+// from the table (i.e. a change record with a "deleted" flag)
+func (c *KTableCollector) Tombstone(pred func(interface{}) bool) *KTableCollector {
+	c.tombstone = pred
+	return c
+}
+
+// SetInput sets the channel input
+func (c *KTableCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Get returns the latest item of key, and whether the key is in the table.
+// It is safe to call while the stream runs.
+func (c *KTableCollector) Get(key interface{}) (interface{}, bool) {
+	c.RLock()
+	defer c.RUnlock()
+	item, ok := c.table[key]
+	return item, ok
+}
+
+// Snapshot returns a copy of the table.  It is safe to call while the
+// stream runs, the table is complete once the stream completes.
+func (c *KTableCollector) Snapshot() map[interface{}]interface{} {
+	c.RLock()
+	defer c.RUnlock()
+	snapshot := make(map[interface{}]interface{}, len(c.table))
+	for key, item := range c.table {
+		snapshot[key] = item
+	}
+	return snapshot
+}
+
+// Open is the starting point that starts the collector
+func (c *KTableCollector) Open(ctx context.Context) <-chan error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	c.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(c.logf, "Opening ktable collector")
+	result := make(chan error)
+
+	if c.input == nil {
+		go func() { result <- errors.New("KTable collector missing input") }()
+		return result
+	}
+	if c.keyFn == nil {
+		go func() { result <- errors.New("KTable collector missing key function") }()
+		return result
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(c.logf, "Closing ktable collector")
+			close(result)
+		}()
+
+		for {
+			select {
+			case item, opened := <-c.input:
+				if !opened {
+					return
+				}
+				_, item = position(item)
+				c.apply(item)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
+
+// apply upserts item, or deletes its key if item is a tombstone
+func (c *KTableCollector) apply(item interface{}) {
+	key := c.keyFn(item)
+	if key != nil && !reflect.TypeOf(key).Comparable() {
+		msg := fmt.Sprintf("ktable key of type %T is not comparable", key)
+		util.Logfn(c.logf, msg)
+		autoctx.Err(c.errf, api.ErrorWithItem(msg, &api.StreamItem{Item: item}))
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if c.tombstone != nil && c.tombstone(item) {
+		delete(c.table, key)
+		return
+	}
+	c.table[key] = item
+}
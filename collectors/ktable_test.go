@@ -0,0 +1,66 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCollector_KTable(t *testing.T) {
+	type change struct {
+		id      string
+		balance int
+		deleted bool
+	}
+	changes := []change{
+		{id: "a", balance: 10},
+		{id: "b", balance: 20},
+		{id: "a", balance: 15}, // update
+		{id: "c", balance: 30},
+		{id: "b", deleted: true}, // delete
+	}
+	in := make(chan interface{})
+	go func() {
+		for _, c := range changes {
+			in <- c
+		}
+		close(in)
+	}()
+
+	table := KTable(func(item interface{}) interface{} { return item.(change).id }).
+		Tombstone(func(item interface{}) bool { return item.(change).deleted })
+	table.SetInput(in)
+
+	select {
+	case err := <-table.Open(context.TODO()):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	snapshot := table.Snapshot()
+	if len(snapshot) != 2 || snapshot["a"].(change).balance != 15 || snapshot["c"].(change).balance != 30 {
+		t.Fatal("unexpected snapshot", snapshot)
+	}
+	if _, ok := table.Get("b"); ok {
+		t.Fatal("expecting deleted key to be removed")
+	}
+	if item, ok := table.Get("a"); !ok || item.(change).balance != 15 {
+		t.Fatal("unexpected item for key a", item)
+	}
+}
+
+func TestCollector_KTable_MissingKey(t *testing.T) {
+	table := KTable(nil)
+	table.SetInput(make(chan interface{}))
+	select {
+	case err := <-table.Open(context.TODO()):
+		if err == nil {
+			t.Fatal("expecting error for missing key function")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
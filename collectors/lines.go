@@ -0,0 +1,51 @@
+package collectors
+
+import (
+	"bytes"
+	"io"
+)
+
+// lineWriter wraps an io.Writer to hold back a trailing line ending
+// so that it can be dropped after the last line is written.
+type lineWriter struct {
+	writer io.Writer
+	ending []byte
+	held   []byte
+}
+
+func newLineWriter(w io.Writer, ending string) *lineWriter {
+	return &lineWriter{writer: w, ending: []byte(ending)}
+}
+
+// Write writes p, holding back any trailing bytes that
+// may be (the start of) a line ending.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	data := append(w.held, p...)
+	hold := 0
+	for n := len(w.ending); n > 0; n-- {
+		if bytes.HasSuffix(data, w.ending[:n]) {
+			hold = n
+			break
+		}
+	}
+	if _, err := w.writer.Write(data[:len(data)-hold]); err != nil {
+		return 0, err
+	}
+	w.held = append([]byte(nil), data[len(data)-hold:]...)
+	return len(p), nil
+}
+
+// finish writes the held bytes, dropping a held line ending
+// unless trailing is true.
+func (w *lineWriter) finish(trailing bool) error {
+	held := w.held
+	w.held = nil
+	if !trailing && bytes.Equal(held, w.ending) {
+		return nil
+	}
+	if len(held) == 0 {
+		return nil
+	}
+	_, err := w.writer.Write(held)
+	return err
+}
@@ -0,0 +1,22 @@
+package collectors
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLineWriter_SplitEnding(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := newLineWriter(buf, "\r\n")
+	for _, chunk := range []string{"a\r", "\nb\r", "\n"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.finish(false); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "a\r\nb" {
+		t.Fatalf("unexpected output %q", buf.String())
+	}
+}
@@ -0,0 +1,157 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// PartitionCollector is a collector that routes each streamed item to a
+// sink associated with the item's key. Sinks are created lazily, using the
+// provided function, the first time a key is seen and are all closed when
+// the stream completes.
+type PartitionCollector struct {
+	keyFn   func(interface{}) interface{}
+	newSink func(interface{}) api.Sink
+	maxOpen int
+	parts   map[interface{}]*partition
+	order   []interface{} // keys, least recently used first
+	input   <-chan interface{}
+	logf    api.LogFunc
+	errf    api.ErrorFunc
+}
+
+type partition struct {
+	input  chan interface{}
+	result <-chan error
+}
+
+// Partition creates a *PartitionCollector which uses keyFn to select the key
+// of each item and newSink to create the sink for a new key.
+func Partition(keyFn func(interface{}) interface{}, newSink func(key interface{}) api.Sink) *PartitionCollector {
+	return &PartitionCollector{
+		keyFn:   keyFn,
+		newSink: newSink,
+		parts:   make(map[interface{}]*partition),
+	}
+}
+
+// MaxOpen bounds the number of simultaneously open sinks.  When the bound is
+// reached, the least recently used sink is closed before a new one is
+// created.  If a closed key is seen again, a new sink is created for it so
+// the sink function must be prepared to be called more than once per key.
+func (c *PartitionCollector) MaxOpen(n int) *PartitionCollector {
+	c.maxOpen = n
+	return c
+}
+
+// SetInput sets the channel input
+func (c *PartitionCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Open is the starting point that starts the collector
+func (c *PartitionCollector) Open(ctx context.Context) <-chan error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	c.errf = autoctx.GetErrFunc(ctx)
+
+	util.Logfn(c.logf, "Opening partition collector")
+	result := make(chan error)
+
+	if c.keyFn == nil || c.newSink == nil {
+		go func() { result <- errors.New("Partition collector missing key or sink function") }()
+		return result
+	}
+
+	go func() {
+		var firstErr error
+		closePart := func(key interface{}) {
+			part := c.parts[key]
+			close(part.input)
+			if err := <-part.result; err != nil {
+				util.Logfn(c.logf, err)
+				autoctx.Err(c.errf, api.Error(err.Error()))
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+			delete(c.parts, key)
+		}
+
+		defer func() {
+			for _, key := range c.order {
+				closePart(key)
+			}
+			util.Logfn(c.logf, "Closing partition collector")
+			if firstErr != nil {
+				result <- firstErr
+			}
+			close(result)
+		}()
+
+		for {
+			select {
+			case item, opened := <-c.input:
+				if !opened {
+					return
+				}
+				_, item = position(item)
+				key := c.keyFn(item)
+				if key != nil && !reflect.TypeOf(key).Comparable() {
+					msg := fmt.Sprintf("partition key of type %T is not comparable", key)
+					util.Logfn(c.logf, msg)
+					autoctx.Err(c.errf, api.Error(msg))
+					continue
+				}
+
+				part, ok := c.parts[key]
+				if !ok {
+					if c.maxOpen > 0 && len(c.parts) >= c.maxOpen {
+						oldest := c.order[0]
+						c.order = c.order[1:]
+						closePart(oldest)
+					}
+					part = c.openPart(ctx, key)
+				} else {
+					c.touch(key)
+				}
+
+				select {
+				case part.input <- item:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
+
+// openPart creates and opens the sink for key
+func (c *PartitionCollector) openPart(ctx context.Context, key interface{}) *partition {
+	snk := c.newSink(key)
+	in := make(chan interface{}, 1024)
+	snk.SetInput(in)
+	part := &partition{input: in, result: snk.Open(ctx)}
+	c.parts[key] = part
+	c.order = append(c.order, key)
+	return part
+}
+
+// touch marks key as most recently used
+func (c *PartitionCollector) touch(key interface{}) {
+	for i, k := range c.order {
+		if k == key {
+			c.order = append(append(c.order[:i:i], c.order[i+1:]...), key)
+			return
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+)
+
+func TestCollector_PartitionMaxOpen(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		for _, v := range []string{"a1", "b1", "c1", "a2"} {
+			in <- v
+		}
+		close(in)
+	}()
+
+	created := map[string]int{}
+	p := Partition(func(item interface{}) interface{} {
+		return item.(string)[:1]
+	}, func(key interface{}) api.Sink {
+		created[key.(string)]++
+		return Null()
+	}).MaxOpen(2)
+	p.SetInput(in)
+
+	select {
+	case err := <-p.Open(context.TODO()):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	// opening c evicts a, so a is re-created for a2
+	if created["a"] != 2 || created["b"] != 1 || created["c"] != 1 {
+		t.Fatal("unexpected sink creation", created)
+	}
+	if len(p.parts) != 0 {
+		t.Fatal("expecting all sinks closed")
+	}
+}
@@ -0,0 +1,77 @@
+package collectors
+
+import (
+	"context"
+	"sync"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// RecordCollector records streamed items into an in-memory buffer
+// which can be replayed later (see emitters.FromBuffer).  Items and Len
+// are safe to call while recording is in progress (they return what has
+// been recorded so far) and once the recording stream completes.
+type RecordCollector struct {
+	mutex sync.RWMutex
+	items []interface{}
+	input <-chan interface{}
+	logf  api.LogFunc
+}
+
+// Record creates a new *RecordCollector
+func Record() *RecordCollector {
+	return new(RecordCollector)
+}
+
+// SetInput sets the channel input
+func (r *RecordCollector) SetInput(in <-chan interface{}) {
+	r.input = in
+}
+
+// Items returns a copy of the recorded items
+func (r *RecordCollector) Items() []interface{} {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	items := make([]interface{}, len(r.items))
+	copy(items, r.items)
+	return items
+}
+
+// Len returns the number of recorded items
+func (r *RecordCollector) Len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return len(r.items)
+}
+
+// Open is the starting point that starts recording
+func (r *RecordCollector) Open(ctx context.Context) <-chan error {
+	r.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(r.logf, "Opening record collector")
+	result := make(chan error)
+
+	go func() {
+		defer func() {
+			close(result)
+			util.Logfn(r.logf, "Closing record collector")
+		}()
+
+		for {
+			select {
+			case item, opened := <-r.input:
+				if !opened {
+					return
+				}
+				r.mutex.Lock()
+				r.items = append(r.items, item)
+				r.mutex.Unlock()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
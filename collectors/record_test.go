@@ -0,0 +1,41 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCollector_Record(t *testing.T) {
+	rec := Record()
+	in := make(chan interface{})
+	rec.SetInput(in)
+	result := rec.Open(context.TODO())
+
+	// read while recording is in progress
+	for i := 0; i < 5; i++ {
+		in <- i
+		if n := len(rec.Items()); n > i+1 {
+			t.Fatalf("unexpected recorded count %d after %d sends", n, i+1)
+		}
+	}
+	close(in)
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	items := rec.Items()
+	if len(items) != 5 || rec.Len() != 5 {
+		t.Fatal("unexpected recorded items", items)
+	}
+	items[0] = "changed"
+	if rec.Items()[0] != 0 {
+		t.Fatal("Items should return a copy of the recording")
+	}
+}
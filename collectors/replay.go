@@ -0,0 +1,146 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// ReplayWriter represents an unreliable destination (i.e. a network
+// connection) written one item at a time by a ReplayCollector.
+type ReplayWriter interface {
+	// Write delivers item, an error indicates the destination failed
+	Write(item interface{}) error
+	// Reconnect re-establishes the destination after a failure
+	Reconnect() error
+}
+
+// ReplayCollector writes streamed items to a ReplayWriter while keeping the
+// most recently written items in a bounded buffer (the oldest items are
+// dropped beyond the bound).  When a write fails, the collector reconnects
+// the writer and replays the buffered items, in order, providing
+// at-least-once delivery for the items within the buffer window.  Since
+// items may be delivered more than once, the destination is responsible for
+// de-duplicating them (writes must be idempotent).
+type ReplayCollector struct {
+	writer     ReplayWriter
+	size       int
+	maxRetries int
+	buffer     []interface{}
+	input      <-chan interface{}
+	logf       api.LogFunc
+	errf       api.ErrorFunc
+}
+
+// Replay creates a *ReplayCollector that buffers up to size
+// items written to writer.
+func Replay(writer ReplayWriter, size int) *ReplayCollector {
+	if size < 1 {
+		size = 1
+	}
+	return &ReplayCollector{
+		writer:     writer,
+		size:       size,
+		maxRetries: 3,
+	}
+}
+
+// MaxRetries sets the number of consecutive reconnect attempts (default 3)
+// made after a failure before the collector gives up with an error.
+func (c *ReplayCollector) MaxRetries(n int) *ReplayCollector {
+	c.maxRetries = n
+	return c
+}
+
+// SetInput sets the channel input
+func (c *ReplayCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Open is the starting point that starts the collector
+func (c *ReplayCollector) Open(ctx context.Context) <-chan error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	c.errf = autoctx.GetErrFunc(ctx)
+
+	util.Logfn(c.logf, "Opening replay collector")
+	result := make(chan error)
+
+	if c.writer == nil {
+		go func() { result <- errors.New("Replay collector missing writer") }()
+		return result
+	}
+
+	go func() {
+		var err error
+		defer func() {
+			util.Logfn(c.logf, "Closing replay collector")
+			if err != nil {
+				result <- err
+			}
+			close(result)
+		}()
+
+		for {
+			select {
+			case item, opened := <-c.input:
+				if !opened {
+					return
+				}
+				_, item = position(item)
+				c.buffer = append(c.buffer, item)
+				if len(c.buffer) > c.size {
+					c.buffer = c.buffer[1:]
+				}
+				if e := c.writer.Write(item); e != nil {
+					c.report(e)
+					if err = c.recover(ctx); err != nil {
+						return
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
+
+// recover reconnects the writer and replays the buffered items,
+// retrying up to maxRetries times.
+func (c *ReplayCollector) recover(ctx context.Context) error {
+	for attempt := 1; attempt <= c.maxRetries; attempt++ {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err := c.writer.Reconnect(); err != nil {
+			c.report(err)
+			continue
+		}
+		if err := c.replay(); err != nil {
+			c.report(err)
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("replay collector: giving up after %d attempts", c.maxRetries)
+}
+
+func (c *ReplayCollector) replay() error {
+	util.Logfn(c.logf, fmt.Sprintf("Replay collector replaying %d items", len(c.buffer)))
+	for _, item := range c.buffer {
+		if err := c.writer.Write(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ReplayCollector) report(err error) {
+	util.Logfn(c.logf, err)
+	autoctx.Err(c.errf, api.Error(err.Error()))
+}
@@ -0,0 +1,92 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// flakyWriter loses its connection once, after a number of writes
+type flakyWriter struct {
+	failAfter  int
+	writes     int
+	failed     bool
+	connected  bool
+	reconnects int
+	delivered  map[interface{}]int
+}
+
+func (w *flakyWriter) Write(item interface{}) error {
+	if !w.connected {
+		return errors.New("not connected")
+	}
+	w.writes++
+	if !w.failed && w.writes > w.failAfter {
+		w.failed = true
+		w.connected = false
+		return errors.New("connection reset")
+	}
+	w.delivered[item]++
+	return nil
+}
+
+func (w *flakyWriter) Reconnect() error {
+	w.reconnects++
+	w.connected = true
+	return nil
+}
+
+func TestCollector_Replay(t *testing.T) {
+	writer := &flakyWriter{failAfter: 5, connected: true, delivered: map[interface{}]int{}}
+	ctx := context.Background()
+	in := make(chan interface{})
+	go func() {
+		for i := 0; i < 20; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+
+	snk := Replay(writer, 4)
+	snk.SetInput(in)
+	select {
+	case err := <-snk.Open(ctx):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	for i := 0; i < 20; i++ {
+		if writer.delivered[i] == 0 {
+			t.Fatal("item lost", i)
+		}
+	}
+	if writer.reconnects != 1 {
+		t.Fatal("expecting one reconnect, got", writer.reconnects)
+	}
+	// items in the replay window are delivered twice
+	if writer.delivered[2] != 2 || writer.delivered[1] != 1 {
+		t.Fatal("unexpected replay window", writer.delivered)
+	}
+}
+
+func TestCollector_Replay_GiveUp(t *testing.T) {
+	writer := &flakyWriter{failAfter: 0, connected: true, delivered: map[interface{}]int{}}
+	in := make(chan interface{}, 1)
+	in <- 1
+	close(in)
+
+	snk := Replay(writer, 4).MaxRetries(0)
+	snk.SetInput(in)
+	select {
+	case err := <-snk.Open(context.Background()):
+		if err == nil {
+			t.Fatal("expecting error after giving up")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
@@ -0,0 +1,158 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// RoundRobinCollector is a collector that distributes streamed items, in
+// turn, across several sinks (i.e. to scale write throughput).  Each sink
+// is fed through its own buffer: when the buffer of the next sink is full,
+// the item goes to the following sink with room, so that a slow sink does
+// not hold back the others.  A sink that returns early (i.e. fails to open)
+// is reported on the error path and skipped.  All sinks are closed when the
+// stream completes.
+type RoundRobinCollector struct {
+	sinks   []api.Sink
+	stopped []bool
+	errs    []error
+	input   <-chan interface{}
+	logf    api.LogFunc
+	errf    api.ErrorFunc
+}
+
+// RoundRobin creates a *RoundRobinCollector distributing items to sinks
+func RoundRobin(sinks ...api.Sink) *RoundRobinCollector {
+	return &RoundRobinCollector{sinks: sinks}
+}
+
+// SetInput sets the channel input
+func (c *RoundRobinCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Open is the starting point that starts the collector
+func (c *RoundRobinCollector) Open(ctx context.Context) <-chan error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	c.errf = autoctx.GetErrFunc(ctx)
+
+	util.Logfn(c.logf, "Opening round-robin collector")
+	result := make(chan error)
+
+	if len(c.sinks) == 0 {
+		go func() { result <- errors.New("RoundRobin collector missing sinks") }()
+		return result
+	}
+
+	inputs := make([]chan interface{}, len(c.sinks))
+	results := make([]<-chan error, len(c.sinks))
+	for i, snk := range c.sinks {
+		inputs[i] = make(chan interface{}, 1024)
+		snk.SetInput(inputs[i])
+		results[i] = snk.Open(ctx)
+	}
+	c.stopped = make([]bool, len(c.sinks))
+	c.errs = make([]error, len(c.sinks))
+
+	go func() {
+		defer func() {
+			var firstErr error
+			for i := range inputs {
+				close(inputs[i])
+				if !c.stopped[i] {
+					c.stop(i, <-results[i])
+				}
+				if c.errs[i] != nil && firstErr == nil {
+					firstErr = c.errs[i]
+				}
+			}
+			util.Logfn(c.logf, "Closing round-robin collector")
+			if firstErr != nil {
+				result <- firstErr
+			}
+			close(result)
+		}()
+
+		next := 0
+		for {
+			select {
+			case item, opened := <-c.input:
+				if !opened {
+					return
+				}
+				_, item = position(item)
+				sent, ok := c.send(ctx, inputs, results, next, item)
+				if !ok {
+					return
+				}
+				next = (sent + 1) % len(inputs)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
+
+// stop marks sink i as stopped after it returned early (i.e. it failed to
+// open), no more items are sent to it
+func (c *RoundRobinCollector) stop(i int, err error) {
+	c.stopped[i], c.errs[i] = true, err
+	if err != nil {
+		util.Logfn(c.logf, err)
+		autoctx.Err(c.errf, api.Error(err.Error()))
+	}
+}
+
+// send sends item to the first running sink, starting at next, with room
+// in its buffer.  If all buffers are full, it blocks until one has room or
+// a sink stops.  It returns the index of the sink used (-1 if all sinks
+// stopped and item is dropped), or false if ctx is done.
+func (c *RoundRobinCollector) send(ctx context.Context, inputs []chan interface{}, results []<-chan error, next int, item interface{}) (int, bool) {
+	for i := 0; i < len(inputs); i++ {
+		idx := (next + i) % len(inputs)
+		if c.stopped[idx] {
+			continue
+		}
+		select {
+		case inputs[idx] <- item:
+			return idx, true
+		default:
+		}
+	}
+
+	for {
+		var cases []reflect.SelectCase
+		var sinks []int
+		for i, in := range inputs {
+			if c.stopped[i] {
+				continue
+			}
+			cases = append(cases,
+				reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(in), Send: reflect.ValueOf(&item).Elem()},
+				reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(results[i])},
+			)
+			sinks = append(sinks, i)
+		}
+		if len(sinks) == 0 {
+			return -1, true
+		}
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+		chosen, recv, _ := reflect.Select(cases)
+		if chosen == len(cases)-1 {
+			return 0, false
+		}
+		idx := sinks[chosen/2]
+		if chosen%2 == 0 {
+			return idx, true
+		}
+		err, _ := recv.Interface().(error)
+		c.stop(idx, err)
+	}
+}
@@ -0,0 +1,90 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+)
+
+func TestCollector_RoundRobin(t *testing.T) {
+	sinks := []*SliceCollector{Slice(), Slice(), Slice(), Slice()}
+	rr := RoundRobin(sinks[0], sinks[1], sinks[2], sinks[3])
+	in := make(chan interface{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+	rr.SetInput(in)
+
+	select {
+	case err := <-rr.Open(context.Background()):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	total := 0
+	for i, snk := range sinks {
+		count := len(snk.Get())
+		if count < 20 || count > 30 {
+			t.Fatalf("sink %d: expecting about 25 items, got %d", i, count)
+		}
+		total += count
+	}
+	if total != 100 {
+		t.Fatal("expecting 100 items, got", total)
+	}
+}
+
+func TestCollector_RoundRobin_NoSinks(t *testing.T) {
+	rr := RoundRobin()
+	rr.SetInput(make(chan interface{}))
+	select {
+	case err := <-rr.Open(context.Background()):
+		if err == nil {
+			t.Fatal("expecting error without sinks")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
+
+func TestCollector_RoundRobin_SinkFailsToOpen(t *testing.T) {
+	good := Slice()
+	rr := RoundRobin(Func(nil), good)
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		errs = append(errs, err)
+	})
+	in := make(chan interface{})
+	go func() {
+		// more items than a sink buffer holds
+		for i := 0; i < 4096; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+	rr.SetInput(in)
+
+	select {
+	case err := <-rr.Open(ctx):
+		if err == nil {
+			t.Fatal("expecting error from sink that failed to open")
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	if count := len(good.Get()); count < 4096-1024 {
+		t.Fatal("expecting remaining items in running sink, got", count)
+	}
+	if len(errs) == 0 {
+		t.Fatal("expecting sink error on the error path")
+	}
+}
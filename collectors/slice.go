@@ -43,6 +43,7 @@ func (s *SliceCollector) Open(ctx context.Context) <-chan error {
 				if !opened {
 					return
 				}
+				_, item = position(item)
 				s.slice = append(s.slice, item)
 			case <-ctx.Done():
 				return
@@ -0,0 +1,105 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// Executor represents a template that can render data into an io.Writer.
+// Both *text/template.Template and *html/template.Template satisfy it.
+type Executor interface {
+	Execute(io.Writer, interface{}) error
+}
+
+// TemplateCollector is a collector that renders streamed items
+// using a Go template and writes the result to an io.Writer.
+type TemplateCollector struct {
+	writer io.Writer
+	tmpl   Executor
+	asList bool
+	input  <-chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// Template creates a *TemplateCollector which executes tmpl for each
+// streamed item, writing the rendered text to w.
+func Template(w io.Writer, tmpl *template.Template) *TemplateCollector {
+	return TemplateWith(w, tmpl)
+}
+
+// TemplateWith is similar to Template but accepts any template Executor
+// (i.e. *html/template.Template).
+func TemplateWith(w io.Writer, tmpl Executor) *TemplateCollector {
+	return &TemplateCollector{writer: w, tmpl: tmpl}
+}
+
+// AsList collects all items and executes the template once, when the
+// stream completes, with all the items as a []interface{}.
+func (c *TemplateCollector) AsList() *TemplateCollector {
+	c.asList = true
+	return c
+}
+
+// SetInput sets the channel input
+func (c *TemplateCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Open is the starting point that starts the collector
+func (c *TemplateCollector) Open(ctx context.Context) <-chan error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	c.errf = autoctx.GetErrFunc(ctx)
+
+	util.Logfn(c.logf, "Opening template collector")
+	result := make(chan error)
+
+	if c.writer == nil || c.tmpl == nil {
+		go func() { result <- errors.New("Template collector missing writer or template") }()
+		return result
+	}
+
+	var items []interface{}
+	execute := func(data interface{}) {
+		if err := c.tmpl.Execute(c.writer, data); err != nil {
+			perr := fmt.Errorf("template execution failed: %s", err)
+			autoctx.MarshalErr(ctx, c.logf, c.errf, perr, data)
+		}
+	}
+
+	go func() {
+		defer func() {
+			if c.asList {
+				execute(items)
+			}
+			close(result)
+			util.Logfn(c.logf, "Closing template collector")
+		}()
+
+		for {
+			select {
+			case item, opened := <-c.input:
+				if !opened {
+					return
+				}
+				_, item = position(item)
+				if c.asList {
+					items = append(items, item)
+					continue
+				}
+				execute(item)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
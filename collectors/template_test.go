@@ -0,0 +1,82 @@
+package collectors
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+)
+
+func TestCollector_Template(t *testing.T) {
+	tests := []struct {
+		name     string
+		tmpl     string
+		asList   bool
+		expected string
+	}{
+		{name: "per item", tmpl: "{{.name}}={{.age}};", expected: "ann=30;bob=40;"},
+		{name: "as list", tmpl: "{{range .}}[{{.name}}]{{end}}", asList: true, expected: "[ann][bob]"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := make(chan interface{})
+			go func() {
+				in <- map[string]interface{}{"name": "ann", "age": 30}
+				in <- map[string]interface{}{"name": "bob", "age": 40}
+				close(in)
+			}()
+
+			var buf bytes.Buffer
+			c := Template(&buf, template.Must(template.New("t").Parse(test.tmpl)))
+			if test.asList {
+				c.AsList()
+			}
+			c.SetInput(in)
+
+			select {
+			case err := <-c.Open(context.TODO()):
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(50 * time.Millisecond):
+				t.Fatal("Waited too long ...")
+			}
+			if buf.String() != test.expected {
+				t.Fatalf("expecting %q, got %q", test.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestCollector_TemplateError(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- 12
+		close(in)
+	}()
+
+	errs := 0
+	ctx := autoctx.WithErrorFunc(context.TODO(), func(err api.StreamError) {
+		errs++
+	})
+	var buf bytes.Buffer
+	c := Template(&buf, template.Must(template.New("t").Parse("{{.Missing}}")))
+	c.SetInput(in)
+
+	select {
+	case err := <-c.Open(ctx):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	if errs != 1 {
+		t.Fatal("expecting template error routed to error func, got", errs)
+	}
+}
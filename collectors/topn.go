@@ -0,0 +1,136 @@
+package collectors
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// TopNCollector is a collector that keeps the n streamed items with the
+// highest scores, as returned by its score function, in a bounded heap.
+// The other items are discarded as they arrive.
+type TopNCollector struct {
+	sync.Mutex
+	n       int
+	scoreFn func(interface{}) float64
+	entries topEntries
+	seq     int64
+	input   <-chan interface{}
+	logf    api.LogFunc
+}
+
+// TopN creates a *TopNCollector keeping the n items with the highest scores
+func TopN(n int, scoreFn func(interface{}) float64) *TopNCollector {
+	return &TopNCollector{n: n, scoreFn: scoreFn}
+}
+
+// SetInput sets the channel input
+func (c *TopNCollector) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+// Get returns the top items sorted by decreasing score (items with equal
+// scores are in arrival order).  It is safe to call while the stream runs.
+func (c *TopNCollector) Get() []interface{} {
+	c.Lock()
+	entries := make(topEntries, len(c.entries))
+	copy(entries, c.entries)
+	c.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[j].less(entries[i]) })
+	result := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		result[i] = entry.item
+	}
+	return result
+}
+
+// Open is the starting point that starts the collector
+func (c *TopNCollector) Open(ctx context.Context) <-chan error {
+	c.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(c.logf, "Opening top-n collector")
+	result := make(chan error)
+
+	if c.input == nil {
+		go func() { result <- errors.New("TopN collector missing input") }()
+		return result
+	}
+	if c.scoreFn == nil || c.n < 1 {
+		go func() { result <- errors.New("TopN collector requires a score function and n > 0") }()
+		return result
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(c.logf, "Closing top-n collector")
+			close(result)
+		}()
+
+		for {
+			select {
+			case item, opened := <-c.input:
+				if !opened {
+					return
+				}
+				_, item = position(item)
+				c.add(item)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return result
+}
+
+// add keeps item if it scores among the top n
+func (c *TopNCollector) add(item interface{}) {
+	entry := topEntry{item: item, score: c.scoreFn(item), seq: c.seq}
+	c.seq++
+
+	c.Lock()
+	defer c.Unlock()
+	if len(c.entries) < c.n {
+		heap.Push(&c.entries, entry)
+		return
+	}
+	if c.entries[0].less(entry) {
+		c.entries[0] = entry
+		heap.Fix(&c.entries, 0)
+	}
+}
+
+// topEntry is a scored item, seq breaks ties in favor of earlier items
+type topEntry struct {
+	item  interface{}
+	score float64
+	seq   int64
+}
+
+// less reports whether e ranks below other
+func (e topEntry) less(other topEntry) bool {
+	if e.score != other.score {
+		return e.score < other.score
+	}
+	return e.seq > other.seq
+}
+
+// topEntries is a min-heap of entries, the lowest ranked at the root
+type topEntries []topEntry
+
+func (h topEntries) Len() int            { return len(h) }
+func (h topEntries) Less(i, j int) bool  { return h[i].less(h[j]) }
+func (h topEntries) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *topEntries) Push(x interface{}) { *h = append(*h, x.(topEntry)) }
+func (h *topEntries) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
@@ -0,0 +1,58 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCollector_TopN(t *testing.T) {
+	type player struct {
+		name  string
+		score float64
+	}
+	players := []player{{"ann", 30}, {"bob", 75}, {"cal", 10}, {"dee", 90}, {"eve", 75}, {"fay", 50}}
+	in := make(chan interface{})
+	go func() {
+		for _, p := range players {
+			in <- p
+		}
+		close(in)
+	}()
+
+	top := TopN(3, func(item interface{}) float64 { return item.(player).score })
+	top.SetInput(in)
+
+	select {
+	case err := <-top.Open(context.TODO()):
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	result := top.Get()
+	expected := []string{"dee", "bob", "eve"}
+	if len(result) != len(expected) {
+		t.Fatal("unexpected top-n", result)
+	}
+	for i, name := range expected {
+		if result[i].(player).name != name {
+			t.Fatal("unexpected top-n", result)
+		}
+	}
+}
+
+func TestCollector_TopN_MissingScore(t *testing.T) {
+	top := TopN(3, nil)
+	top.SetInput(make(chan interface{}))
+	select {
+	case err := <-top.Open(context.TODO()):
+		if err == nil {
+			t.Fatal("expecting error for missing score function")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
@@ -11,18 +11,35 @@ import (
 )
 
 type WriterCollector struct {
-	writer io.Writer
-	input  <-chan interface{}
-	logf   api.LogFunc
-	errf   api.ErrorFunc
+	writer     io.Writer
+	lineEnding string
+	trailing   bool
+	input      <-chan interface{}
+	logf       api.LogFunc
+	errf       api.ErrorFunc
 }
 
 func Writer(writer io.Writer) *WriterCollector {
 	return &WriterCollector{
-		writer: writer,
+		writer:   writer,
+		trailing: true,
 	}
 }
 
+// LineEnding sets a line ending (i.e. "\n" or "\r\n") written after
+// each item.  By default, items are written without line endings.
+func (c *WriterCollector) LineEnding(ending string) *WriterCollector {
+	c.lineEnding = ending
+	return c
+}
+
+// TrailingNewline sets whether the line ending is written after the last
+// item (the default) or only between items.
+func (c *WriterCollector) TrailingNewline(trailing bool) *WriterCollector {
+	c.trailing = trailing
+	return c
+}
+
 func (c *WriterCollector) SetInput(in <-chan interface{}) {
 	c.input = in
 }
@@ -34,8 +51,21 @@ func (c *WriterCollector) Open(ctx context.Context) <-chan error {
 	util.Logfn(c.logf, "Opening io.Writer collector")
 	result := make(chan error)
 
+	writer := c.writer
+	var lines *lineWriter
+	if c.lineEnding != "" {
+		lines = newLineWriter(c.writer, c.lineEnding)
+		writer = lines
+	}
+
 	go func() {
 		defer func() {
+			if lines != nil {
+				if err := lines.finish(c.trailing); err != nil {
+					util.Logfn(c.logf, err)
+					autoctx.Err(c.errf, api.Error(err.Error()))
+				}
+			}
 			close(result)
 			util.Logfn(c.logf, "Closing io.Writer collector")
 		}()
@@ -46,16 +76,17 @@ func (c *WriterCollector) Open(ctx context.Context) <-chan error {
 				if !opened {
 					return
 				}
+				_, val = position(val)
 				switch data := val.(type) {
 				case string:
-					_, err := fmt.Fprint(c.writer, data)
+					_, err := fmt.Fprint(writer, data)
 					if err != nil {
 						util.Logfn(c.logf, err)
 						autoctx.Err(c.errf, api.Error(err.Error()))
 						continue
 					}
 				case []byte:
-					if _, err := c.writer.Write(data); err != nil {
+					if _, err := writer.Write(data); err != nil {
 						util.Logfn(c.logf, err)
 						autoctx.Err(c.errf, api.Error(err.Error()))
 						continue
@@ -63,13 +94,19 @@ func (c *WriterCollector) Open(ctx context.Context) <-chan error {
 				default:
 					// other types are serialized using string representation
 					// extracted by fmt
-					_, err := fmt.Fprintf(c.writer, "%v", data)
+					_, err := fmt.Fprintf(writer, "%v", data)
 					if err != nil {
 						util.Logfn(c.logf, err)
 						autoctx.Err(c.errf, api.Error(err.Error()))
 						continue
 					}
 				}
+				if c.lineEnding != "" {
+					if _, err := io.WriteString(writer, c.lineEnding); err != nil {
+						util.Logfn(c.logf, err)
+						autoctx.Err(c.errf, api.Error(err.Error()))
+					}
+				}
 			case <-ctx.Done():
 				return
 			}
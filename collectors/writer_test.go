@@ -59,3 +59,44 @@ func TestCollector_Writer_String(t *testing.T) {
 	}
 
 }
+
+func TestCollector_Writer_LineEnding(t *testing.T) {
+	tests := []struct {
+		name     string
+		ending   string
+		trailing bool
+		expected string
+	}{
+		{name: "default", expected: "abc"},
+		{name: "lf", ending: "\n", trailing: true, expected: "a\nb\nc\n"},
+		{name: "crlf", ending: "\r\n", trailing: true, expected: "a\r\nb\r\nc\r\n"},
+		{name: "lf-no-trailing", ending: "\n", expected: "a\nb\nc"},
+		{name: "crlf-no-trailing", ending: "\r\n", expected: "a\r\nb\r\nc"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sink := bytes.NewBufferString("")
+			w := Writer(sink).LineEnding(test.ending).TrailingNewline(test.trailing)
+			in := make(chan interface{})
+			go func() {
+				in <- "a"
+				in <- []byte("b")
+				in <- "c"
+				close(in)
+			}()
+			w.SetInput(in)
+			select {
+			case err := <-w.Open(context.TODO()):
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(50 * time.Millisecond):
+				t.Fatal("Waited too long ...")
+			}
+			if sink.String() != test.expected {
+				t.Fatalf("expecting %q, got %q", test.expected, sink.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package emitters
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// Buffer represents an in-memory recording of a stream
+// such as the one captured by collectors.Record.
+type Buffer interface {
+	Items() []interface{}
+}
+
+// BufferEmitter replays the items of a recorded stream buffer.
+type BufferEmitter struct {
+	buffer Buffer
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+// FromBuffer creates a *BufferEmitter that emits the items recorded in buf.
+// A new emitter should be created for each replay.
+func FromBuffer(buf Buffer) *BufferEmitter {
+	return &BufferEmitter{
+		buffer: buf,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// GetOutput returns the output channel of this source node
+func (e *BufferEmitter) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// Open opens the source node to start replaying the recorded items
+func (e *BufferEmitter) Open(ctx context.Context) error {
+	if e.buffer == nil {
+		return errors.New("BufferEmitter requires buffer")
+	}
+	e.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(e.logf, "Opening buffer emitter")
+
+	// take a snapshot so the replay is not affected by later recordings
+	items := e.buffer.Items()
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			util.Logfn(e.logf, "Buffer emitter closing")
+			cancel()
+			close(e.output)
+		}()
+		for _, item := range items {
+			select {
+			case e.output <- item:
+			case <-exeCtx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,38 @@
+package emitters
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type testBuffer []interface{}
+
+func (b testBuffer) Items() []interface{} {
+	return b
+}
+
+func TestEmitter_Buffer(t *testing.T) {
+	e := FromBuffer(testBuffer{"A", "B", "C"})
+	var result []interface{}
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for item := range e.GetOutput() {
+			result = append(result, item)
+		}
+	}()
+
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-wait:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("waited too long")
+	}
+	if len(result) != 3 || result[0] != "A" || result[2] != "C" {
+		t.Fatal("unexpected replayed items", result)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"unicode/utf8"
 
 	"github.com/vladimirvivien/automi/api"
 	autoctx "github.com/vladimirvivien/automi/api/context"
@@ -14,19 +15,26 @@ import (
 )
 
 // CsvEmitter implements an Emitter node that gets its content from the
-// specified io.Reader and emits each record as []string.
+// specified io.Reader and emits each record as []string.  Records are
+// parsed and emitted one at a time as they are read, the source is never
+// buffered in its entirety, making it suitable for very large files.
 type CsvEmitter struct {
 	filepath    string   // path for the file
 	delimChar   rune     // Delimiter charater, defaults to comma
+	delimString string   // multi-character delimiter, overrides delimChar
+	delimErr    error    // invalid delimiter
 	commentChar rune     // Charater indicating line is a cg.org/omment
 	headers     []string // Column header names (specified here or read from file)
 	hasHeaders  bool     // indicates first row is for headers (default false).
 	fieldCount  int      // if greater than zero is used to validate field count
+	inferSample int      // number of rows sampled to infer column types
+	nullValues  []string // field values emitted as nil
+	nulls       map[string]bool
 
 	srcParam  interface{}
 	file      *os.File
 	srcReader io.Reader
-	csvReader *csv.Reader
+	csvReader recordReader
 	logf      api.LogFunc
 	errf      api.ErrorFunc
 	output    chan interface{}
@@ -52,6 +60,25 @@ func (c *CsvEmitter) DelimChar(char rune) *CsvEmitter {
 	return c
 }
 
+// DelimString sets a delimiter of one or more characters (i.e. "||") for
+// dialects that encoding/csv can't handle.  Records delimited by several
+// characters are parsed by splitting each line on the delimiter, quoted
+// fields are not supported.  A single character delimiter is the same as
+// DelimChar.
+func (c *CsvEmitter) DelimString(delim string) *CsvEmitter {
+	c.delimErr = nil
+	c.delimString = ""
+	switch {
+	case delim == "":
+		c.delimErr = errors.New("CSV delimiter must not be empty")
+	case utf8.RuneCountInString(delim) == 1:
+		c.delimChar, _ = utf8.DecodeRuneInString(delim)
+	default:
+		c.delimString = delim
+	}
+	return c
+}
+
 // CommentChar sets the character used to indicate comment lines
 func (c *CsvEmitter) CommentChar(char rune) *CsvEmitter {
 	c.commentChar = char
@@ -64,6 +91,26 @@ func (c *CsvEmitter) HasHeaders() *CsvEmitter {
 	return c
 }
 
+// InferTypes samples the first n rows of data to infer the type of
+// each column (int64, float64, bool, time.Time, or string) and emits
+// each record as []interface{} with values converted to the inferred
+// types instead of []string.  Columns with mixed or unparsable values
+// in the sample remain strings, values that fail to convert after the
+// sample are emitted as strings, and empty values are emitted as nil.
+func (c *CsvEmitter) InferTypes(n int) *CsvEmitter {
+	c.inferSample = n
+	return c
+}
+
+// NullValues sets field values (i.e. "NULL", "N/A", "-") that are emitted
+// as nil instead of strings.  When null values are set, each record is
+// emitted as []interface{} instead of []string.  Null values are ignored
+// when inferring column types (see InferTypes).
+func (c *CsvEmitter) NullValues(values ...string) *CsvEmitter {
+	c.nullValues = append(c.nullValues, values...)
+	return c
+}
+
 // init internal initialization method
 func (c *CsvEmitter) init(ctx context.Context) error {
 	c.logf = autoctx.GetLogFunc(ctx)
@@ -80,16 +127,33 @@ func (c *CsvEmitter) init(ctx context.Context) error {
 		c.commentChar = '#'
 	}
 
+	c.nulls = make(map[string]bool)
+	for _, value := range c.nullValues {
+		c.nulls[value] = true
+	}
+	if c.inferSample > 0 {
+		c.nulls[""] = true
+	}
+
+	if c.delimErr != nil {
+		return c.delimErr
+	}
+
 	// setup source
 	if err := c.setupSource(); err != nil {
 		return err
 	}
 
-	c.csvReader = csv.NewReader(c.srcReader)
-	c.csvReader.Comment = c.commentChar
-	c.csvReader.Comma = c.delimChar
-	c.csvReader.TrimLeadingSpace = true
-	c.csvReader.LazyQuotes = true
+	if c.delimString != "" {
+		c.csvReader = newSplitReader(c.srcReader, c.delimString, c.commentChar)
+	} else {
+		reader := csv.NewReader(c.srcReader)
+		reader.Comment = c.commentChar
+		reader.Comma = c.delimChar
+		reader.TrimLeadingSpace = true
+		reader.LazyQuotes = true
+		c.csvReader = reader
+	}
 
 	// resolve header and field count
 	if c.hasHeaders {
@@ -135,20 +199,66 @@ func (c *CsvEmitter) Open(ctx context.Context) (err error) {
 			close(c.output)
 		}()
 
+		var sample [][]string
+		var kinds []columnKind
+		emit := func(row []string) bool {
+			var item interface{} = row
+			if c.inferSample > 0 || len(c.nulls) > 0 {
+				item = convertRow(row, kinds, c.nulls)
+			}
+			select {
+			case c.output <- item:
+				return true
+			case <-exeCtx.Done():
+				return false
+			}
+		}
+		// infer types from sampled rows, then emit them
+		flushSample := func() bool {
+			if kinds != nil {
+				return true
+			}
+			kinds = inferKinds(sample, c.nulls)
+			for _, row := range sample {
+				if !emit(row) {
+					return false
+				}
+			}
+			sample = nil
+			return true
+		}
+
 		for {
 			row, err := c.csvReader.Read()
 			if err != nil {
 				if err == io.EOF {
+					if c.inferSample > 0 {
+						flushSample()
+					}
 					return
 				}
+				if _, ok := err.(*csv.ParseError); ok {
+					// malformed row, handled according to policy
+					autoctx.MarshalErr(exeCtx, c.logf, c.errf, fmt.Errorf("Error reading row: %s", err), row)
+					continue
+				}
 				util.Logfn(c.logf, fmt.Errorf("Error reading row: %s", err))
 				autoctx.Err(c.errf, api.Error(err.Error()))
 				continue
 			}
 
-			select {
-			case c.output <- row:
-			case <-exeCtx.Done():
+			if c.inferSample > 0 && kinds == nil {
+				sample = append(sample, row)
+				if len(sample) < c.inferSample {
+					continue
+				}
+				if !flushSample() {
+					return
+				}
+				continue
+			}
+
+			if !emit(row) {
 				return
 			}
 		}
@@ -0,0 +1,57 @@
+package emitters
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// recordReader reads CSV records, it is implemented by *csv.Reader
+type recordReader interface {
+	Read() ([]string, error)
+}
+
+// splitReader reads records by splitting lines on a (multi-character)
+// delimiter.  Like the csv.Reader used by CsvEmitter, it skips empty and
+// comment lines, trims leading spaces of fields, and expects all records
+// to have the field count of the first one.
+type splitReader struct {
+	reader  *bufio.Reader
+	delim   string
+	comment rune
+	fields  int
+	line    int
+}
+
+func newSplitReader(r io.Reader, delim string, comment rune) *splitReader {
+	return &splitReader{reader: bufio.NewReader(r), delim: delim, comment: comment}
+}
+
+// Read returns the next record, or io.EOF when there are no more records.
+// A record with an unexpected field count is returned along with a
+// *csv.ParseError (as with csv.Reader).
+func (r *splitReader) Read() ([]string, error) {
+	for {
+		line, err := r.reader.ReadString('\n')
+		if line == "" && err != nil {
+			return nil, err
+		}
+		r.line++
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" || (r.comment != 0 && strings.HasPrefix(line, string(r.comment))) {
+			continue
+		}
+
+		record := strings.Split(line, r.delim)
+		for i := range record {
+			record[i] = strings.TrimLeft(record[i], " \t")
+		}
+		if r.fields == 0 {
+			r.fields = len(record)
+		} else if len(record) != r.fields {
+			return record, &csv.ParseError{StartLine: r.line, Line: r.line, Column: 1, Err: csv.ErrFieldCount}
+		}
+		return record, nil
+	}
+}
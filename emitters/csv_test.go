@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
 	"github.com/vladimirvivien/automi/testutil"
 )
 
@@ -166,3 +169,225 @@ func Benchmark_CSV(b *testing.B) {
 	}
 	m.RUnlock()
 }
+
+func TestEmitter_CSV_Streaming(t *testing.T) {
+	rdr, wtr := io.Pipe()
+	next := make(chan struct{})
+
+	// a single writer produces the header then one row each time
+	// it is signaled, so rows can only be emitted if the emitter
+	// streams them before the rest of the input exists.
+	go func() {
+		defer wtr.Close()
+		fmt.Fprint(wtr, "Col1,Col2,Col3\n")
+		for range next {
+			fmt.Fprintf(wtr, "%s,%s,%s\n", testutil.GenWord(), testutil.GenWord(), testutil.GenWord())
+		}
+	}()
+
+	csv := CSV(rdr).HasHeaders()
+	if err := csv.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		next <- struct{}{}
+		select {
+		case row := <-csv.GetOutput():
+			if len(row.([]string)) != 3 {
+				t.Fatal("unexpected row", row)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("row not emitted before end of input")
+		}
+	}
+
+	close(next)
+	select {
+	case _, opened := <-csv.GetOutput():
+		if opened {
+			t.Fatal("unexpected row after end of input")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("emitter not closed after end of input")
+	}
+}
+
+func TestEmitter_CSV_InferTypes(t *testing.T) {
+	data := "name,age,score,active\nMays,40,7.5,true\nAaron,32,8,false\nMantle,,9.25,maybe\nBonds,x,6,true"
+	csv := CSV(strings.NewReader(data)).HasHeaders().InferTypes(3)
+
+	var rows [][]interface{}
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for row := range csv.GetOutput() {
+			rows = append(rows, row.([]interface{}))
+		}
+	}()
+
+	if err := csv.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-wait:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Opening Source took too long")
+	}
+
+	if len(rows) != 4 {
+		t.Fatal("unexpected row count", len(rows))
+	}
+	if rows[0][0] != "Mays" || rows[0][1] != int64(40) || rows[0][2] != 7.5 {
+		t.Fatal("unexpected typed row", rows[0])
+	}
+	if rows[1][2] != float64(8) {
+		t.Fatal("expecting mixed int/float column as float64, got", rows[1][2])
+	}
+	if rows[2][1] != nil || rows[2][3] != "maybe" || rows[0][3] != "true" {
+		t.Fatal("unexpected empty or mixed values", rows[2])
+	}
+	if rows[3][1] != "x" {
+		t.Fatal("expecting unparsable value after sample to remain a string", rows[3][1])
+	}
+}
+
+func TestEmitter_CSV_NullValues(t *testing.T) {
+	data := "name,age,team\nMays,40,NULL\nAaron,N/A,-\nMantle,37,"
+	csv := CSV(strings.NewReader(data)).HasHeaders().NullValues("NULL", "N/A", "-")
+
+	var rows [][]interface{}
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for row := range csv.GetOutput() {
+			rows = append(rows, row.([]interface{}))
+		}
+	}()
+
+	if err := csv.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-wait:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Opening Source took too long")
+	}
+
+	if len(rows) != 3 {
+		t.Fatal("unexpected row count", len(rows))
+	}
+	if rows[0][2] != nil || rows[1][1] != nil || rows[1][2] != nil {
+		t.Fatal("expecting null tokens as nil", rows)
+	}
+	if rows[0][1] != "40" || rows[2][2] != "" {
+		t.Fatal("expecting other values as strings", rows)
+	}
+}
+
+func TestEmitter_CSV_NullValuesInferTypes(t *testing.T) {
+	data := "name,age\nMays,40\nAaron,N/A\nMantle,37"
+	csv := CSV(strings.NewReader(data)).HasHeaders().NullValues("N/A").InferTypes(3)
+
+	var rows [][]interface{}
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for row := range csv.GetOutput() {
+			rows = append(rows, row.([]interface{}))
+		}
+	}()
+
+	if err := csv.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-wait:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Opening Source took too long")
+	}
+
+	if len(rows) != 3 || rows[0][1] != int64(40) || rows[1][1] != nil || rows[2][1] != int64(37) {
+		t.Fatal("expecting null tokens not to break numeric inference", rows)
+	}
+}
+
+func TestEmitter_CSV_MarshalErrorPolicy(t *testing.T) {
+	data := "a,b\nc,d,e\nf,g\n" // second row has an extra field
+	tests := []struct {
+		policy   api.MarshalErrorPolicy
+		errors   int
+		withItem bool
+	}{
+		{policy: api.MarshalError, errors: 1},
+		{policy: api.MarshalSkip, errors: 0},
+		{policy: api.MarshalDeadLetter, errors: 1, withItem: true},
+	}
+
+	for _, test := range tests {
+		var errs []api.StreamError
+		ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+			errs = append(errs, err)
+		})
+		ctx = autoctx.WithMarshalErrorPolicy(ctx, test.policy)
+
+		csv := CSV(strings.NewReader(data))
+		if err := csv.Open(ctx); err != nil {
+			t.Fatal(err)
+		}
+		var rows [][]string
+		for row := range csv.GetOutput() {
+			rows = append(rows, row.([]string))
+		}
+
+		if len(rows) != 2 || rows[0][0] != "a" || rows[1][0] != "f" {
+			t.Fatalf("policy %d: unexpected rows %v", test.policy, rows)
+		}
+		if len(errs) != test.errors {
+			t.Fatalf("policy %d: expecting %d errors, got %v", test.policy, test.errors, errs)
+		}
+		if test.withItem {
+			item := errs[0].Item()
+			if item == nil || len(item.Item.([]string)) != 3 {
+				t.Fatalf("policy %d: expecting malformed row with error, got %v", test.policy, item)
+			}
+		} else if test.errors > 0 && errs[0].Item() != nil {
+			t.Fatalf("policy %d: unexpected item with error", test.policy)
+		}
+	}
+}
+
+func TestEmitter_CSV_DelimString(t *testing.T) {
+	data := "name||city||zip\n# comment\nAlice|| Paris||75001\r\n\nBob||Lyon||69001"
+	csv := CSV(strings.NewReader(data)).DelimString("||").HasHeaders()
+	if err := csv.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var rows [][]string
+	for row := range csv.GetOutput() {
+		rows = append(rows, row.([]string))
+	}
+	if len(csv.headers) != 3 || csv.headers[2] != "zip" {
+		t.Fatal("unexpected headers", csv.headers)
+	}
+	expected := [][]string{{"Alice", "Paris", "75001"}, {"Bob", "Lyon", "69001"}}
+	if len(rows) != len(expected) {
+		t.Fatal("unexpected rows", rows)
+	}
+	for i := range expected {
+		if strings.Join(rows[i], "|") != strings.Join(expected[i], "|") {
+			t.Fatalf("expecting row %v, got %v", expected[i], rows[i])
+		}
+	}
+}
+
+func TestEmitter_CSV_DelimString_Invalid(t *testing.T) {
+	if err := CSV(strings.NewReader("a,b")).DelimString("").Open(context.Background()); err == nil {
+		t.Fatal("expecting error for empty delimiter")
+	}
+	csv := CSV(nil).DelimString(";")
+	if csv.delimChar != ';' || csv.delimString != "" {
+		t.Fatal("expecting single character delimiter to set DelimChar")
+	}
+}
@@ -0,0 +1,105 @@
+package emitters
+
+import (
+	"strconv"
+	"time"
+)
+
+// columnKind is the inferred type of a CSV column
+type columnKind int
+
+const (
+	kindUnknown columnKind = iota // no (non-empty) sampled values
+	kindInt
+	kindFloat
+	kindBool
+	kindTime
+	kindString
+)
+
+// kindOf returns the narrowest kind for value
+func kindOf(value string) columnKind {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return kindInt
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return kindFloat
+	}
+	if _, err := strconv.ParseBool(value); err == nil {
+		return kindBool
+	}
+	if _, err := time.Parse(time.RFC3339, value); err == nil {
+		return kindTime
+	}
+	return kindString
+}
+
+// merge returns the kind compatible with both k and other
+func (k columnKind) merge(other columnKind) columnKind {
+	switch {
+	case k == kindUnknown:
+		return other
+	case other == kindUnknown || k == other:
+		return k
+	case (k == kindInt && other == kindFloat) || (k == kindFloat && other == kindInt):
+		return kindFloat
+	}
+	return kindString
+}
+
+// inferKinds infers the kind of each column from sampled rows,
+// ignoring null values
+func inferKinds(rows [][]string, nulls map[string]bool) []columnKind {
+	kinds := []columnKind{}
+	for _, row := range rows {
+		for i, value := range row {
+			if i >= len(kinds) {
+				kinds = append(kinds, kindUnknown)
+			}
+			if nulls[value] {
+				continue
+			}
+			kinds[i] = kinds[i].merge(kindOf(value))
+		}
+	}
+	return kinds
+}
+
+// convertRow converts row values using the column kinds (strings
+// when kinds is nil), null values are converted to nil
+func convertRow(row []string, kinds []columnKind, nulls map[string]bool) []interface{} {
+	result := make([]interface{}, len(row))
+	for i, value := range row {
+		if nulls[value] {
+			continue
+		}
+		kind := kindString
+		if i < len(kinds) {
+			kind = kinds[i]
+		}
+		result[i] = convertValue(value, kind)
+	}
+	return result
+}
+
+func convertValue(value string, kind columnKind) interface{} {
+	switch kind {
+	case kindInt:
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return v
+		}
+	case kindFloat:
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	case kindBool:
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	case kindTime:
+		if v, err := time.Parse(time.RFC3339, value); err == nil {
+			return v
+		}
+	}
+	return value
+}
@@ -0,0 +1,27 @@
+package emitters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCSVTypes_InferKinds(t *testing.T) {
+	kinds := inferKinds([][]string{
+		{"1", "1.5", "true", "2019-06-01T10:00:00Z", "a", ""},
+		{"2", "3", "false", "2019-06-02T10:00:00Z", "1", ""},
+	}, map[string]bool{"": true})
+	expected := []columnKind{kindInt, kindFloat, kindBool, kindTime, kindString, kindUnknown}
+	for i := range expected {
+		if kinds[i] != expected[i] {
+			t.Fatalf("column %d: expecting kind %d, got %d", i, expected[i], kinds[i])
+		}
+	}
+
+	row := convertRow([]string{"3", "2", "true", "2019-06-03T10:00:00Z", "b", "z", "extra"}, kinds, nil)
+	if row[0] != int64(3) || row[1] != float64(2) || row[2] != true || row[4] != "b" || row[6] != "extra" {
+		t.Fatal("unexpected converted row", row)
+	}
+	if _, ok := row[3].(time.Time); !ok {
+		t.Fatal("expecting time.Time value, got", row[3])
+	}
+}
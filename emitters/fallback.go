@@ -0,0 +1,152 @@
+package emitters
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// FallbackEmitter is an emitter that emits the items of a primary emitter
+// and, if the primary fails before completing, continues with the items of
+// a fallback emitter (i.e. a cache or a backup file).  The primary fails
+// when it cannot be opened or when it reports an error to the error path
+// (i.e. a read or fetch error).  Items emitted by the primary before it
+// failed are kept, the primary is then cancelled and the remaining items
+// come from the fallback.
+type FallbackEmitter struct {
+	primary  api.Emitter
+	fallback func(err error) api.Emitter
+	output   chan interface{}
+	logf     api.LogFunc
+	errf     api.ErrorFunc
+}
+
+// ConcatFallback creates a *FallbackEmitter.  The fallback function is
+// called with the error of the primary and returns the emitter to continue
+// with, if it returns nil the error is reported to the error path and the
+// emitter completes.  Emitters that implement api.Source are opened.
+func ConcatFallback(primary api.Emitter, fallback func(err error) api.Emitter) *FallbackEmitter {
+	return &FallbackEmitter{
+		primary:  primary,
+		fallback: fallback,
+		output:   make(chan interface{}, 1024),
+	}
+}
+
+// GetOutput returns the output channel of this source node
+func (e *FallbackEmitter) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// Open opens the primary emitter and starts emitting items
+func (e *FallbackEmitter) Open(ctx context.Context) error {
+	if e.primary == nil || e.fallback == nil {
+		return errors.New("fallback emitter missing primary emitter or fallback function")
+	}
+	e.logf = autoctx.GetLogFunc(ctx)
+	e.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(e.logf, "Fallback emitter starting")
+
+	// errors reported by the primary trigger the fallback
+	failed := make(chan error, 1)
+	primCtx, cancelPrim := context.WithCancel(ctx)
+	primCtx = autoctx.WithErrorFunc(primCtx, func(err api.StreamError) {
+		select {
+		case failed <- err:
+		default:
+		}
+	})
+
+	var openErr error
+	if src, ok := e.primary.(api.Source); ok {
+		openErr = src.Open(primCtx)
+	}
+
+	go func() {
+		defer func() {
+			util.Logfn(e.logf, "Fallback emitter closing")
+			cancelPrim()
+			close(e.output)
+		}()
+
+		err := openErr
+		if err == nil {
+			var done bool
+			if done, err = e.emit(ctx, e.primary.GetOutput(), failed); done {
+				return
+			}
+		}
+		cancelPrim()
+		util.Logfn(e.logf, "Fallback emitter switching to fallback: "+err.Error())
+
+		next := e.fallback(err)
+		if next == nil {
+			autoctx.Err(e.errf, api.Error(err.Error()))
+			return
+		}
+		if src, ok := next.(api.Source); ok {
+			if err := src.Open(ctx); err != nil {
+				util.Logfn(e.logf, err)
+				autoctx.Err(e.errf, api.Error(err.Error()))
+				return
+			}
+		}
+		e.emit(ctx, next.GetOutput(), nil)
+	}()
+	return nil
+}
+
+// emit forwards items until input closes or ctx is done (done is true), or
+// until an error is received from failed.  Once failed, the items already
+// buffered in input are forwarded and the rest of input is discarded.
+func (e *FallbackEmitter) emit(ctx context.Context, input <-chan interface{}, failed <-chan error) (bool, error) {
+	fail := func(err error) (bool, error) {
+		// keep the items emitted (buffered) before the error was reported
+		for pending := true; pending; {
+			select {
+			case item, opened := <-input:
+				if !opened {
+					return false, err
+				}
+				select {
+				case e.output <- item:
+				case <-ctx.Done():
+					return true, nil
+				}
+			default:
+				pending = false
+			}
+		}
+		go func() {
+			for range input {
+			}
+		}()
+		return false, err
+	}
+	for {
+		select {
+		case item, opened := <-input:
+			if !opened {
+				// an error may be reported just before closing
+				select {
+				case err := <-failed:
+					return false, err
+				default:
+				}
+				return true, nil
+			}
+			select {
+			case e.output <- item:
+			case <-ctx.Done():
+				return true, nil
+			}
+		case err := <-failed:
+			return fail(err)
+		case <-ctx.Done():
+			return true, nil
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package emitters
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+)
+
+func TestEmitter_ConcatFallback(t *testing.T) {
+	pages := 0
+	primary := Paginated(func(ctx context.Context, cursor string) ([]interface{}, string, error) {
+		pages++
+		if pages > 1 {
+			return nil, "", errors.New("primary unavailable")
+		}
+		return []interface{}{"a", "b"}, "next", nil
+	})
+	var fallbackErr error
+	e := ConcatFallback(primary, func(err error) api.Emitter {
+		fallbackErr = err
+		return Slice([]string{"c", "d"})
+	})
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var items []interface{}
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for item := range e.GetOutput() {
+			items = append(items, item)
+		}
+	}()
+	select {
+	case <-wait:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(items) != 4 || items[0] != "a" || items[1] != "b" || items[2] != "c" || items[3] != "d" {
+		t.Fatal("expecting primary items followed by fallback items, got", items)
+	}
+	if fallbackErr == nil {
+		t.Fatal("expecting fallback called with the primary error")
+	}
+}
+
+func TestEmitter_ConcatFallback_PrimaryCompletes(t *testing.T) {
+	e := ConcatFallback(Slice([]int{1, 2}), func(err error) api.Emitter {
+		t.Fatal("fallback should not be used")
+		return nil
+	})
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var items []interface{}
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for item := range e.GetOutput() {
+			items = append(items, item)
+		}
+	}()
+	select {
+	case <-wait:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	if len(items) != 2 {
+		t.Fatal("unexpected items", items)
+	}
+}
@@ -0,0 +1,93 @@
+package emitters
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/api/tuple"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// MapEmitter is an emitter that takes in a map and emits
+// each of its entries as a tuple.KV{key, value}.
+type MapEmitter struct {
+	m      interface{}
+	less   func(a, b interface{}) bool
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+// Map creates a new *MapEmitter for map m.  Entries are emitted in the
+// unspecified order of map iteration (see Sorted).
+func Map(m interface{}) *MapEmitter {
+	return &MapEmitter{
+		m:      m,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// Sorted emits the entries sorted by key, using less to compare keys
+func (e *MapEmitter) Sorted(less func(a, b interface{}) bool) *MapEmitter {
+	e.less = less
+	return e
+}
+
+// GetOutput returns the output channel of this source node
+func (e *MapEmitter) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// Open opens the source node to start streaming the map entries
+func (e *MapEmitter) Open(ctx context.Context) error {
+	mapVal := reflect.ValueOf(e.m)
+	if mapVal.Kind() != reflect.Map {
+		return errors.New("MapEmitter requires map")
+	}
+	e.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(e.logf, "Opening map emitter")
+
+	var keys []reflect.Value
+	if e.less != nil {
+		keys = mapVal.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return e.less(keys[i].Interface(), keys[j].Interface())
+		})
+	}
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			util.Logfn(e.logf, "Map emitter closing")
+			cancel()
+			close(e.output)
+		}()
+		emit := func(key, val reflect.Value) bool {
+			select {
+			case e.output <- tuple.KV{key.Interface(), val.Interface()}:
+				return true
+			case <-exeCtx.Done():
+				return false
+			}
+		}
+
+		if keys != nil {
+			for _, key := range keys {
+				if !emit(key, mapVal.MapIndex(key)) {
+					return
+				}
+			}
+			return
+		}
+		iter := mapVal.MapRange()
+		for iter.Next() {
+			if !emit(iter.Key(), iter.Value()) {
+				return
+			}
+		}
+	}()
+	return nil
+}
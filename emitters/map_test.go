@@ -0,0 +1,84 @@
+package emitters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api/tuple"
+)
+
+func TestEmitter_Map(t *testing.T) {
+	m := map[string]int{"a": 1, "b": 2, "c": 3}
+	e := Map(m)
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]int)
+	for item := range e.GetOutput() {
+		kv := item.(tuple.KV)
+		seen[kv[0].(string)] = kv[1].(int)
+	}
+	if len(seen) != len(m) {
+		t.Fatal("expecting all entries, got", seen)
+	}
+	for k, v := range m {
+		if seen[k] != v {
+			t.Fatal("unexpected entry", k, seen[k])
+		}
+	}
+}
+
+func TestEmitter_Map_Sorted(t *testing.T) {
+	e := Map(map[int]string{3: "c", 1: "a", 2: "b"}).
+		Sorted(func(a, b interface{}) bool { return a.(int) < b.(int) })
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys []interface{}
+	for item := range e.GetOutput() {
+		keys = append(keys, item.(tuple.KV)[0])
+	}
+	if len(keys) != 3 || keys[0] != 1 || keys[1] != 2 || keys[2] != 3 {
+		t.Fatal("expecting entries sorted by key, got", keys)
+	}
+}
+
+func TestEmitter_Map_Cancel(t *testing.T) {
+	m := make(map[int]int)
+	for i := 0; i < 5000; i++ {
+		m[i] = i
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	e := Map(m)
+	if err := e.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	<-e.GetOutput()
+	cancel()
+
+	select {
+	case <-drained(e.GetOutput()):
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("expecting emitter to stop on cancellation")
+	}
+}
+
+func TestEmitter_Map_NotMap(t *testing.T) {
+	if err := Map([]int{1}).Open(context.Background()); err == nil {
+		t.Fatal("expecting error for non-map")
+	}
+}
+
+// drained returns a channel closed once ch is closed
+func drained(ch <-chan interface{}) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(done)
+	}()
+	return done
+}
@@ -0,0 +1,122 @@
+package emitters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// FetchFunc fetches the page of items at cursor (the empty cursor for the
+// first page) and returns the items along with the cursor of the next page,
+// an empty next cursor indicates the last page.
+type FetchFunc func(ctx context.Context, cursor string) (items []interface{}, next string, err error)
+
+// PaginatedEmitter is an emitter that sources its items from a paginated
+// API by repeatedly calling a fetch function and following the next cursor.
+type PaginatedEmitter struct {
+	fetch    FetchFunc
+	attempts int
+	backoff  time.Duration
+	output   chan interface{}
+	logf     api.LogFunc
+	errf     api.ErrorFunc
+}
+
+// Paginated creates a *PaginatedEmitter which uses fetch to retrieve pages
+func Paginated(fetch FetchFunc) *PaginatedEmitter {
+	return &PaginatedEmitter{
+		fetch:    fetch,
+		attempts: 1,
+		output:   make(chan interface{}, 1024),
+	}
+}
+
+// Retry sets the number of attempts made to fetch a page before giving up,
+// waiting for backoff after the first failure and doubling the wait after
+// each subsequent one.
+func (e *PaginatedEmitter) Retry(attempts int, backoff time.Duration) *PaginatedEmitter {
+	e.attempts = attempts
+	e.backoff = backoff
+	return e
+}
+
+// GetOutput returns the output channel of this source node
+func (e *PaginatedEmitter) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// Open opens the emitter to start fetching pages and emitting their items.
+// A page that cannot be fetched is reported to the error path and ends the
+// emitter.
+func (e *PaginatedEmitter) Open(ctx context.Context) error {
+	if e.fetch == nil {
+		return errors.New("paginated emitter missing fetch function")
+	}
+	if e.attempts < 1 {
+		e.attempts = 1
+	}
+	e.logf = autoctx.GetLogFunc(ctx)
+	e.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(e.logf, "Opening paginated emitter")
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			util.Logfn(e.logf, "Closing paginated emitter")
+			cancel()
+			close(e.output)
+		}()
+
+		cursor := ""
+		for {
+			items, next, err := e.fetchPage(exeCtx, cursor)
+			if err != nil {
+				perr := fmt.Errorf("Unable to fetch page %q: %s", cursor, err)
+				util.Logfn(e.logf, perr)
+				autoctx.Err(e.errf, api.Error(perr.Error()))
+				return
+			}
+			for _, item := range items {
+				select {
+				case e.output <- item:
+				case <-exeCtx.Done():
+					return
+				}
+			}
+			if next == "" {
+				return
+			}
+			cursor = next
+		}
+	}()
+	return nil
+}
+
+// fetchPage fetches the page at cursor, retrying with backoff
+func (e *PaginatedEmitter) fetchPage(ctx context.Context, cursor string) ([]interface{}, string, error) {
+	wait := e.backoff
+	var err error
+	for attempt := 1; attempt <= e.attempts; attempt++ {
+		var items []interface{}
+		var next string
+		if items, next, err = e.fetch(ctx, cursor); err == nil {
+			return items, next, nil
+		}
+		if attempt == e.attempts {
+			break
+		}
+		util.Logfn(e.logf, fmt.Sprintf("Paginated emitter retrying page %q: %s", cursor, err))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, "", ctx.Err()
+		}
+		wait *= 2
+	}
+	return nil, "", err
+}
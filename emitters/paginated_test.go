@@ -0,0 +1,75 @@
+package emitters
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+)
+
+func TestEmitter_Paginated(t *testing.T) {
+	pages := map[string][]interface{}{
+		"":   {1, 2},
+		"p2": {3, 4},
+		"p3": {5},
+	}
+	nexts := map[string]string{"": "p2", "p2": "p3", "p3": ""}
+	failures := 1
+	fetch := func(ctx context.Context, cursor string) ([]interface{}, string, error) {
+		if cursor == "p2" && failures > 0 {
+			failures--
+			return nil, "", errors.New("temporarily unavailable")
+		}
+		return pages[cursor], nexts[cursor], nil
+	}
+
+	e := Paginated(fetch).Retry(2, time.Millisecond)
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var items []interface{}
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for item := range e.GetOutput() {
+			items = append(items, item)
+		}
+	}()
+
+	select {
+	case <-wait:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Emitter took too long")
+	}
+	if len(items) != 5 || items[0] != 1 || items[4] != 5 {
+		t.Fatal("unexpected items", items)
+	}
+}
+
+func TestEmitter_Paginated_Error(t *testing.T) {
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		errs = append(errs, err)
+	})
+	e := Paginated(func(ctx context.Context, cursor string) ([]interface{}, string, error) {
+		if cursor == "" {
+			return []interface{}{"a"}, "next", nil
+		}
+		return nil, "", errors.New("boom")
+	})
+	if err := e.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	for range e.GetOutput() {
+		count++
+	}
+	if count != 1 || len(errs) != 1 {
+		t.Fatal("unexpected items or errors", count, errs)
+	}
+}
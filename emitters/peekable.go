@@ -0,0 +1,179 @@
+package emitters
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// PeekableEmitter is an emitter wrapping another emitter to let its first
+// item be peeked (i.e. to detect the format of the source from its first
+// line) without consuming it: the output emits all the items of the wrapped
+// emitter, starting with the peeked one.  An item read elsewhere (i.e. from
+// the underlying reader) can also be pushed back with Unread to be emitted
+// first.
+type PeekableEmitter struct {
+	src    api.Emitter
+	once   sync.Once
+	opened chan struct{}
+	ready  chan struct{} // closed once the first item is known
+	first  interface{}
+	ok     bool
+	err    error
+	unread interface{}
+	output chan interface{}
+
+	mu     sync.Mutex
+	cancel context.CancelFunc // cancels the context of the wrapped emitter
+	done   chan struct{}      // closed once the emitter is done
+	logf   api.LogFunc
+}
+
+// Peekable creates a *PeekableEmitter wrapping src
+func Peekable(src api.Emitter) *PeekableEmitter {
+	return &PeekableEmitter{
+		src:    src,
+		opened: make(chan struct{}),
+		ready:  make(chan struct{}),
+		done:   make(chan struct{}),
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// GetOutput returns the output channel of this source node
+func (e *PeekableEmitter) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// Unread pushes item back to be emitted first, ahead of the items of the
+// wrapped emitter (and returned by Peek).  A single item can be unread,
+// before the emitter is opened.
+func (e *PeekableEmitter) Unread(item interface{}) error {
+	select {
+	case <-e.opened:
+		return errors.New("peekable emitter already open")
+	default:
+	}
+	if e.unread != nil {
+		return errors.New("peekable emitter already has an unread item")
+	}
+	e.unread = item
+	return nil
+}
+
+// Open opens the wrapped emitter, if it is an api.Source, and starts
+// emitting items.  The emitter can be opened to peek before being used as
+// the source of a stream, which opens it again: the wrapped emitter is only
+// opened once, with the context of the first call, and later calls only
+// add their context so that its cancellation (i.e. the stream being
+// cancelled or stopping its source) stops the emitter, and its log function
+// is used from then on.
+func (e *PeekableEmitter) Open(ctx context.Context) error {
+	opening := false
+	e.once.Do(func() {
+		opening = true
+		e.err = e.open(ctx)
+		close(e.opened)
+	})
+	if opening || e.err != nil {
+		return e.err
+	}
+
+	e.mu.Lock()
+	if logf := autoctx.GetLogFunc(ctx); logf != nil {
+		e.logf = logf
+	}
+	e.mu.Unlock()
+	go func() {
+		select {
+		case <-ctx.Done():
+			e.cancel()
+		case <-e.done:
+		}
+	}()
+	return nil
+}
+
+func (e *PeekableEmitter) open(ctx context.Context) error {
+	if e.src == nil {
+		close(e.ready)
+		return errors.New("peekable emitter missing source")
+	}
+	ctx, e.cancel = context.WithCancel(ctx)
+	e.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(e.logf, "Peekable emitter starting")
+	if src, ok := e.src.(api.Source); ok {
+		if err := src.Open(ctx); err != nil {
+			e.cancel()
+			close(e.ready)
+			return err
+		}
+	}
+
+	go func() {
+		first := true
+		defer func() {
+			util.Logfn(e.getLogFunc(), "Peekable emitter closing")
+			if first {
+				close(e.ready)
+			}
+			close(e.output)
+			close(e.done)
+			e.cancel()
+		}()
+		send := func(item interface{}) bool {
+			if first {
+				e.first, e.ok = item, true
+				close(e.ready)
+				first = false
+			}
+			select {
+			case e.output <- item:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		if e.unread != nil && !send(e.unread) {
+			return
+		}
+		for {
+			select {
+			case item, opened := <-e.src.GetOutput():
+				if !opened {
+					return
+				}
+				if !send(item) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (e *PeekableEmitter) getLogFunc() api.LogFunc {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.logf
+}
+
+// Peek returns the first item of the wrapped emitter, without consuming
+// it, and true.  It blocks until the first item is available.  It returns
+// nil and false if the emitter is not open, if it failed to open, or if
+// the wrapped emitter closed (or was cancelled) without emitting any item.
+func (e *PeekableEmitter) Peek() (interface{}, bool) {
+	select {
+	case <-e.opened:
+	default:
+		return nil, false
+	}
+	<-e.ready
+	return e.first, e.ok
+}
@@ -0,0 +1,121 @@
+package emitters
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	autoctx "github.com/vladimirvivien/automi/api/context"
+)
+
+func TestEmitter_Peekable(t *testing.T) {
+	src := Scanner(strings.NewReader("name,age\nann,30\nbob,40"), nil)
+	p := Peekable(src)
+	if _, ok := p.Peek(); ok {
+		t.Fatal("expecting no item before open")
+	}
+	if err := p.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	first, ok := p.Peek()
+	if !ok || !strings.Contains(first.(string), ",") {
+		t.Fatal("unexpected peeked item", first)
+	}
+	if again, _ := p.Peek(); again != first {
+		t.Fatal("expecting peek to be repeatable")
+	}
+	if err := p.Open(context.Background()); err != nil { // i.e. by a stream
+		t.Fatal(err)
+	}
+
+	var items []interface{}
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for item := range p.GetOutput() {
+			items = append(items, item)
+		}
+	}()
+	select {
+	case <-wait:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	if len(items) != 3 || items[0] != "name,age" || items[2] != "bob,40" {
+		t.Fatal("expecting all items, starting with the peeked one, got", items)
+	}
+}
+
+func TestEmitter_Peekable_Empty(t *testing.T) {
+	p := Peekable(Slice([]string{}))
+	if err := p.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if item, ok := p.Peek(); ok || item != nil {
+		t.Fatal("expecting no item for empty source, got", item)
+	}
+}
+
+func TestEmitter_Peekable_StreamContext(t *testing.T) {
+	ch := make(chan string, 1)
+	ch <- "first"
+	p := Peekable(Chan(ch)) // source never closes
+	if err := p.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if first, ok := p.Peek(); !ok || first != "first" {
+		t.Fatal("unexpected peeked item", first)
+	}
+
+	// opened again by the stream, its context now applies
+	logs := make(chan interface{}, 8)
+	ctx, cancel := context.WithCancel(autoctx.WithLogFunc(context.Background(), func(msg interface{}) {
+		logs <- msg
+	}))
+	if err := p.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+	<-p.GetOutput()
+	cancel()
+
+	select {
+	case _, opened := <-p.GetOutput():
+		if opened {
+			t.Fatal("expecting output closed")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("emitter not stopped by the stream context")
+	}
+	if len(logs) == 0 {
+		t.Fatal("expecting closing logged with the stream log function")
+	}
+}
+
+func TestEmitter_Peekable_Unread(t *testing.T) {
+	p := Peekable(Slice([]string{"ann,30", "bob,40"}))
+	if err := p.Unread("name,age"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Unread("other"); err == nil {
+		t.Fatal("expecting a single unread item")
+	}
+	if err := p.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Unread("late"); err == nil {
+		t.Fatal("expecting unread to fail once open")
+	}
+	if first, ok := p.Peek(); !ok || first != "name,age" {
+		t.Fatal("expecting unread item peeked, got", first)
+	}
+
+	var items []interface{}
+	for item := range p.GetOutput() {
+		items = append(items, item)
+	}
+	if len(items) != 3 || items[0] != "name,age" || items[2] != "bob,40" {
+		t.Fatal("expecting unread item first, got", items)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sync"
 
 	"github.com/vladimirvivien/automi/api"
 	autoctx "github.com/vladimirvivien/automi/api/context"
@@ -16,6 +17,7 @@ import (
 type ReaderEmitter struct {
 	reader io.Reader
 	size   int
+	pool   *sync.Pool
 	output chan interface{}
 	logf   api.LogFunc
 	errf   api.ErrorFunc
@@ -36,6 +38,38 @@ func (e *ReaderEmitter) BufferSize(s int) *ReaderEmitter {
 	return e
 }
 
+// Pooled enables reuse of the emitted []byte buffers to reduce allocations
+// for high-throughput sources.  When pooled, downstream MUST NOT retain the
+// emitted slices (or sub-slices of them) and SHOULD hand each one back with
+// Release once it is done with it.  Buffers that are not released are
+// garbage collected as usual.
+func (e *ReaderEmitter) Pooled() *ReaderEmitter {
+	e.pool = &sync.Pool{}
+	return e
+}
+
+// Release returns a []byte item, emitted by a pooled emitter, to
+// the pool for reuse.  It is a no-op when the emitter is not pooled
+// or when item was not emitted by this emitter.
+func (e *ReaderEmitter) Release(item interface{}) {
+	buf, ok := item.([]byte)
+	if !ok || e.pool == nil || cap(buf) != e.size {
+		return
+	}
+	buf = buf[:e.size]
+	e.pool.Put(&buf)
+}
+
+// buffer returns a transfer buffer, from the pool if pooled
+func (e *ReaderEmitter) buffer() []byte {
+	if e.pool != nil {
+		if buf, ok := e.pool.Get().(*[]byte); ok {
+			return *buf
+		}
+	}
+	return make([]byte, e.size)
+}
+
 // GetOutput returns the output channel of this source node
 func (e *ReaderEmitter) GetOutput() <-chan interface{} {
 	return e.output
@@ -61,7 +95,7 @@ func (e *ReaderEmitter) Open(ctx context.Context) error {
 		}()
 
 		for {
-			buf := make([]byte, e.size)
+			buf := e.buffer()
 			bytesRead, err := e.reader.Read(buf)
 
 			if bytesRead > 0 {
@@ -70,6 +104,11 @@ func (e *ReaderEmitter) Open(ctx context.Context) error {
 				case <-exeCtx.Done():
 					return
 				}
+			} else {
+				e.Release(buf)
+			}
+			if err == io.EOF {
+				return
 			}
 			if err != nil {
 				// Any error closes channel
@@ -57,3 +57,44 @@ func TestEmitter_Reader(t *testing.T) {
 		m.Unlock()
 	}
 }
+
+func TestEmitter_Reader_Pooled(t *testing.T) {
+	e := Reader(strings.NewReader("Hello World")).BufferSize(4).Pooled()
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var read bytes.Buffer
+	for item := range e.GetOutput() {
+		read.Write(item.([]byte))
+		e.Release(item)
+	}
+	if read.String() != "Hello World" {
+		t.Fatal("unexpected content", read.String())
+	}
+}
+
+func benchmarkReader(b *testing.B, pooled bool) {
+	data := bytes.Repeat([]byte("automi"), 1024*1024)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e := Reader(bytes.NewReader(data)).BufferSize(1024)
+		if pooled {
+			e.Pooled()
+		}
+		if err := e.Open(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+		for item := range e.GetOutput() {
+			e.Release(item)
+		}
+	}
+}
+
+func BenchmarkReaderEmitter(b *testing.B) {
+	benchmarkReader(b, false)
+}
+
+func BenchmarkReaderEmitter_Pooled(b *testing.B) {
+	benchmarkReader(b, true)
+}
@@ -0,0 +1,94 @@
+package emitters
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// TimedBufferEmitter replays the items of a recorded stream buffer
+// honoring their original inter-arrival gaps, scaled by a speed factor.
+type TimedBufferEmitter struct {
+	buffer Buffer
+	timeFn func(interface{}) time.Time
+	speed  float64
+	after  func(time.Duration) <-chan time.Time
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+// ReplayTimed creates a *TimedBufferEmitter that emits the items recorded
+// in buf, waiting between consecutive items for the difference of their
+// times, as returned by timeFn, divided by speed (i.e. 2 replays twice as
+// fast).  Items with a time earlier than their predecessor are emitted
+// without waiting.  A new emitter should be created for each replay.
+func ReplayTimed(buf Buffer, timeFn func(interface{}) time.Time, speed float64) *TimedBufferEmitter {
+	return &TimedBufferEmitter{
+		buffer: buf,
+		timeFn: timeFn,
+		speed:  speed,
+		after:  time.After,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// WithClock sets the function used to wait between items (time.After by
+// default), i.e. a fake clock for testing.
+func (e *TimedBufferEmitter) WithClock(after func(time.Duration) <-chan time.Time) *TimedBufferEmitter {
+	e.after = after
+	return e
+}
+
+// GetOutput returns the output channel of this source node
+func (e *TimedBufferEmitter) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// Open opens the source node to start replaying the recorded items
+func (e *TimedBufferEmitter) Open(ctx context.Context) error {
+	if e.buffer == nil || e.timeFn == nil {
+		return errors.New("TimedBufferEmitter requires buffer and time function")
+	}
+	if e.speed <= 0 {
+		return errors.New("TimedBufferEmitter speed must be greater than zero")
+	}
+	e.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(e.logf, "Opening timed buffer emitter")
+
+	// take a snapshot so the replay is not affected by later recordings
+	items := e.buffer.Items()
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			util.Logfn(e.logf, "Timed buffer emitter closing")
+			cancel()
+			close(e.output)
+		}()
+
+		var last time.Time
+		for i, item := range items {
+			stamp := e.timeFn(item)
+			if i > 0 {
+				if gap := stamp.Sub(last); gap > 0 {
+					select {
+					case <-e.after(time.Duration(float64(gap) / e.speed)):
+					case <-exeCtx.Done():
+						return
+					}
+				}
+			}
+			last = stamp
+			select {
+			case e.output <- item:
+			case <-exeCtx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,55 @@
+package emitters
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEmitter_ReplayTimed(t *testing.T) {
+	start := time.Unix(0, 0)
+	buf := testBuffer{
+		start,
+		start.Add(2 * time.Second),
+		start.Add(3 * time.Second),
+		start.Add(1 * time.Second), // out of order, no wait
+		start.Add(7 * time.Second),
+	}
+
+	var waits []time.Duration
+	fakeAfter := func(d time.Duration) <-chan time.Time {
+		waits = append(waits, d)
+		ch := make(chan time.Time, 1)
+		ch <- start
+		return ch
+	}
+
+	e := ReplayTimed(buf, func(item interface{}) time.Time { return item.(time.Time) }, 2).WithClock(fakeAfter)
+	if err := e.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	for range e.GetOutput() {
+		count++
+	}
+	if count != len(buf) {
+		t.Fatal("expecting all items replayed, got", count)
+	}
+	expected := []time.Duration{time.Second, 500 * time.Millisecond, 3 * time.Second}
+	if len(waits) != len(expected) {
+		t.Fatal("unexpected waits", waits)
+	}
+	for i := range expected {
+		if waits[i] != expected[i] {
+			t.Fatalf("expecting wait %v, got %v", expected[i], waits[i])
+		}
+	}
+}
+
+func TestEmitter_ReplayTimed_BadSpeed(t *testing.T) {
+	e := ReplayTimed(testBuffer{}, func(interface{}) time.Time { return time.Time{} }, 0)
+	if err := e.Open(context.Background()); err == nil {
+		t.Fatal("expecting error for zero speed")
+	}
+}
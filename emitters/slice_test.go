@@ -5,6 +5,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/vladimirvivien/automi/api"
 )
 
 func TestEmitter_Slice(t *testing.T) {
@@ -36,3 +38,37 @@ func TestEmitter_Slice(t *testing.T) {
 	}
 	m.Unlock()
 }
+
+func TestEmitter_SlicePositionedResume(t *testing.T) {
+	s := Slice([]string{"A", "B", "C", "D"}).Positioned()
+	if err := s.Resume(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var positions []interface{}
+	for item := range s.GetOutput() {
+		positions = append(positions, item.(api.StreamItem).Position)
+	}
+	if len(positions) != 2 || positions[0] != 2 || positions[1] != 3 {
+		t.Fatal("unexpected resumed positions", positions)
+	}
+}
+
+func TestEmitter_SliceEmitBatchSize(t *testing.T) {
+	s := Slice([]int{1, 2, 3, 4, 5, 6, 7}).EmitBatchSize(3)
+	if !s.EmitsBatches() {
+		t.Fatal("expecting emitter to emit batches")
+	}
+	if err := s.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var sizes []int
+	for item := range s.GetOutput() {
+		sizes = append(sizes, len(item.(api.ItemBatch)))
+	}
+	if len(sizes) != 3 || sizes[0] != 3 || sizes[1] != 3 || sizes[2] != 1 {
+		t.Fatal("unexpected batch sizes", sizes)
+	}
+}
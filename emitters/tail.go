@@ -0,0 +1,176 @@
+package emitters
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// TailEmitter is an emitter that reads the lines of a file and then follows
+// the file (similar to tail -f), emitting lines appended to it as string
+// values (without line endings).  The file is polled for changes: when it is
+// truncated, it is read again from its start, and when it is replaced (i.e.
+// log rotation), the rest of the replaced file is emitted (including an
+// incomplete last line) before the new file at the path is opened and read
+// from its start.  The emitter runs until its context is done.
+type TailEmitter struct {
+	path     string
+	interval time.Duration
+	file     *os.File
+	output   chan interface{}
+	logf     api.LogFunc
+	errf     api.ErrorFunc
+}
+
+// Tail creates a *TailEmitter following the file at path
+func Tail(path string) *TailEmitter {
+	return &TailEmitter{
+		path:     path,
+		interval: 250 * time.Millisecond,
+		output:   make(chan interface{}, 1024),
+	}
+}
+
+// PollInterval sets how often the file is checked for changes
+// once all its lines have been read (default 250ms)
+func (e *TailEmitter) PollInterval(d time.Duration) *TailEmitter {
+	e.interval = d
+	return e
+}
+
+// GetOutput returns the output channel of this source node
+func (e *TailEmitter) GetOutput() <-chan interface{} {
+	return e.output
+}
+
+// Open opens the file and starts emitting its lines.  Read errors are
+// sent to the error path and stop the emitter.
+func (e *TailEmitter) Open(ctx context.Context) error {
+	e.logf = autoctx.GetLogFunc(ctx)
+	e.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(e.logf, "Tail emitter starting")
+
+	if e.interval <= 0 {
+		return fmt.Errorf("Tail emitter poll interval must be greater than zero")
+	}
+	file, err := os.Open(e.path)
+	if err != nil {
+		return err
+	}
+	e.file = file
+
+	go func() {
+		defer func() {
+			util.Logfn(e.logf, "Tail emitter closing")
+			e.file.Close()
+			close(e.output)
+		}()
+		if err := e.follow(ctx); err != nil {
+			util.Logfn(e.logf, err)
+			autoctx.Err(e.errf, api.Error(err.Error()))
+		}
+	}()
+	return nil
+}
+
+// follow emits lines until ctx is done or a read error occurs
+func (e *TailEmitter) follow(ctx context.Context) error {
+	reader := bufio.NewReader(e.file)
+	var offset int64   // bytes read from the current file
+	var partial string // incomplete last line
+
+	// emit sends line downstream, it returns false when ctx is done
+	emit := func(line string) bool {
+		select {
+		case e.output <- strings.TrimRight(line, "\r\n"):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// readLines emits the complete lines of the current file up to its end,
+	// it returns false when ctx is done
+	readLines := func() (bool, error) {
+		for {
+			line, err := reader.ReadString('\n')
+			offset += int64(len(line))
+			partial += line
+			if err == io.EOF {
+				return true, nil
+			}
+			if err != nil {
+				return false, fmt.Errorf("Tail emitter read error: %s", err)
+			}
+			if !emit(partial) {
+				return false, nil
+			}
+			partial = ""
+		}
+	}
+
+	for {
+		if ok, err := readLines(); !ok || err != nil {
+			return err
+		}
+
+		// all lines read, wait for changes
+		select {
+		case <-time.After(e.interval):
+		case <-ctx.Done():
+			return nil
+		}
+
+		info, err := os.Stat(e.path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // being rotated
+			}
+			return err
+		}
+		current, err := e.file.Stat()
+		if err != nil {
+			return err
+		}
+		switch {
+		case !os.SameFile(info, current):
+			util.Logfn(e.logf, fmt.Sprintf("Tail emitter reopening rotated file %s", e.path))
+			file, err := os.Open(e.path)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return err
+			}
+			// lines written to the rotated file since it was last read
+			ok, err := readLines()
+			if ok && partial != "" {
+				ok = emit(partial)
+			}
+			if !ok || err != nil {
+				file.Close()
+				return err
+			}
+			e.file.Close()
+			e.file = file
+		case info.Size() < offset:
+			util.Logfn(e.logf, fmt.Sprintf("Tail emitter rereading truncated file %s", e.path))
+			if _, err := e.file.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+		reader.Reset(e.file)
+		offset = 0
+		partial = ""
+	}
+}
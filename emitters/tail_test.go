@@ -0,0 +1,106 @@
+package emitters
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEmitter_Tail(t *testing.T) {
+	dir, err := ioutil.TempDir("", "automi-tail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	if err := ioutil.WriteFile(path, []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tail := Tail(path).PollInterval(5 * time.Millisecond)
+	if err := tail.Open(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	next := func() interface{} {
+		select {
+		case line := <-tail.GetOutput():
+			return line
+		case <-time.After(500 * time.Millisecond):
+			t.Fatal("Waited too long for line")
+		}
+		return nil
+	}
+	if next() != "one" || next() != "two" {
+		t.Fatal("unexpected existing lines")
+	}
+
+	// appended lines, the partial line is emitted once complete
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("three\r\nfo")
+	time.Sleep(20 * time.Millisecond)
+	f.WriteString("ur\n")
+	f.Close()
+	if line := next(); line != "three" {
+		t.Fatal("unexpected appended line", line)
+	}
+	if line := next(); line != "four" {
+		t.Fatal("unexpected appended line", line)
+	}
+
+	// rotation, lines written to the old file before it is renamed
+	// are emitted before the lines of the new file
+	f, err = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("late\nlast")
+	f.Close()
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte("five\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if line := next(); line != "late" {
+		t.Fatal("unexpected line of rotated file", line)
+	}
+	if line := next(); line != "last" {
+		t.Fatal("unexpected partial line of rotated file", line)
+	}
+	if line := next(); line != "five" {
+		t.Fatal("unexpected line after rotation", line)
+	}
+
+	// truncation
+	if err := ioutil.WriteFile(path, []byte("six\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if line := next(); line != "six" {
+		t.Fatal("unexpected line after truncation", line)
+	}
+
+	cancel()
+	select {
+	case _, opened := <-tail.GetOutput():
+		if opened {
+			t.Fatal("expecting output closed after cancel")
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Waited too long for close")
+	}
+}
+
+func TestEmitter_Tail_Missing(t *testing.T) {
+	if err := Tail("/no/such/file.log").Open(context.Background()); err == nil {
+		t.Fatal("expecting error for missing file")
+	}
+}
@@ -0,0 +1,4 @@
+// Package group provides helpers used to coordinate groups of
+// operations such as bounding concurrency of slow (I/O) calls, and helpers
+// used to build key functions for grouping operations.
+package group
@@ -0,0 +1,87 @@
+package group
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// FieldExtractor returns a function that extracts the value at the dotted
+// path (i.e. "Address.City") from map or struct items, for use as a key or
+// value function.  Each path element is looked up as a key of a map with
+// string keys, or as an exported field of a struct (pointers are followed).
+// The function returns nil when an element of the path is missing.  Struct
+// field indices are cached per type, the function is safe for concurrent use.
+func FieldExtractor(path string) func(interface{}) interface{} {
+	names := strings.Split(path, ".")
+	var cache sync.Map // fieldKey -> []int (nil when missing)
+
+	fieldIndex := func(t reflect.Type, depth int) []int {
+		key := fieldKey{typ: t, depth: depth}
+		if index, ok := cache.Load(key); ok {
+			return index.([]int)
+		}
+		var index []int
+		if field, ok := t.FieldByName(names[depth]); ok && field.PkgPath == "" {
+			index = field.Index
+		}
+		cache.Store(key, index)
+		return index
+	}
+
+	return func(item interface{}) interface{} {
+		val := reflect.ValueOf(item)
+		for depth, name := range names {
+			for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+				if val.IsNil() {
+					return nil
+				}
+				val = val.Elem()
+			}
+			switch val.Kind() {
+			case reflect.Map:
+				if val.Type().Key().Kind() != reflect.String {
+					return nil
+				}
+				val = val.MapIndex(reflect.ValueOf(name).Convert(val.Type().Key()))
+			case reflect.Struct:
+				index := fieldIndex(val.Type(), depth)
+				if index == nil {
+					return nil
+				}
+				field, ok := fieldByIndex(val, index)
+				if !ok {
+					return nil
+				}
+				val = field
+			default:
+				return nil
+			}
+			if !val.IsValid() {
+				return nil
+			}
+		}
+		return val.Interface()
+	}
+}
+
+// fieldKey identifies a cached struct field lookup
+type fieldKey struct {
+	typ   reflect.Type
+	depth int
+}
+
+// fieldByIndex returns the nested field of struct val at index, following
+// embedded pointers, it returns false if one of them is nil
+func fieldByIndex(val reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				return reflect.Value{}, false
+			}
+			val = val.Elem()
+		}
+		val = val.Field(x)
+	}
+	return val, true
+}
@@ -0,0 +1,73 @@
+package group
+
+import (
+	"testing"
+)
+
+type address struct {
+	City string
+	Zip  *string
+}
+
+type customer struct {
+	Name    string
+	Address *address
+	Tags    map[string]interface{}
+}
+
+func TestFieldExtractor_Struct(t *testing.T) {
+	zip := "10001"
+	items := []interface{}{
+		customer{Name: "ann", Address: &address{City: "NYC", Zip: &zip}},
+		&customer{Name: "bob", Address: &address{City: "LA"}},
+		customer{Name: "cal"},
+	}
+	city := FieldExtractor("Address.City")
+	expected := []interface{}{"NYC", "LA", nil}
+	for i, item := range items {
+		if got := city(item); got != expected[i] {
+			t.Fatalf("item %d: expecting %v, got %v", i, expected[i], got)
+		}
+	}
+	if got := FieldExtractor("Address.Zip")(items[0]); *(got.(*string)) != zip {
+		t.Fatal("unexpected zip", got)
+	}
+	if got := FieldExtractor("Address.Country")(items[0]); got != nil {
+		t.Fatal("expecting nil for missing field, got", got)
+	}
+	if got := FieldExtractor("Name.First")(items[0]); got != nil {
+		t.Fatal("expecting nil for path through a non-container, got", got)
+	}
+}
+
+func TestFieldExtractor_Map(t *testing.T) {
+	item := map[string]interface{}{
+		"user": map[string]interface{}{
+			"profile": customer{Name: "dee", Tags: map[string]interface{}{"tier": "gold"}},
+		},
+	}
+	if got := FieldExtractor("user.profile.Name")(item); got != "dee" {
+		t.Fatal("unexpected name", got)
+	}
+	if got := FieldExtractor("user.profile.Tags.tier")(item); got != "gold" {
+		t.Fatal("unexpected tier", got)
+	}
+	if got := FieldExtractor("user.missing")(item); got != nil {
+		t.Fatal("expecting nil for missing key, got", got)
+	}
+}
+
+type member struct {
+	*address
+	Name string
+}
+
+func TestFieldExtractor_Embedded(t *testing.T) {
+	city := FieldExtractor("City")
+	if got := city(member{address: &address{City: "SF"}, Name: "eve"}); got != "SF" {
+		t.Fatal("expecting promoted field, got", got)
+	}
+	if got := city(member{Name: "fay"}); got != nil {
+		t.Fatal("expecting nil through a nil embedded pointer, got", got)
+	}
+}
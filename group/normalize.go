@@ -0,0 +1,43 @@
+package group
+
+import (
+	"math"
+	"reflect"
+)
+
+// NormalizeKey canonicalizes numeric keys so that the same logical value
+// matches across types (i.e. an int64 decoded from one source and a float64
+// decoded from JSON): integral values that fit are returned as int64, other
+// numeric values as float64 (uint64 values beyond int64 are returned as is).
+// Keys of other types are returned unchanged.
+func NormalizeKey(key interface{}) interface{} {
+	val := reflect.ValueOf(key)
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return val.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := val.Uint(); u <= math.MaxInt64 {
+			return int64(u)
+		}
+		return val.Uint()
+	case reflect.Float32, reflect.Float64:
+		f := val.Float()
+		if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(f)
+		}
+		return f
+	}
+	return key
+}
+
+// NormalizedKey returns a key function which normalizes the keys returned
+// by keyFn with normalize (NormalizeKey if nil), i.e. so that both sides of
+// a join or a lookup match on canonical keys.
+func NormalizedKey(keyFn func(interface{}) interface{}, normalize func(interface{}) interface{}) func(interface{}) interface{} {
+	if normalize == nil {
+		normalize = NormalizeKey
+	}
+	return func(item interface{}) interface{} {
+		return normalize(keyFn(item))
+	}
+}
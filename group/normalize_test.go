@@ -0,0 +1,41 @@
+package group
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalizeKey(t *testing.T) {
+	tests := []struct {
+		key      interface{}
+		expected interface{}
+	}{
+		{key: 42, expected: int64(42)},
+		{key: int8(-3), expected: int64(-3)},
+		{key: uint16(7), expected: int64(7)},
+		{key: float64(42), expected: int64(42)},
+		{key: float32(2.5), expected: 2.5},
+		{key: uint64(math.MaxUint64), expected: uint64(math.MaxUint64)},
+		{key: "42", expected: "42"},
+		{key: nil, expected: nil},
+	}
+	for _, test := range tests {
+		if actual := NormalizeKey(test.key); actual != test.expected {
+			t.Errorf("NormalizeKey(%#v): expecting %#v, got %#v", test.key, test.expected, actual)
+		}
+	}
+}
+
+func TestNormalizedKey_Match(t *testing.T) {
+	left := map[string]interface{}{"id": int64(7), "name": "widget"}
+	right := map[string]interface{}{"id": float64(7), "price": 9.99} // decoded from JSON
+
+	id := FieldExtractor("id")
+	if id(left) == id(right) {
+		t.Fatal("expecting raw keys not to match")
+	}
+	key := NormalizedKey(id, nil)
+	if key(left) != key(right) {
+		t.Fatal("expecting normalized keys to match", key(left), key(right))
+	}
+}
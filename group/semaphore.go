@@ -0,0 +1,53 @@
+package group
+
+import (
+	"context"
+)
+
+// SemaphoreLimiter bounds the number of operations that can be
+// in-flight at any given time.  It is independent of the number
+// of goroutines (workers) used to run the operations.
+type SemaphoreLimiter struct {
+	slots chan struct{}
+}
+
+// Semaphore creates a *SemaphoreLimiter that allows at most
+// n operations to be in-flight at once.  If n < 1, it defaults to 1.
+func Semaphore(n int) *SemaphoreLimiter {
+	if n < 1 {
+		n = 1
+	}
+	return &SemaphoreLimiter{slots: make(chan struct{}, n)}
+}
+
+// Acquire blocks until a slot is available or the context is done.
+// When the context is done before a slot is acquired, the context
+// error is returned and no slot is held.
+func (s *SemaphoreLimiter) Acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release returns a previously acquired slot.  It panics
+// if called without a matching Acquire.
+func (s *SemaphoreLimiter) Release() {
+	select {
+	case <-s.slots:
+	default:
+		panic("group: semaphore released more than acquired")
+	}
+}
+
+// Limit returns the maximum number of in-flight operations
+func (s *SemaphoreLimiter) Limit() int {
+	return cap(s.slots)
+}
+
+// InFlight returns the number of slots currently acquired
+func (s *SemaphoreLimiter) InFlight() int {
+	return len(s.slots)
+}
@@ -0,0 +1,51 @@
+package group
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSemaphore_AcquireRelease(t *testing.T) {
+	sem := Semaphore(2)
+	if sem.Limit() != 2 {
+		t.Fatal("unexpected limit", sem.Limit())
+	}
+	for i := 0; i < 2; i++ {
+		if err := sem.Acquire(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sem.InFlight() != 2 {
+		t.Fatal("expecting 2 in-flight, got", sem.InFlight())
+	}
+	sem.Release()
+	if sem.InFlight() != 1 {
+		t.Fatal("expecting 1 in-flight, got", sem.InFlight())
+	}
+}
+
+func TestSemaphore_AcquireCancel(t *testing.T) {
+	sem := Semaphore(1)
+	if err := sem.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := sem.Acquire(ctx); err == nil {
+		t.Fatal("expecting context error when acquiring a full semaphore")
+	}
+	if sem.InFlight() != 1 {
+		t.Fatal("cancelled acquire should not hold a slot")
+	}
+}
+
+func TestSemaphore_UnmatchedRelease(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expecting panic on unmatched release")
+		}
+	}()
+	Semaphore(1).Release()
+}
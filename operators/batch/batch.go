@@ -61,13 +61,17 @@ func (op *BatchOperator) Exec(ctx context.Context) (err error) {
 
 	go func() {
 		var batchValue reflect.Value
+		var batchPos interface{} // position of last item in batch
 		exeCtx, cancel := context.WithCancel(ctx)
 
 		defer func() {
 			util.Logfn(op.logf, "Closing batch operator")
 			// push any straggler items in batch
 			if batchValue.IsValid() && batchValue.Len() > 0 {
-				op.output <- batchValue.Interface()
+				select {
+				case op.output <- api.WithPosition(batchValue.Interface(), batchPos):
+				case <-exeCtx.Done():
+				}
 			}
 			cancel()
 			close(op.output)
@@ -85,6 +89,13 @@ func (op *BatchOperator) Exec(ctx context.Context) (err error) {
 				if !opened {
 					return
 				}
+				// positioned items are batched unwrapped, the batch
+				// carries the position of its last item.
+				item, pos := api.UnwrapPosition(item)
+				if pos != nil {
+					batchPos = pos
+				}
+
 				// detect type of first item to create proper
 				// Slice type for batch.
 				if !batchValue.IsValid() {
@@ -101,8 +112,9 @@ func (op *BatchOperator) Exec(ctx context.Context) (err error) {
 
 				// done batching, push downstream
 				select {
-				case op.output <- batchValue.Interface():
+				case op.output <- api.WithPosition(batchValue.Interface(), batchPos):
 					index = 1
+					batchPos = nil
 					batchType := op.makeBatchType(item)
 					batchValue = reflect.MakeSlice(reflect.SliceOf(batchType), 0, 1)
 				case <-exeCtx.Done():
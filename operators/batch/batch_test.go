@@ -2,6 +2,7 @@ package batch
 
 import (
 	"context"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
@@ -318,3 +319,27 @@ func BenchmarkBatchOp_Exec(b *testing.B) {
 	}
 	m.RUnlock()
 }
+
+func TestBatchOp_CancelDuringFlush(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// fill the output buffer, nobody reads it, then leave a
+	// straggler item to be flushed when the input closes.
+	in := make(chan interface{})
+	o := New()
+	o.SetInput(in)
+	o.SetTrigger(TriggerBySize(2))
+	if err := o.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < cap(o.output)*2+1; i++ {
+		in <- i
+	}
+	close(in)
+
+	cancel()
+	if !testutil.WaitGoroutines(baseline, time.Second) {
+		t.Fatal("batch operator goroutine leaked after cancel")
+	}
+}
@@ -0,0 +1,131 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// BigFloatPrec is the precision, in bits, of sums computed with BigFloatSumFunc
+const BigFloatPrec = 256
+
+// BigIntSumFunc generates an api.UnFunc that sums batched integer items from
+// upstream, like SumFunc, using a big.Int so the sum cannot overflow.  The
+// data is expected to be of type []integers or [][]integers (*big.Int values
+// are also accepted).  The function returns the sum as an int64 if it fits,
+// otherwise as a *big.Int.  Items that are not integers are reported to the
+// error path and skipped.
+func BigIntSumFunc() api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
+		dataType := reflect.TypeOf(param0)
+		if dataType == nil || (dataType.Kind() != reflect.Slice && dataType.Kind() != reflect.Array) {
+			return param0 // ignores the data
+		}
+
+		sum := new(big.Int)
+		var tmp big.Int
+		eachValue(reflect.ValueOf(param0), func(item reflect.Value) {
+			switch {
+			case util.IsIntValue(item):
+				if item.Kind() >= reflect.Uint && item.Kind() <= reflect.Uintptr {
+					sum.Add(sum, tmp.SetUint64(item.Uint()))
+				} else {
+					sum.Add(sum, tmp.SetInt64(item.Int()))
+				}
+			case item.Type() == reflect.TypeOf((*big.Int)(nil)) && !item.IsNil():
+				sum.Add(sum, item.Interface().(*big.Int))
+			default:
+				autoctx.Err(autoctx.GetErrFunc(ctx), api.ErrorWithItem(
+					fmt.Sprintf("big int sum: expecting an integer, got %s", item.Type()),
+					&api.StreamItem{Item: item.Interface()},
+				))
+			}
+		})
+
+		if sum.IsInt64() {
+			return sum.Int64()
+		}
+		return sum
+	})
+}
+
+// BigFloatSumFunc generates an api.UnFunc that sums batched numeric items
+// from upstream, like SumFunc, using a big.Float (with BigFloatPrec bits of
+// precision) so large totals stay exact.  The data is expected to be of type
+// []numbers or [][]numbers (*big.Int and *big.Float values are also
+// accepted).  The function returns the sum as a float64 if it converts
+// exactly, otherwise as a *big.Float.  Non-numeric items are reported to
+// the error path and skipped.
+func BigFloatSumFunc() api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
+		dataType := reflect.TypeOf(param0)
+		if dataType == nil || (dataType.Kind() != reflect.Slice && dataType.Kind() != reflect.Array) {
+			return param0 // ignores the data
+		}
+
+		sum := new(big.Float).SetPrec(BigFloatPrec)
+		tmp := new(big.Float).SetPrec(BigFloatPrec)
+		eachValue(reflect.ValueOf(param0), func(item reflect.Value) {
+			switch {
+			case util.IsIntValue(item):
+				if item.Kind() >= reflect.Uint && item.Kind() <= reflect.Uintptr {
+					tmp.SetUint64(item.Uint())
+				} else {
+					tmp.SetInt64(item.Int())
+				}
+			case util.IsFloatValue(item) && !math.IsNaN(item.Float()) && !math.IsInf(item.Float(), 0):
+				tmp.SetFloat64(item.Float())
+			case item.Type() == reflect.TypeOf((*big.Int)(nil)) && !item.IsNil():
+				tmp.SetInt(item.Interface().(*big.Int))
+			case item.Type() == reflect.TypeOf((*big.Float)(nil)) && !item.IsNil():
+				tmp.Set(item.Interface().(*big.Float))
+			default:
+				autoctx.Err(autoctx.GetErrFunc(ctx), api.ErrorWithItem(
+					fmt.Sprintf("big float sum: expecting a number, got %s", item.Type()),
+					&api.StreamItem{Item: item.Interface()},
+				))
+				return
+			}
+			sum.Add(sum, tmp)
+		})
+
+		if f, accuracy := sum.Float64(); accuracy == big.Exact {
+			return f
+		}
+		return sum
+	})
+}
+
+// eachValue calls f with each value of a batch of type []T or [][]T
+func eachValue(batch reflect.Value, f func(reflect.Value)) {
+	for i := 0; i < batch.Len(); i++ {
+		item := batch.Index(i)
+		if item.Kind() == reflect.Interface {
+			if item.IsNil() {
+				continue
+			}
+			item = item.Elem()
+		}
+		switch item.Kind() {
+		case reflect.Slice, reflect.Array:
+			for j := 0; j < item.Len(); j++ {
+				elem := item.Index(j)
+				if elem.Kind() == reflect.Interface {
+					if elem.IsNil() {
+						continue
+					}
+					elem = elem.Elem()
+				}
+				f(elem)
+			}
+		default:
+			f(item)
+		}
+	}
+}
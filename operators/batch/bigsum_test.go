@@ -0,0 +1,41 @@
+package batch
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestBatchFuncs_BigIntSum(t *testing.T) {
+	op := BigIntSumFunc()
+	if sum := op.Apply(context.TODO(), []int{1, 2, 3}); sum != int64(6) {
+		t.Fatal("expecting native sum when it fits, got", sum)
+	}
+
+	sum := op.Apply(context.TODO(), [][]int64{{math.MaxInt64, math.MaxInt64}, {2}})
+	expected, _ := new(big.Int).SetString("18446744073709551616", 10) // 2 * MaxInt64 + 2
+	if bigSum, ok := sum.(*big.Int); !ok || bigSum.Cmp(expected) != 0 {
+		t.Fatal("unexpected big sum", sum)
+	}
+
+	if sum := op.Apply(context.TODO(), []uint64{math.MaxUint64, 1}); sum.(*big.Int).String() != "18446744073709551616" {
+		t.Fatal("unexpected unsigned sum", sum)
+	}
+}
+
+func TestBatchFuncs_BigFloatSum(t *testing.T) {
+	op := BigFloatSumFunc()
+	if sum := op.Apply(context.TODO(), []interface{}{1, 2.5}); sum != 3.5 {
+		t.Fatal("expecting native sum when exact, got", sum)
+	}
+
+	sum := op.Apply(context.TODO(), []interface{}{1e20, 1, -1e20, int64(math.MaxInt64), int64(math.MaxInt64)})
+	bigSum, ok := sum.(*big.Float)
+	if !ok {
+		t.Fatal("expecting big sum, got", sum)
+	}
+	if text := bigSum.Text('f', 0); text != "18446744073709551615" { // 2 * MaxInt64 + 1
+		t.Fatal("unexpected big sum", text)
+	}
+}
@@ -2,11 +2,15 @@ package batch
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"sort"
 	"strings"
 
 	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/api/tuple"
+	"github.com/vladimirvivien/automi/sketch"
 	"github.com/vladimirvivien/automi/util"
 )
 
@@ -378,6 +382,187 @@ func SumFunc() api.UnFunc {
 	})
 }
 
+// DistinctCountFunc generates an api.UnFunc that estimates the number of
+// distinct keys in batched items (i.e. a window) from upstream.  The batch
+// is expected to be of type []T, keyFn is applied to each T to extract its
+// key (T itself is the key if keyFn is nil).  Small cardinalities are
+// counted exactly, larger ones are estimated using a HyperLogLog sketch.
+// Items with keys that are not hashable are reported to the error path.
+// The function returns the count as uint64.
+func DistinctCountFunc(keyFn func(interface{}) interface{}) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
+		dataType := reflect.TypeOf(param0)
+		dataVal := reflect.ValueOf(param0)
+
+		// validate expected type
+		if dataType.Kind() != reflect.Slice && dataType.Kind() != reflect.Array {
+			return param0 // ignores the data
+		}
+
+		counter := sketch.NewCardinality()
+		for i := 0; i < dataVal.Len(); i++ {
+			item := dataVal.Index(i).Interface()
+			key := item
+			if keyFn != nil {
+				key = keyFn(item)
+			}
+			if !sketch.Hashable(key) {
+				autoctx.Err(autoctx.GetErrFunc(ctx), api.ErrorWithItem(
+					fmt.Sprintf("distinct count: key of type %T is not hashable", key),
+					&api.StreamItem{Item: item},
+				))
+				continue
+			}
+			counter.Add(key)
+		}
+		return counter.Count()
+	})
+}
+
+// DistinctByKeyFunc generates an api.UnFunc that deduplicates the items of
+// each batch from upstream, of type []T, by the key returned by keyFn (the
+// item itself if keyFn is nil).  Only the first item of each key is kept,
+// in batch order, and the batch is sent downstream as []interface{}.  Keys
+// are only tracked within a batch (or window).  Items with keys that are
+// not comparable are reported to the error path.
+func DistinctByKeyFunc(keyFn func(interface{}) interface{}) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
+		dataType := reflect.TypeOf(param0)
+		dataVal := reflect.ValueOf(param0)
+
+		// validate expected type
+		if dataType.Kind() != reflect.Slice && dataType.Kind() != reflect.Array {
+			return param0 // ignores the data
+		}
+
+		seen := make(map[interface{}]struct{})
+		result := make([]interface{}, 0, dataVal.Len())
+		for i := 0; i < dataVal.Len(); i++ {
+			item := dataVal.Index(i).Interface()
+			key := item
+			if keyFn != nil {
+				key = keyFn(item)
+			}
+			if key != nil && !reflect.TypeOf(key).Comparable() {
+				autoctx.Err(autoctx.GetErrFunc(ctx), api.ErrorWithItem(
+					fmt.Sprintf("distinct by key: key of type %T is not comparable", key),
+					&api.StreamItem{Item: item},
+				))
+				continue
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			result = append(result, item)
+		}
+		return result
+	})
+}
+
+// MapPartitionFunc generates an api.UnFunc that passes each batch from upstream,
+// of type []T, to the user-provided function f as a []interface{} in a single call.
+// The batch returned by f is sent downstream and is not required to have the
+// same length as the input batch (items can be dropped or added).  When f
+// returns nil or an empty batch nothing is sent downstream.  Items that are
+// not slices or arrays are sent to the error path.
+func MapPartitionFunc(f func([]interface{}) []interface{}) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
+		dataType := reflect.TypeOf(param0)
+		dataVal := reflect.ValueOf(param0)
+
+		// validate expected type
+		if dataType == nil || (dataType.Kind() != reflect.Slice && dataType.Kind() != reflect.Array) {
+			return rejectItem(ctx, fmt.Sprintf("map partition: expecting a batch, got %T", param0), param0)
+		}
+
+		partition, ok := param0.([]interface{})
+		if !ok {
+			partition = make([]interface{}, dataVal.Len())
+			for i := 0; i < dataVal.Len(); i++ {
+				partition[i] = dataVal.Index(i).Interface()
+			}
+		}
+
+		result := f(partition)
+		if len(result) == 0 {
+			return nil
+		}
+		return result
+	})
+}
+
+// CountAnnotateFunc generates an api.UnFunc that pairs each element of a
+// batch from upstream, of type []T, with the number of elements of the batch
+// sharing its key (as returned by keyFn), as []tuple.KV{element, int64}.
+// Items that are not slices or arrays, and elements with keys that cannot be
+// used as map keys, are sent to the error path and dropped.
+func CountAnnotateFunc(keyFn func(interface{}) interface{}) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
+		dataType := reflect.TypeOf(param0)
+		dataVal := reflect.ValueOf(param0)
+
+		// validate expected type
+		if dataType == nil || (dataType.Kind() != reflect.Slice && dataType.Kind() != reflect.Array) {
+			return rejectItem(ctx, fmt.Sprintf("count annotate: expecting a batch, got %T", param0), param0)
+		}
+
+		items := make([]interface{}, 0, dataVal.Len())
+		keys := make([]interface{}, 0, dataVal.Len())
+		counts := make(map[interface{}]int64)
+		for i := 0; i < dataVal.Len(); i++ {
+			item := dataVal.Index(i).Interface()
+			key := keyFn(item)
+			if key != nil && !reflect.TypeOf(key).Comparable() {
+				rejectItem(ctx, fmt.Sprintf("count annotate: key of type %T is not comparable", key), item)
+				continue
+			}
+			items = append(items, item)
+			keys = append(keys, key)
+			counts[key]++
+		}
+
+		result := make([]tuple.KV, len(keys))
+		for i, key := range keys {
+			result[i] = tuple.KV{items[i], counts[key]}
+		}
+		return result
+	})
+}
+
+// Item is an element of a batch along with its provenance: the zero-based
+// index of its batch in the stream and its index within the batch.
+type Item struct {
+	BatchIndex int
+	ItemIndex  int
+	Value      interface{}
+}
+
+// DebatchFunc generates an api.UnFunc that converts each batch from upstream,
+// of type []T, into a []Item recording the provenance of each element (use
+// ReStream afterward to send the elements individually).  Items that are not
+// slices or arrays are sent to the error path, and are not counted as
+// batches.  The function must be applied by a single worker.
+func DebatchFunc() api.UnFunc {
+	var batchIndex int
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
+		dataType := reflect.TypeOf(param0)
+		dataVal := reflect.ValueOf(param0)
+
+		// validate expected type
+		if dataType == nil || (dataType.Kind() != reflect.Slice && dataType.Kind() != reflect.Array) {
+			return rejectItem(ctx, fmt.Sprintf("debatch: expecting a batch, got %T", param0), param0)
+		}
+
+		result := make([]Item, dataVal.Len())
+		for i := range result {
+			result[i] = Item{BatchIndex: batchIndex, ItemIndex: i, Value: dataVal.Index(i).Interface()}
+		}
+		batchIndex++
+		return result
+	})
+}
+
 // SortFunc generates an api.UnFunc that sorts batched data from upstream.
 // The batched items are expected to be in the following type:
 //   []T - where T is comparable type (string, numeric, etc)
@@ -551,6 +736,13 @@ func ForAll(f func(ctx context.Context, batch interface{}) map[interface{}][]int
 	})
 }
 
+// rejectItem reports data, with msg, to the error path and drops it
+// (an api.StreamError carrying an item would send the item downstream)
+func rejectItem(ctx context.Context, msg string, data interface{}) interface{} {
+	autoctx.Err(autoctx.GetErrFunc(ctx), api.ErrorWithItem(msg, &api.StreamItem{Item: data}))
+	return nil
+}
+
 func sumAll(item reflect.Value) float64 {
 	if !item.IsValid() {
 		return 0.0
@@ -3,8 +3,20 @@ package batch
 import (
 	"context"
 	"testing"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/api/tuple"
 )
 
+func errorsContext() (context.Context, *[]api.StreamError) {
+	errs := new([]api.StreamError)
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		*errs = append(*errs, err)
+	})
+	return ctx, errs
+}
+
 func TestBatchFuncs_GroupByPos_WithSlice(t *testing.T) {
 	op := GroupByPosFunc(0)
 	data := [][]string{
@@ -317,3 +329,98 @@ func TestBatchFuncs_SortWithFunc(t *testing.T) {
 		t.Fatal("Unexpected sort order")
 	}
 }
+
+func TestBatchFuncs_DistinctCount(t *testing.T) {
+	op := DistinctCountFunc(func(item interface{}) interface{} {
+		return item.(map[string]string)["Vehicle"]
+	})
+	data := []map[string]string{
+		{"Vehicle": "Spirit"},
+		{"Vehicle": "Voyager"},
+		{"Vehicle": "Spirit"},
+	}
+	val := op.Apply(context.TODO(), data)
+	if val.(uint64) != 2 {
+		t.Fatal("unexpected distinct count", val)
+	}
+}
+
+func TestBatchFuncs_DistinctByKey(t *testing.T) {
+	op := DistinctByKeyFunc(func(item interface{}) interface{} {
+		return item.(map[string]string)["Vehicle"]
+	})
+	data := []map[string]string{
+		{"Vehicle": "Spirit", "Sol": "1"},
+		{"Vehicle": "Voyager", "Sol": "1"},
+		{"Vehicle": "Spirit", "Sol": "2"},
+	}
+	items := op.Apply(context.TODO(), data).([]interface{})
+	if len(items) != 2 || items[0].(map[string]string)["Sol"] != "1" || items[1].(map[string]string)["Vehicle"] != "Voyager" {
+		t.Fatal("unexpected distinct items", items)
+	}
+}
+
+func TestBatchFuncs_MapPartition(t *testing.T) {
+	op := MapPartitionFunc(func(batch []interface{}) []interface{} {
+		return batch[:1]
+	})
+	val := op.Apply(context.TODO(), []string{"Spirit", "Voyager"})
+	if items := val.([]interface{}); len(items) != 1 || items[0] != "Spirit" {
+		t.Fatal("unexpected partition", val)
+	}
+	ctx, errs := errorsContext()
+	if result := op.Apply(ctx, "Spirit"); result != nil {
+		t.Fatal("expecting non-batch item to be dropped, got", result)
+	}
+	if len(*errs) != 1 || (*errs)[0].Item().Item != "Spirit" {
+		t.Fatal("expecting non-batch item on the error path, got", *errs)
+	}
+}
+
+func TestBatchFuncs_Debatch(t *testing.T) {
+	op := DebatchFunc()
+	ctx, errs := errorsContext()
+	op.Apply(ctx, []string{"Spirit", "Voyager"})
+	if result := op.Apply(ctx, "Spirit"); result != nil {
+		t.Fatal("expecting non-batch item to be dropped, got", result)
+	}
+	if len(*errs) != 1 || (*errs)[0].Item().Item != "Spirit" {
+		t.Fatal("expecting non-batch item on the error path, got", *errs)
+	}
+	items := op.Apply(ctx, []int{7, 8}).([]Item)
+	if len(items) != 2 || items[1] != (Item{BatchIndex: 1, ItemIndex: 1, Value: 8}) {
+		t.Fatal("unexpected provenance", items)
+	}
+}
+
+func TestBatchFuncs_CountAnnotate(t *testing.T) {
+	op := CountAnnotateFunc(func(item interface{}) interface{} { return item.(int) % 2 })
+	result := op.Apply(context.TODO(), []int{1, 2, 3}).([]tuple.KV)
+	if len(result) != 3 || result[0][1] != int64(2) || result[1][1] != int64(1) || result[2][0] != 3 {
+		t.Fatal("unexpected annotated batch", result)
+	}
+	ctx, errs := errorsContext()
+	if result := op.Apply(ctx, 1); result != nil {
+		t.Fatal("expecting non-batch item to be dropped, got", result)
+	}
+	if len(*errs) != 1 || (*errs)[0].Item().Item != 1 {
+		t.Fatal("expecting non-batch item on the error path, got", *errs)
+	}
+}
+
+func TestBatchFuncs_CountAnnotate_NotComparable(t *testing.T) {
+	op := CountAnnotateFunc(func(item interface{}) interface{} {
+		if item == "bad" {
+			return []string{"bad"}
+		}
+		return item
+	})
+	ctx, errs := errorsContext()
+	result := op.Apply(ctx, []string{"a", "bad", "a"}).([]tuple.KV)
+	if len(result) != 2 || result[0][0] != "a" || result[1][1] != int64(2) {
+		t.Fatal("unexpected annotated batch", result)
+	}
+	if len(*errs) != 1 || (*errs)[0].Item().Item != "bad" {
+		t.Fatal("expecting element with bad key on the error path, got", *errs)
+	}
+}
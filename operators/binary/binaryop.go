@@ -14,6 +14,7 @@ import (
 type BinaryOperator struct {
 	op          api.BinOperation
 	state       interface{}
+	pos         interface{} // position of last reduced item
 	concurrency int
 	input       <-chan interface{}
 	output      chan interface{}
@@ -71,7 +72,10 @@ func (o *BinaryOperator) Exec(ctx context.Context) (err error) {
 
 	go func() {
 		defer func() {
-			o.output <- o.state
+			select {
+			case o.output <- api.WithPosition(o.state, o.pos):
+			case <-ctx.Done():
+			}
 			close(o.output)
 			util.Logfn(o.logf, "Binary operator done")
 		}()
@@ -101,7 +105,13 @@ func (o *BinaryOperator) doOp(ctx context.Context) {
 				return
 			}
 
-			o.state = o.op.Apply(exeCtx, o.state, item)
+			// positioned items are reduced unwrapped, the result
+			// carries the position of the last reduced item.
+			data, pos := api.UnwrapPosition(item)
+			if pos != nil {
+				o.pos = pos
+			}
+			o.state = o.op.Apply(exeCtx, o.state, data)
 
 			switch val := o.state.(type) {
 			case nil:
@@ -6,6 +6,8 @@ import (
 	"reflect"
 
 	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/sketch"
 )
 
 // ReduceFunc returns a binary function which takes a user-defined accumulator
@@ -54,3 +56,57 @@ func isBinaryFuncForm(ftype reflect.Type) error {
 	}
 	return nil
 }
+
+// DistinctCountFunc returns a binary function that accumulates the keys,
+// returned by keyFn for each streamed item, into a *sketch.Cardinality used
+// to estimate the number of distinct keys with bounded memory (see package
+// sketch).  If keyFn is nil, the item itself is used as the key. Items with
+// keys that are not hashable are reported to the error path and skipped.
+func DistinctCountFunc(keyFn func(interface{}) interface{}) api.BinFunc {
+	return api.BinFunc(func(ctx context.Context, op0, op1 interface{}) interface{} {
+		counter, ok := op0.(*sketch.Cardinality)
+		if !ok || counter == nil {
+			counter = sketch.NewCardinality()
+		}
+		key := op1
+		if keyFn != nil {
+			key = keyFn(op1)
+		}
+		if !sketch.Hashable(key) {
+			autoctx.Err(autoctx.GetErrFunc(ctx), api.ErrorWithItem(
+				fmt.Sprintf("distinct count: key of type %T is not hashable", key),
+				&api.StreamItem{Item: op1},
+			))
+			return counter
+		}
+		counter.Add(key)
+		return counter
+	})
+}
+
+// HeavyHittersFunc returns a binary function that adds the keys, returned
+// by keyFn for each streamed item, to a *sketch.HeavyHitters tracking the
+// most frequent keys with bounded memory (see package sketch).  If keyFn is
+// nil, the item itself is used as the key.  Items with keys that are not
+// hashable are reported to the error path and skipped.
+func HeavyHittersFunc(keyFn func(interface{}) interface{}, capacity int) api.BinFunc {
+	return api.BinFunc(func(ctx context.Context, op0, op1 interface{}) interface{} {
+		hitters, ok := op0.(*sketch.HeavyHitters)
+		if !ok || hitters == nil {
+			hitters = sketch.NewHeavyHitters(capacity, 0, 0)
+		}
+		key := op1
+		if keyFn != nil {
+			key = keyFn(op1)
+		}
+		if !sketch.Hashable(key) {
+			autoctx.Err(autoctx.GetErrFunc(ctx), api.ErrorWithItem(
+				fmt.Sprintf("heavy hitters: key of type %T is not hashable", key),
+				&api.StreamItem{Item: op1},
+			))
+			return hitters
+		}
+		hitters.Add(key)
+		return hitters
+	})
+}
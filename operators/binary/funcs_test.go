@@ -3,6 +3,8 @@ package binary
 import (
 	"context"
 	"testing"
+
+	"github.com/vladimirvivien/automi/sketch"
 )
 
 func TestBinaryFunc_Reduce(t *testing.T) {
@@ -25,3 +27,14 @@ func TestBinaryFunc_Reduce(t *testing.T) {
 		t.Fatal("unexpected result from ReduceFunc: ", seed)
 	}
 }
+
+func TestBinaryFunc_DistinctCount(t *testing.T) {
+	op := DistinctCountFunc(nil)
+	var state interface{}
+	for _, v := range []int{1, 2, 2, 3, 1} {
+		state = op.Apply(context.TODO(), state, v)
+	}
+	if count := state.(*sketch.Cardinality).Count(); count != 3 {
+		t.Fatal("unexpected distinct count", count)
+	}
+}
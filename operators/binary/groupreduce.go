@@ -0,0 +1,376 @@
+package binary
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/api/tuple"
+	"github.com/vladimirvivien/automi/sketch"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// entryOverhead approximates the memory used by a group entry,
+// in addition to its key representation
+const entryOverhead = 64
+
+// GroupReduceOperator groups streamed items by key and reduces the items of
+// each group into an accumulator.  When the upstream closes, the result of
+// each group is sent downstream as tuple.KV{key, accumulator}, ordered by
+// the string representation of the keys.
+//
+// To handle key spaces too large for memory, a spill threshold can be set:
+// when the estimated size of the groups held in memory exceeds it, the
+// groups are written, sorted by key, to a temporary file and cleared. All
+// spilled files are merged (similar to an external sort) when the upstream
+// closes, using the combine function (which must be associative and
+// commutative) to merge the accumulators of a key spilled more than once.
+// Spilled keys and accumulators are encoded with encoding/gob, types other
+// than the built-in ones must be registered with gob.Register, and pointer
+// keys lose their identity once spilled (use value keys).  Temporary files
+// are removed on completion or cancellation.
+//
+// Keys with the same string representation are kept in the same bucket and
+// told apart by comparing the keys themselves (with ==, or
+// reflect.DeepEqual for keys that are not comparable).
+type GroupReduceOperator struct {
+	keyFn     func(interface{}) interface{}
+	reduceFn  func(acc, item interface{}) interface{}
+	combineFn func(acc0, acc1 interface{}) interface{}
+	threshold int64
+	tempDir   string
+
+	groups map[string][]*groupEntry // entries bucketed by sort key
+	size   int64
+	spills []string
+
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// groupEntry is the accumulator of a key, it is also the spilled record
+type groupEntry struct {
+	SortKey string
+	Key     interface{}
+	Acc     interface{}
+}
+
+// GroupReduce creates a *GroupReduceOperator.  The keyFn selects the key of
+// each item, reduceFn folds an item into the accumulator of its group (the
+// accumulator is nil for the first item of a group) and combineFn merges two
+// accumulators of the same key (only used when groups are spilled).
+func GroupReduce(
+	keyFn func(interface{}) interface{},
+	reduceFn func(acc, item interface{}) interface{},
+	combineFn func(acc0, acc1 interface{}) interface{},
+) *GroupReduceOperator {
+	return &GroupReduceOperator{
+		keyFn:     keyFn,
+		reduceFn:  reduceFn,
+		combineFn: combineFn,
+		groups:    make(map[string][]*groupEntry),
+		output:    make(chan interface{}, 1024),
+	}
+}
+
+// SpillThreshold sets the estimated size, in bytes, of the groups held in
+// memory beyond which they are spilled to disk.  Zero (the default)
+// keeps all groups in memory.
+func (o *GroupReduceOperator) SpillThreshold(bytes int64) *GroupReduceOperator {
+	o.threshold = bytes
+	return o
+}
+
+// TempDir sets the directory for spilled files (default os.TempDir())
+func (o *GroupReduceOperator) TempDir(dir string) *GroupReduceOperator {
+	o.tempDir = dir
+	return o
+}
+
+// SetInput sets the input channel for the executor node
+func (o *GroupReduceOperator) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+// GetOutput returns the output channel for the executor node
+func (o *GroupReduceOperator) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+// Exec executes the operator
+func (o *GroupReduceOperator) Exec(ctx context.Context) error {
+	o.logf = autoctx.GetLogFunc(ctx)
+	o.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(o.logf, "Group reduce operator starting")
+
+	if o.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+	if o.keyFn == nil || o.reduceFn == nil {
+		return fmt.Errorf("Group reduce operator missing key or reduce function")
+	}
+	if o.threshold > 0 && o.combineFn == nil {
+		return fmt.Errorf("Group reduce operator requires a combine function to spill")
+	}
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			for _, file := range o.spills {
+				os.Remove(file)
+			}
+			cancel()
+			close(o.output)
+			util.Logfn(o.logf, "Group reduce operator done")
+		}()
+
+		for {
+			select {
+			case item, opened := <-o.input:
+				if !opened {
+					if err := o.emit(exeCtx); err != nil {
+						o.report(err)
+					}
+					return
+				}
+				data, _ := api.UnwrapPosition(item)
+				o.add(data)
+				if o.threshold > 0 && o.size > o.threshold {
+					if err := o.spill(); err != nil {
+						o.report(err)
+						return
+					}
+				}
+			case <-exeCtx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (o *GroupReduceOperator) add(item interface{}) {
+	key := o.keyFn(item)
+	sortKey := fmt.Sprintf("%T:%v", key, key)
+	bucket := o.groups[sortKey]
+	entry := findEntry(bucket, key)
+	if entry == nil {
+		entry = &groupEntry{SortKey: sortKey, Key: key}
+		o.groups[sortKey] = append(bucket, entry)
+		o.size += int64(len(sortKey)) + entryOverhead
+	}
+	entry.Acc = o.reduceFn(entry.Acc, item)
+}
+
+// findEntry returns the entry of bucket for key, or nil
+func findEntry(bucket []*groupEntry, key interface{}) *groupEntry {
+	for _, entry := range bucket {
+		if sameKey(entry.Key, key) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// sameKey compares keys with ==, or reflect.DeepEqual for keys
+// that are not comparable
+func sameKey(k0, k1 interface{}) bool {
+	if sketch.Hashable(k0) && sketch.Hashable(k1) {
+		return k0 == k1
+	}
+	return reflect.DeepEqual(k0, k1)
+}
+
+// sorted returns the in-memory groups sorted by key, entries
+// of a bucket are kept in insertion order
+func (o *GroupReduceOperator) sorted() []*groupEntry {
+	var sortKeys []string
+	for sortKey := range o.groups {
+		sortKeys = append(sortKeys, sortKey)
+	}
+	sort.Strings(sortKeys)
+	entries := make([]*groupEntry, 0, len(o.groups))
+	for _, sortKey := range sortKeys {
+		entries = append(entries, o.groups[sortKey]...)
+	}
+	return entries
+}
+
+// spill writes the in-memory groups, sorted, to a temporary file
+func (o *GroupReduceOperator) spill() error {
+	file, err := ioutil.TempFile(o.tempDir, "automi-groupreduce")
+	if err != nil {
+		return err
+	}
+	o.spills = append(o.spills, file.Name())
+	util.Logfn(o.logf, fmt.Sprintf("Group reduce spilling %d groups to %s", len(o.groups), file.Name()))
+
+	writer := bufio.NewWriter(file)
+	enc := gob.NewEncoder(writer)
+	for _, entry := range o.sorted() {
+		if err := enc.Encode(entry); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	o.groups = make(map[string][]*groupEntry)
+	o.size = 0
+	return nil
+}
+
+// emit merges the spilled runs with the in-memory groups and sends
+// the group results downstream in key order
+func (o *GroupReduceOperator) emit(ctx context.Context) error {
+	var all []*run
+	memory := o.sorted()
+	if len(memory) > 0 {
+		all = append(all, &run{next: func() (*groupEntry, error) {
+			if len(memory) == 0 {
+				return nil, io.EOF
+			}
+			entry := memory[0]
+			memory = memory[1:]
+			return entry, nil
+		}})
+	}
+	for _, name := range o.spills {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		dec := gob.NewDecoder(bufio.NewReader(file))
+		all = append(all, &run{next: func() (*groupEntry, error) {
+			entry := new(groupEntry)
+			if err := dec.Decode(entry); err != nil {
+				return nil, err
+			}
+			return entry, nil
+		}})
+	}
+	// load the head entry of each run, dropping empty ones
+	runs := &runHeap{}
+	for _, r := range all {
+		if err := r.advance(); err != nil {
+			return err
+		}
+		if r.head != nil {
+			*runs = append(*runs, r)
+		}
+	}
+	heap.Init(runs)
+
+	// entries of the current sort key, merged by key
+	var current []*groupEntry
+	send := func() bool {
+		for _, entry := range current {
+			select {
+			case o.output <- tuple.KV{entry.Key, entry.Acc}:
+			case <-ctx.Done():
+				return false
+			}
+		}
+		return true
+	}
+	for runs.Len() > 0 {
+		r := (*runs)[0]
+		entry := r.head
+		if len(current) > 0 && current[0].SortKey == entry.SortKey {
+			if same := findEntry(current, entry.Key); same != nil {
+				same.Acc = o.combineFn(same.Acc, entry.Acc)
+			} else {
+				current = append(current, entry)
+			}
+		} else {
+			if !send() {
+				return nil
+			}
+			current = []*groupEntry{entry}
+		}
+		if err := r.advance(); err != nil {
+			return err
+		}
+		if r.head == nil {
+			heap.Pop(runs)
+		} else {
+			heap.Fix(runs, 0)
+		}
+	}
+	send()
+	return nil
+}
+
+func (o *GroupReduceOperator) report(err error) {
+	util.Logfn(o.logf, err)
+	autoctx.Err(o.errf, api.Error(err.Error()))
+}
+
+// run is a sorted sequence of group entries (in memory or spilled)
+type run struct {
+	head *groupEntry
+	next func() (*groupEntry, error)
+}
+
+// advance loads the next entry of the run, head is nil once exhausted
+func (r *run) advance() error {
+	entry, err := r.next()
+	if err == io.EOF {
+		r.head = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	r.head = entry
+	return nil
+}
+
+// runHeap orders runs by their head entry
+type runHeap []*run
+
+func (h runHeap) Len() int            { return len(h) }
+func (h runHeap) Less(i, j int) bool  { return h[i].head.SortKey < h[j].head.SortKey }
+func (h runHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x interface{}) { *h = append(*h, x.(*run)) }
+func (h *runHeap) Pop() interface{} {
+	old := *h
+	r := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return r
+}
+
+// GroupCount creates a *GroupReduceOperator that counts the items of each
+// group, the result of a group is sent downstream as tuple.KV{key, int64}.
+// Groups can be spilled to disk (see SpillThreshold).
+func GroupCount(keyFn func(interface{}) interface{}) *GroupReduceOperator {
+	return GroupReduce(
+		keyFn,
+		func(acc, item interface{}) interface{} {
+			if acc == nil {
+				return int64(1)
+			}
+			return acc.(int64) + 1
+		},
+		func(acc0, acc1 interface{}) interface{} {
+			return acc0.(int64) + acc1.(int64)
+		},
+	)
+}
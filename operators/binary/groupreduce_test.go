@@ -0,0 +1,177 @@
+package binary
+
+import (
+	"context"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/vladimirvivien/automi/api/tuple"
+)
+
+func TestGroupReduceOperator(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int64
+	}{
+		{name: "memory"},
+		{name: "spill", threshold: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "automi-groupreduce")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			in := make(chan interface{})
+			go func() {
+				for i := 0; i < 1000; i++ {
+					in <- i
+				}
+				close(in)
+			}()
+
+			sum := func(acc, item interface{}) interface{} {
+				if acc == nil {
+					return item
+				}
+				return acc.(int) + item.(int)
+			}
+			op := GroupReduce(
+				func(item interface{}) interface{} { return item.(int) % 7 },
+				sum,
+				sum,
+			).SpillThreshold(test.threshold).TempDir(dir)
+			op.SetInput(in)
+			if err := op.Exec(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			var results []tuple.KV
+			for item := range op.GetOutput() {
+				results = append(results, item.(tuple.KV))
+			}
+
+			if len(results) != 7 {
+				t.Fatal("expecting 7 groups, got", len(results))
+			}
+			for k, kv := range results {
+				expected := 0
+				for i := k; i < 1000; i += 7 {
+					expected += i
+				}
+				if kv[0] != k || kv[1] != expected {
+					t.Fatalf("group %d: expecting sum %d, got %v", k, expected, kv)
+				}
+			}
+			if test.threshold > 0 && len(op.spills) == 0 {
+				t.Fatal("expecting groups to be spilled")
+			}
+			if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+				t.Fatal("spilled files not removed:", len(files))
+			}
+		})
+	}
+}
+
+func TestGroupCountOperator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "automi-groupcount")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	in := make(chan interface{})
+	go func() {
+		for _, word := range []string{"b", "a", "b", "c", "b", "a"} {
+			in <- word
+		}
+		close(in)
+	}()
+	op := GroupCount(func(item interface{}) interface{} { return item }).SpillThreshold(1).TempDir(dir)
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var results []tuple.KV
+	for item := range op.GetOutput() {
+		results = append(results, item.(tuple.KV))
+	}
+	expected := []tuple.KV{{"a", int64(2)}, {"b", int64(3)}, {"c", int64(1)}}
+	if len(results) != len(expected) {
+		t.Fatal("unexpected counts", results)
+	}
+	for i := range expected {
+		if results[i][0] != expected[i][0] || results[i][1] != expected[i][1] {
+			t.Fatal("unexpected counts", results)
+		}
+	}
+}
+
+// samePrint keys are distinct but have the same string representation
+type samePrint struct{ ID int }
+
+func (samePrint) String() string { return "same" }
+
+func TestGroupReduceOperator_SamePrintedKeys(t *testing.T) {
+	gob.Register(samePrint{})
+	tests := []struct {
+		name      string
+		threshold int64
+	}{
+		{name: "memory"},
+		{name: "spill", threshold: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := make(chan interface{})
+			go func() {
+				for _, id := range []int{1, 2, 1, 2, 1} {
+					in <- samePrint{ID: id}
+				}
+				close(in)
+			}()
+
+			op := GroupCount(func(item interface{}) interface{} { return item }).SpillThreshold(test.threshold)
+			op.SetInput(in)
+			if err := op.Exec(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			counts := make(map[samePrint]int64)
+			for item := range op.GetOutput() {
+				kv := item.(tuple.KV)
+				counts[kv[0].(samePrint)] += kv[1].(int64)
+			}
+			if len(counts) != 2 || counts[samePrint{1}] != 3 || counts[samePrint{2}] != 2 {
+				t.Fatal("expecting keys counted apart, got", counts)
+			}
+		})
+	}
+
+	// pointer keys are told apart by identity
+	k0, k1 := &samePrint{ID: 1}, &samePrint{ID: 1}
+	in := make(chan interface{}, 3)
+	in <- k0
+	in <- k1
+	in <- k0
+	close(in)
+	op := GroupCount(func(item interface{}) interface{} { return item })
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var results []tuple.KV
+	for item := range op.GetOutput() {
+		results = append(results, item.(tuple.KV))
+	}
+	if len(results) != 2 || results[0][0] != k0 || results[0][1] != int64(2) || results[1][0] != k1 {
+		t.Fatal("expecting pointer keys counted apart, got", results)
+	}
+}
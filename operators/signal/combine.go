@@ -0,0 +1,116 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// CombineFunc combines the latest item of the stream (a) with
+// the latest item of the other emitter (b)
+type CombineFunc func(a, b interface{}) interface{}
+
+// CombineLatestOperator is an operator that, whenever the stream or
+// another emitter produces an item, sends downstream the combination of
+// the latest item of each.  Nothing is sent until both have produced an
+// item.  The output is closed once both the stream and the emitter close.
+type CombineLatestOperator struct {
+	other   api.Emitter
+	combine CombineFunc
+	input   <-chan interface{}
+	output  chan interface{}
+	logf    api.LogFunc
+}
+
+// CombineLatest creates a *CombineLatestOperator combining the
+// stream with the other emitter using the combine function
+func CombineLatest(other api.Emitter, combine CombineFunc) *CombineLatestOperator {
+	return &CombineLatestOperator{
+		other:   other,
+		combine: combine,
+		output:  make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (o *CombineLatestOperator) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+// GetOutput returns the output channel for the executor node
+func (o *CombineLatestOperator) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+// Exec starts the operator.  If the other emitter is an api.Source,
+// it is opened with the operator's context.
+func (o *CombineLatestOperator) Exec(ctx context.Context) error {
+	o.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(o.logf, "CombineLatest operator starting")
+
+	if o.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+	if o.other == nil {
+		return fmt.Errorf("No emitter found to combine with")
+	}
+	if o.combine == nil {
+		return fmt.Errorf("CombineLatest operator missing combine function")
+	}
+	if src, ok := o.other.(api.Source); ok {
+		if err := src.Open(ctx); err != nil {
+			return err
+		}
+	}
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			util.Logfn(o.logf, "CombineLatest operator done")
+			cancel()
+			close(o.output)
+		}()
+		o.doOp(exeCtx)
+	}()
+	return nil
+}
+
+func (o *CombineLatestOperator) doOp(ctx context.Context) {
+	input, other := o.input, o.other.GetOutput()
+	var latestA, latestB interface{}
+	var hasA, hasB bool
+
+	for input != nil || other != nil {
+		var pos interface{}
+		select {
+		case item, opened := <-input:
+			if !opened {
+				input = nil
+				continue
+			}
+			latestA, pos = api.UnwrapPosition(item)
+			hasA = true
+		case item, opened := <-other:
+			if !opened {
+				other = nil
+				continue
+			}
+			latestB, _ = api.UnwrapPosition(item)
+			hasB = true
+		case <-ctx.Done():
+			return
+		}
+
+		if !hasA || !hasB {
+			continue
+		}
+		select {
+		case o.output <- api.WithPosition(o.combine(latestA, latestB), pos):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCombineLatestOperator(t *testing.T) {
+	in := make(chan interface{})
+	other := make(chanEmitter)
+	go func() {
+		other <- "x" // no emission, stream has no item yet
+		in <- 1
+		in <- 2
+		other <- "y"
+		in <- 3
+		close(other)
+		in <- 4
+		close(in)
+	}()
+
+	op := CombineLatest(other, func(a, b interface{}) interface{} {
+		return fmt.Sprintf("%v%v", a, b)
+	})
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var result []interface{}
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for item := range op.GetOutput() {
+			result = append(result, item)
+		}
+	}()
+	select {
+	case <-wait:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	expected := []interface{}{"1x", "2x", "2y", "3y", "4y"}
+	if len(result) != len(expected) {
+		t.Fatal("unexpected combinations", result)
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Fatal("unexpected combinations", result)
+		}
+	}
+}
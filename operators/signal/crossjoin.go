@@ -0,0 +1,122 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// CrossJoinOperator is an operator that sends downstream the cartesian
+// product of the stream (left) and another emitter (right).  The right
+// side is buffered entirely before the first left item is joined, then each
+// left item is combined with every right item, in the order they were
+// received.  Both memory (N right items) and time (N*M combinations) grow
+// with the sizes of both sides, it is intended for small or bounded inputs.
+type CrossJoinOperator struct {
+	right   api.Emitter
+	combine CombineFunc
+	input   <-chan interface{}
+	output  chan interface{}
+	logf    api.LogFunc
+}
+
+// CrossJoin creates a *CrossJoinOperator joining each item of the stream
+// with every item of right using the combine function.  If right closes
+// without emitting, the stream items are dropped and nothing is sent.
+func CrossJoin(right api.Emitter, combine CombineFunc) *CrossJoinOperator {
+	return &CrossJoinOperator{
+		right:   right,
+		combine: combine,
+		output:  make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (o *CrossJoinOperator) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+// GetOutput returns the output channel for the executor node
+func (o *CrossJoinOperator) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+// Exec starts the operator.  If the right emitter is an api.Source,
+// it is opened with the operator's context.
+func (o *CrossJoinOperator) Exec(ctx context.Context) error {
+	o.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(o.logf, "CrossJoin operator starting")
+
+	if o.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+	if o.right == nil {
+		return fmt.Errorf("No emitter found to join with")
+	}
+	if o.combine == nil {
+		return fmt.Errorf("CrossJoin operator missing combine function")
+	}
+	if src, ok := o.right.(api.Source); ok {
+		if err := src.Open(ctx); err != nil {
+			return err
+		}
+	}
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			util.Logfn(o.logf, "CrossJoin operator done")
+			cancel()
+			close(o.output)
+		}()
+		o.doOp(exeCtx)
+	}()
+	return nil
+}
+
+func (o *CrossJoinOperator) doOp(ctx context.Context) {
+	// buffer the right side
+	var rights []interface{}
+	right := o.right.GetOutput()
+	for right != nil {
+		select {
+		case item, opened := <-right:
+			if !opened {
+				right = nil
+				continue
+			}
+			data, _ := api.UnwrapPosition(item)
+			rights = append(rights, data)
+		case <-ctx.Done():
+			return
+		}
+	}
+	util.Logfn(o.logf, fmt.Sprintf("CrossJoin operator buffered %d right items", len(rights)))
+
+	for {
+		select {
+		case item, opened := <-o.input:
+			if !opened {
+				return
+			}
+			// the position of the left item goes with its last combination
+			left, pos := api.UnwrapPosition(item)
+			for i, r := range rights {
+				result := o.combine(left, r)
+				if i == len(rights)-1 {
+					result = api.WithPosition(result, pos)
+				}
+				select {
+				case o.output <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCrossJoinOperator(t *testing.T) {
+	tests := []struct {
+		name     string
+		right    []interface{}
+		expected []interface{}
+	}{
+		{name: "product", right: []interface{}{"a", "b"}, expected: []interface{}{"1a", "1b", "2a", "2b"}},
+		{name: "empty right", right: nil, expected: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := make(chan interface{})
+			right := make(chanEmitter)
+			go func() {
+				for _, r := range test.right {
+					right <- r
+				}
+				close(right)
+			}()
+			go func() {
+				in <- 1
+				in <- 2
+				close(in)
+			}()
+
+			op := CrossJoin(right, func(l, r interface{}) interface{} {
+				return fmt.Sprintf("%v%v", l, r)
+			})
+			op.SetInput(in)
+			if err := op.Exec(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			var result []interface{}
+			wait := make(chan struct{})
+			go func() {
+				defer close(wait)
+				for item := range op.GetOutput() {
+					result = append(result, item)
+				}
+			}()
+			select {
+			case <-wait:
+			case <-time.After(50 * time.Millisecond):
+				t.Fatal("Took too long")
+			}
+
+			if len(result) != len(test.expected) {
+				t.Fatal("unexpected result", result)
+			}
+			for i := range result {
+				if result[i] != test.expected[i] {
+					t.Fatal("unexpected result", result)
+				}
+			}
+		})
+	}
+}
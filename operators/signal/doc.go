@@ -0,0 +1,3 @@
+// Package signal provides operators whose flow of items is controlled by
+// another source (a signal) running alongside the stream.
+package signal
@@ -0,0 +1,119 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// GateOperator is an operator that passes streamed items only while its
+// gate is open.  The gate is toggled by the values received from a control
+// channel: true opens it, false closes it.
+type GateOperator struct {
+	open   <-chan bool
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+// Gate creates a *GateOperator controlled by the open channel.  The gate
+// starts opened.  While it is closed, the operator stops reading from
+// upstream, applying backpressure to the stream, until a true value is
+// received or the context is cancelled.  Once the open channel is closed,
+// the gate stays opened for the remaining items.
+func Gate(open <-chan bool) *GateOperator {
+	return &GateOperator{
+		open:   open,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (o *GateOperator) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+// GetOutput returns the output channel for the executor node
+func (o *GateOperator) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+// Exec starts the operator
+func (o *GateOperator) Exec(ctx context.Context) error {
+	o.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(o.logf, "Gate operator starting")
+
+	if o.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+	if o.open == nil {
+		return fmt.Errorf("No gate channel found")
+	}
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			util.Logfn(o.logf, "Gate operator done")
+			cancel()
+			close(o.output)
+		}()
+		o.doOp(exeCtx)
+	}()
+	return nil
+}
+
+func (o *GateOperator) doOp(ctx context.Context) {
+	control := o.open
+	opened := true
+
+	toggle := func(val bool, ok bool) {
+		if !ok {
+			control = nil
+			opened = true
+			return
+		}
+		if val != opened {
+			util.Logfn(o.logf, fmt.Sprintf("Gate operator opened: %t", val))
+		}
+		opened = val
+	}
+
+	for {
+		// a pending toggle takes precedence over pending items
+		select {
+		case val, ok := <-control:
+			toggle(val, ok)
+			continue
+		default:
+		}
+
+		if !opened {
+			select {
+			case val, ok := <-control:
+				toggle(val, ok)
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+
+		select {
+		case val, ok := <-control:
+			toggle(val, ok)
+		case item, ok := <-o.input:
+			if !ok {
+				return
+			}
+			select {
+			case o.output <- item:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
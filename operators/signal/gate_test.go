@@ -0,0 +1,78 @@
+package signal
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGateOperator(t *testing.T) {
+	in := make(chan interface{})
+	open := make(chan bool)
+	blocked := make(chan bool, 1)
+	go func() {
+		in <- 1
+		open <- false
+		select {
+		case in <- 2:
+			blocked <- false
+		case <-time.After(10 * time.Millisecond):
+			blocked <- true
+		}
+		open <- true
+		in <- 2
+		in <- 3
+		close(in)
+	}()
+
+	op := Gate(open)
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var result []interface{}
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for item := range op.GetOutput() {
+			result = append(result, item)
+		}
+	}()
+
+	select {
+	case <-wait:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+	if !<-blocked {
+		t.Fatal("expecting items to be held while the gate is closed")
+	}
+	if len(result) != 3 || result[0] != 1 || result[2] != 3 {
+		t.Fatal("unexpected result", result)
+	}
+}
+
+func TestGateOperator_Cancelled(t *testing.T) {
+	in := make(chan interface{}, 1)
+	in <- 1
+	open := make(chan bool, 1)
+	open <- false
+
+	ctx, cancel := context.WithCancel(context.Background())
+	op := Gate(open)
+	op.SetInput(in)
+	if err := op.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	select {
+	case _, ok := <-op.GetOutput():
+		if ok {
+			t.Fatal("expecting no item while the gate is closed")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("expecting output to close on cancel")
+	}
+}
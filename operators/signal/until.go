@@ -0,0 +1,153 @@
+package signal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+type untilMode int
+
+const (
+	skipUntil untilMode = iota
+	takeUntil
+)
+
+// UntilOperator is an operator that drops (SkipUntil) or passes (TakeUntil)
+// streamed items until a signal emitter produces its first item.
+type UntilOperator struct {
+	mode   untilMode
+	signal api.Emitter
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+// SkipUntil creates an *UntilOperator that drops items until the signal
+// emits its first item, the remaining items are then passed downstream.
+// If the signal closes without emitting, all items are dropped.
+func SkipUntil(signal api.Emitter) *UntilOperator {
+	return newUntil(skipUntil, signal)
+}
+
+// TakeUntil creates an *UntilOperator that passes items downstream until
+// the signal emits its first item, the operator then closes its output and
+// discards the remaining items from upstream. If the signal closes without
+// emitting, all items are passed downstream.
+func TakeUntil(signal api.Emitter) *UntilOperator {
+	return newUntil(takeUntil, signal)
+}
+
+func newUntil(mode untilMode, signal api.Emitter) *UntilOperator {
+	return &UntilOperator{
+		mode:   mode,
+		signal: signal,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (o *UntilOperator) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+// GetOutput returns the output channel for the executor node
+func (o *UntilOperator) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+// Exec starts the operator.  If the signal is an api.Source, it is
+// opened with the operator's context.
+func (o *UntilOperator) Exec(ctx context.Context) error {
+	o.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(o.logf, "Until operator starting")
+
+	if o.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+	if o.signal == nil {
+		return fmt.Errorf("No signal emitter found")
+	}
+	if src, ok := o.signal.(api.Source); ok {
+		if err := src.Open(ctx); err != nil {
+			return err
+		}
+	}
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			util.Logfn(o.logf, "Until operator done")
+			cancel()
+		}()
+		o.doOp(exeCtx)
+	}()
+	return nil
+}
+
+func (o *UntilOperator) doOp(ctx context.Context) {
+	signal := o.signal.GetOutput()
+	fired := false
+	closed := false
+	defer func() {
+		if !closed {
+			close(o.output)
+		}
+	}()
+
+	fire := func() {
+		if !fired {
+			util.Logfn(o.logf, "Until operator signaled")
+		}
+		fired = true
+		// once TakeUntil fires, close the output and drain upstream
+		if o.mode == takeUntil && !closed {
+			close(o.output)
+			closed = true
+		}
+	}
+
+	for {
+		// a pending signal takes precedence over pending items
+		select {
+		case _, opened := <-signal:
+			if !opened {
+				signal = nil
+				continue
+			}
+			fire()
+			continue
+		default:
+		}
+
+		select {
+		case _, opened := <-signal:
+			if !opened {
+				signal = nil
+				continue
+			}
+			fire()
+		case item, opened := <-o.input:
+			if !opened {
+				return
+			}
+			pass := fired
+			if o.mode == takeUntil {
+				pass = !fired
+			}
+			if !pass {
+				continue
+			}
+			select {
+			case o.output <- item:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package signal
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type chanEmitter chan interface{}
+
+func (c chanEmitter) GetOutput() <-chan interface{} { return c }
+
+func TestUntilOperator(t *testing.T) {
+	tests := []struct {
+		name     string
+		op       func(sig chanEmitter) *UntilOperator
+		expected []interface{}
+	}{
+		{name: "take", op: func(sig chanEmitter) *UntilOperator { return TakeUntil(sig) }, expected: []interface{}{1, 2, 3}},
+		{name: "skip", op: func(sig chanEmitter) *UntilOperator { return SkipUntil(sig) }, expected: []interface{}{4, 5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			in := make(chan interface{})
+			sig := make(chanEmitter)
+			go func() {
+				for i := 1; i <= 5; i++ {
+					if i == 4 {
+						sig <- struct{}{}
+					}
+					in <- i
+				}
+				close(in)
+				close(sig)
+			}()
+
+			op := test.op(sig)
+			op.SetInput(in)
+			if err := op.Exec(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			var result []interface{}
+			wait := make(chan struct{})
+			go func() {
+				defer close(wait)
+				for item := range op.GetOutput() {
+					result = append(result, item)
+				}
+			}()
+
+			select {
+			case <-wait:
+			case <-time.After(50 * time.Millisecond):
+				t.Fatal("Took too long")
+			}
+			if len(result) != len(test.expected) {
+				t.Fatal("unexpected result", result)
+			}
+			for i := range result {
+				if result[i] != test.expected[i] {
+					t.Fatal("unexpected result", result)
+				}
+			}
+		})
+	}
+}
+
+func TestUntilOperator_SignalClosed(t *testing.T) {
+	in := make(chan interface{}, 3)
+	in <- 1
+	in <- 2
+	close(in)
+	sig := make(chanEmitter)
+	close(sig)
+
+	op := TakeUntil(sig)
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for range op.GetOutput() {
+		count++
+	}
+	if count != 2 {
+		t.Fatal("expecting all items when signal never fires, got", count)
+	}
+}
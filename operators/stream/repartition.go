@@ -0,0 +1,136 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/sketch"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// RepartitionOperator is an operator that redistributes streamed items
+// across a fixed number of lanes by key: items with the same key (as
+// returned by the key function) always go to the same lane, selected by
+// hashing the key.  Items with keys that cannot be hashed are reported to
+// the error path.  The main output of the operator emits no items, it and
+// all lanes are closed once the upstream closes.
+type RepartitionOperator struct {
+	keyFn  func(interface{}) interface{}
+	input  <-chan interface{}
+	output chan interface{}
+	lanes  []chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// NewRepartition creates a *RepartitionOperator with n lanes
+func NewRepartition(keyFn func(interface{}) interface{}, n int) *RepartitionOperator {
+	if n < 1 {
+		n = 1
+	}
+	o := &RepartitionOperator{
+		keyFn:  keyFn,
+		output: make(chan interface{}, 1024),
+		lanes:  make([]chan interface{}, n),
+	}
+	for i := range o.lanes {
+		o.lanes[i] = make(chan interface{}, 1024)
+	}
+	return o
+}
+
+// SetInput sets the input channel for the executor node
+func (o *RepartitionOperator) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+// GetOutput returns the main output channel, which emits no items
+func (o *RepartitionOperator) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+// Lanes returns the number of lanes of the operator
+func (o *RepartitionOperator) Lanes() int {
+	return len(o.lanes)
+}
+
+// Lane returns an api.Source emitting the items of lane i, or nil if
+// there is no such lane.  Every lane must be consumed, otherwise the
+// operator blocks once the lane buffer is full.
+func (o *RepartitionOperator) Lane(i int) api.Source {
+	if i < 0 || i >= len(o.lanes) {
+		return nil
+	}
+	return &laneSource{output: o.lanes[i]}
+}
+
+// Exec is the entry point for the executor
+func (o *RepartitionOperator) Exec(ctx context.Context) error {
+	o.logf = autoctx.GetLogFunc(ctx)
+	o.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(o.logf, "Repartition operator started")
+
+	if o.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+	if o.keyFn == nil {
+		return fmt.Errorf("Repartition operator missing key function")
+	}
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			util.Logfn(o.logf, "Repartition operator done")
+			cancel()
+			close(o.output)
+			for _, lane := range o.lanes {
+				close(lane)
+			}
+		}()
+		o.doOp(exeCtx)
+	}()
+	return nil
+}
+
+func (o *RepartitionOperator) doOp(ctx context.Context) {
+	for {
+		select {
+		case item, opened := <-o.input:
+			if !opened {
+				return
+			}
+			data, _ := api.UnwrapPosition(item)
+			key := o.keyFn(data)
+			if !sketch.Hashable(key) {
+				err := api.ErrorWithItem(fmt.Sprintf("repartition key of type %T is not comparable", key), &api.StreamItem{Item: data})
+				util.Logfn(o.logf, err)
+				autoctx.Err(o.errf, err)
+				continue
+			}
+			lane := o.lanes[sketch.Hash(key)%uint64(len(o.lanes))]
+			select {
+			case lane <- item:
+			case <-ctx.Done():
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// laneSource emits the items of a lane
+type laneSource struct {
+	output <-chan interface{}
+}
+
+func (s *laneSource) GetOutput() <-chan interface{} {
+	return s.output
+}
+
+// Open is a no-op, items flow once the repartition operator executes
+func (s *laneSource) Open(context.Context) error {
+	return nil
+}
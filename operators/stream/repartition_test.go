@@ -0,0 +1,64 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRepartition_Exec(t *testing.T) {
+	type order struct {
+		ID       int
+		Customer string
+	}
+	op := NewRepartition(func(item interface{}) interface{} { return item.(order).Customer }, 3)
+	in := make(chan interface{})
+	go func() {
+		defer close(in)
+		for i, c := range []string{"ann", "bob", "cal", "ann", "dee", "bob", "ann"} {
+			in <- order{ID: i, Customer: c}
+		}
+	}()
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	laneOf := make(map[string]int)
+	counts := 0
+	var wg sync.WaitGroup
+	for i := 0; i < op.Lanes(); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for item := range op.Lane(i).GetOutput() {
+				mu.Lock()
+				c := item.(order).Customer
+				if lane, ok := laneOf[c]; ok && lane != i {
+					t.Errorf("key %s on lanes %d and %d", c, lane, i)
+				}
+				laneOf[c] = i
+				counts++
+				mu.Unlock()
+			}
+		}(i)
+	}
+
+	select {
+	case _, opened := <-op.GetOutput():
+		if opened {
+			t.Fatal("main output should emit no items")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	wg.Wait()
+	if counts != 7 || len(laneOf) != 4 {
+		t.Fatal("unexpected lane distribution", counts, laneOf)
+	}
+	if op.Lane(3) != nil {
+		t.Fatal("expecting nil for unknown lane")
+	}
+}
@@ -0,0 +1,303 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// SpoolOperator is an operator that buffers streamed items, without
+// blocking the upstream, until downstream is ready to receive them (a
+// disk-backed FIFO).  Up to memLimit items are kept in memory, the excess
+// is appended to temporary files and read back in order when downstream
+// catches up.  Spooled items (and their source positions) are encoded with
+// encoding/gob, types other than the built-in ones must be registered with
+// gob.Register.  Items that cannot be encoded are kept in memory, in order,
+// and when spooling fails (i.e. the disk is full) the error is sent to the
+// error path and the following items are kept in memory.  Temporary files
+// are removed on completion or cancellation.
+type SpoolOperator struct {
+	memLimit int
+	dir      string
+	memOnly  bool // spooling failed, items are kept in memory
+
+	head     []interface{}  // next items to send, in memory
+	segments []spoolSegment // spooled items, oldest first
+	writer   *spoolWriter   // file receiving new spooled items
+	reader   *spoolReader   // file being read back (first segment)
+
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// spoolRecord is a spooled item
+type spoolRecord struct {
+	Item     interface{}
+	Position interface{}
+}
+
+// spoolSegment is a spooled file, or items held in memory between spooled
+// files when they cannot be spooled
+type spoolSegment struct {
+	name  string
+	items []interface{}
+}
+
+type spoolWriter struct {
+	file    *os.File
+	buffer  *bufio.Writer
+	record  bytes.Buffer // encoded record, written to buffer once complete
+	enc     *gob.Encoder
+	records int
+}
+
+type spoolReader struct {
+	file *os.File
+	dec  *gob.Decoder
+}
+
+// NewSpool creates a *SpoolOperator keeping up to memLimit items in memory
+// and spooling the excess to files in dir (os.TempDir() if empty)
+func NewSpool(memLimit int, dir string) *SpoolOperator {
+	return &SpoolOperator{
+		memLimit: memLimit,
+		dir:      dir,
+		output:   make(chan interface{}),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (o *SpoolOperator) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+// GetOutput returns the output channel for the executor node
+func (o *SpoolOperator) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+// Exec executes the operator
+func (o *SpoolOperator) Exec(ctx context.Context) error {
+	o.logf = autoctx.GetLogFunc(ctx)
+	o.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(o.logf, "Spool operator starting")
+
+	if o.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+	if o.memLimit < 1 {
+		return fmt.Errorf("Spool operator requires a memory limit greater than zero")
+	}
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			o.cleanup()
+			cancel()
+			close(o.output)
+			util.Logfn(o.logf, "Spool operator done")
+		}()
+
+		input := o.input
+		for {
+			if len(o.head) == 0 && o.spooled() {
+				o.refill()
+			}
+			if input == nil && len(o.head) == 0 {
+				return
+			}
+
+			var output chan interface{}
+			var next interface{}
+			if len(o.head) > 0 {
+				output, next = o.output, o.head[0]
+			}
+
+			select {
+			case item, opened := <-input:
+				if !opened {
+					input = nil
+					continue
+				}
+				o.add(item)
+			case output <- next:
+				o.head[0] = nil
+				o.head = o.head[1:]
+			case <-exeCtx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// spooled returns true when items are waiting on disk
+func (o *SpoolOperator) spooled() bool {
+	return len(o.segments) > 0 || o.writer != nil
+}
+
+// add keeps item in memory, or spools it once memory is full (or
+// earlier items are spooled, to preserve the order of items)
+func (o *SpoolOperator) add(item interface{}) {
+	if !o.spooled() && len(o.head) < o.memLimit {
+		o.head = append(o.head, item)
+		return
+	}
+	if o.memOnly {
+		o.hold(item)
+		return
+	}
+	if o.writer == nil {
+		file, err := ioutil.TempFile(o.dir, "automi-spool")
+		if err != nil {
+			o.fail(err)
+			o.hold(item)
+			return
+		}
+		util.Logfn(o.logf, fmt.Sprintf("Spool operator spooling items to %s", file.Name()))
+		o.writer = &spoolWriter{file: file, buffer: bufio.NewWriter(file)}
+		o.writer.enc = gob.NewEncoder(&o.writer.record)
+	}
+	data, pos := api.UnwrapPosition(item)
+	if err := o.writer.write(&spoolRecord{Item: data, Position: pos}); err != nil {
+		// the encoder may have sent partial type information, the
+		// next items are spooled to a new file
+		util.Logfn(o.logf, fmt.Sprintf("Spool operator keeping item in memory: %s", err))
+		o.hold(item)
+	}
+}
+
+// write encodes rec, the file only receives complete records
+func (w *spoolWriter) write(rec *spoolRecord) error {
+	w.record.Reset()
+	if err := w.enc.Encode(rec); err != nil {
+		return err
+	}
+	if _, err := w.record.WriteTo(w.buffer); err != nil {
+		return err
+	}
+	w.records++
+	return nil
+}
+
+// hold keeps item in memory after the items spooled so far
+func (o *SpoolOperator) hold(item interface{}) {
+	if o.writer != nil {
+		o.closeWriter()
+	}
+	if last := len(o.segments) - 1; last >= 0 && o.segments[last].name == "" {
+		o.segments[last].items = append(o.segments[last].items, item)
+		return
+	}
+	o.segments = append(o.segments, spoolSegment{items: []interface{}{item}})
+}
+
+// refill reads up to memLimit spooled items back in memory
+func (o *SpoolOperator) refill() {
+	for len(o.head) < o.memLimit {
+		if o.reader == nil {
+			// hand over the file being written when it is the last one
+			if len(o.segments) == 0 {
+				if o.writer == nil {
+					return
+				}
+				o.closeWriter()
+				continue
+			}
+			seg := o.segments[0]
+			if seg.name == "" {
+				o.head = append(o.head, seg.items...)
+				o.segments = o.segments[1:]
+				continue
+			}
+			file, err := os.Open(seg.name)
+			if err != nil {
+				o.fail(err)
+				o.removeSegment()
+				continue
+			}
+			o.reader = &spoolReader{file: file, dec: gob.NewDecoder(bufio.NewReader(file))}
+		}
+
+		var rec spoolRecord
+		err := o.reader.dec.Decode(&rec)
+		if err != nil {
+			if err != io.EOF {
+				o.fail(err)
+			}
+			o.removeSegment()
+			continue
+		}
+		o.head = append(o.head, api.WithPosition(rec.Item, rec.Position))
+	}
+}
+
+// closeWriter flushes and closes the file being written, it becomes
+// the last segment to read back
+func (o *SpoolOperator) closeWriter() {
+	w := o.writer
+	o.writer = nil
+	if err := w.buffer.Flush(); err != nil {
+		o.fail(err)
+		w.records = 0 // incomplete file
+	}
+	if err := w.file.Close(); err != nil && w.records > 0 {
+		o.fail(err)
+		w.records = 0
+	}
+	if w.records == 0 {
+		os.Remove(w.file.Name())
+		return
+	}
+	o.segments = append(o.segments, spoolSegment{name: w.file.Name()})
+}
+
+// removeSegment closes and removes the first segment
+func (o *SpoolOperator) removeSegment() {
+	if o.reader != nil {
+		o.reader.file.Close()
+		o.reader = nil
+	}
+	os.Remove(o.segments[0].name)
+	o.segments = o.segments[1:]
+}
+
+// cleanup closes and removes the spooled files
+func (o *SpoolOperator) cleanup() {
+	if o.writer != nil {
+		o.writer.file.Close()
+		os.Remove(o.writer.file.Name())
+		o.writer = nil
+	}
+	if o.reader != nil {
+		o.reader.file.Close()
+		o.reader = nil
+	}
+	for _, seg := range o.segments {
+		if seg.name != "" {
+			os.Remove(seg.name)
+		}
+	}
+	o.segments = nil
+}
+
+// fail reports a spooling error, the following items are kept in memory
+func (o *SpoolOperator) fail(err error) {
+	util.Logfn(o.logf, err)
+	autoctx.Err(o.errf, api.Error(fmt.Sprintf("spool: %s", err)))
+	if !o.memOnly {
+		o.memOnly = true
+		util.Logfn(o.logf, "Spool operator keeping items in memory")
+	}
+}
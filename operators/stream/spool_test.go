@@ -0,0 +1,231 @@
+package stream
+
+import (
+	"context"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+)
+
+func TestSpoolOp_Exec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	in := make(chan interface{})
+	sent := make(chan struct{})
+	go func() {
+		for i := 0; i < 500; i++ {
+			if i%3 == 0 {
+				in <- api.StreamItem{Item: i, Position: i}
+				continue
+			}
+			in <- i
+		}
+		close(sent)
+		close(in)
+	}()
+
+	o := NewSpool(4, dir)
+	o.SetInput(in)
+	if err := o.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the upstream is not blocked by the paused consumer
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("upstream blocked while consumer is paused")
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) == 0 {
+		t.Fatal("expecting items spooled to disk")
+	}
+
+	next := 0
+	for item := range o.GetOutput() {
+		data, pos := api.UnwrapPosition(item)
+		if data != next || (next%3 == 0 && pos != next) {
+			t.Fatalf("expecting item %d, got %v", next, item)
+		}
+		next++
+	}
+	if next != 500 {
+		t.Fatal("expecting 500 items, got", next)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Fatal("expecting spooled files removed, got", len(files))
+	}
+}
+
+func TestSpoolOp_Cancel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	in := make(chan interface{}, 10)
+	for i := 0; i < 10; i++ {
+		in <- i
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	o := NewSpool(2, dir)
+	o.SetInput(in)
+	if err := o.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	<-o.GetOutput()
+	cancel()
+
+	select {
+	case <-drainOutput(o.GetOutput()):
+	case <-time.After(time.Second):
+		t.Fatal("expecting output closed on cancel")
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Fatal("expecting spooled files removed on cancel, got", len(files))
+	}
+}
+
+// unspooled is not registered with gob and cannot be spooled
+type unspooled struct{ N int }
+
+// unencodable has no exported field, gob fails after sending type information
+type unencodable struct{ n int }
+
+func TestSpoolOp_Unencodable(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	gob.Register(unencodable{})
+
+	in := make(chan interface{})
+	sent := make(chan struct{})
+	go func() {
+		for i := 0; i < 50; i++ {
+			switch {
+			case i%7 == 0:
+				in <- unspooled{N: i}
+			case i%11 == 0:
+				in <- unencodable{n: i}
+			default:
+				in <- i
+			}
+		}
+		close(sent)
+		close(in)
+	}()
+
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		errs = append(errs, err)
+	})
+	o := NewSpool(2, dir)
+	o.SetInput(in)
+	if err := o.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("upstream blocked while consumer is paused")
+	}
+
+	next := 0
+	for item := range o.GetOutput() {
+		switch val := item.(type) {
+		case unspooled:
+			if val.N != next {
+				t.Fatalf("expecting item %d, got %v", next, item)
+			}
+		case unencodable:
+			if val.n != next {
+				t.Fatalf("expecting item %d, got %v", next, item)
+			}
+		default:
+			if val != next {
+				t.Fatalf("expecting item %d, got %v", next, item)
+			}
+		}
+		next++
+	}
+	if next != 50 {
+		t.Fatal("expecting 50 items, got", next)
+	}
+	if len(errs) != 0 {
+		t.Fatal("unexpected errors", errs)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Fatal("expecting spooled files removed, got", len(files))
+	}
+}
+
+func TestSpoolOp_SpoolError(t *testing.T) {
+	in := make(chan interface{})
+	sent := make(chan struct{})
+	go func() {
+		for i := 0; i < 20; i++ {
+			in <- i
+		}
+		close(sent)
+		close(in)
+	}()
+
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		errs = append(errs, err)
+	})
+	o := NewSpool(2, "/no/such/dir")
+	o.SetInput(in)
+	if err := o.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("upstream blocked after spooling failed")
+	}
+	next := 0
+	for item := range o.GetOutput() {
+		if item != next {
+			t.Fatalf("expecting item %d, got %v", next, item)
+		}
+		next++
+	}
+	if next != 20 {
+		t.Fatal("expecting 20 items, got", next)
+	}
+	if len(errs) != 1 {
+		t.Fatal("expecting spooling error reported once, got", errs)
+	}
+}
+
+func drainOutput(output <-chan interface{}) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for range output {
+		}
+		close(done)
+	}()
+	return done
+}
+
+func TestSpoolOp_MissingLimit(t *testing.T) {
+	o := NewSpool(0, "")
+	o.SetInput(make(chan interface{}))
+	if err := o.Exec(context.Background()); err == nil {
+		t.Fatal("expecting error without memory limit")
+	}
+}
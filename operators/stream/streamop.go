@@ -61,6 +61,17 @@ func (r *StreamOperator) Exec(ctx context.Context) (err error) {
 				if !opened {
 					return
 				}
+				// positioned items are unpacked and the last element
+				// carries the position of its parent item, so the parent
+				// is only checkpointed once all its elements are processed
+				item, pos := api.UnwrapPosition(item)
+				wrap := func(val interface{}, last bool) interface{} {
+					if !last {
+						return val
+					}
+					return api.WithPosition(val, pos)
+				}
+
 				itemType := reflect.TypeOf(item)
 				itemVal := reflect.ValueOf(item)
 
@@ -71,24 +82,25 @@ func (r *StreamOperator) Exec(ctx context.Context) (err error) {
 						j := itemVal.Index(i)
 
 						select {
-						case r.output <- j.Interface():
+						case r.output <- wrap(j.Interface(), i == itemVal.Len()-1):
 						case <-exeCtx.Done():
 							return
 						}
 					}
 				// unpack map as tuple.KV{key, value}
 				case reflect.Map:
-					for _, key := range itemVal.MapKeys() {
+					keys := itemVal.MapKeys()
+					for i, key := range keys {
 						val := itemVal.MapIndex(key)
 						select {
-						case r.output <- tuple.KV{key.Interface(), val.Interface()}:
+						case r.output <- wrap(tuple.KV{key.Interface(), val.Interface()}, i == len(keys)-1):
 						case <-exeCtx.Done():
 							return
 						}
 					}
 				default:
 					select {
-					case r.output <- item:
+					case r.output <- wrap(item, true):
 					case <-exeCtx.Done():
 						return
 					}
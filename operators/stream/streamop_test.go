@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/vladimirvivien/automi/api"
 	"github.com/vladimirvivien/automi/testutil"
 )
 
@@ -17,6 +18,38 @@ func TestStreamOp_New(t *testing.T) {
 	}
 }
 
+func TestUnbatchOp_Exec(t *testing.T) {
+	o := Unbatch()
+	in := make(chan interface{})
+	go func() {
+		in <- api.ItemBatch{"A", []string{"B", "C"}}
+		in <- "D"
+		close(in)
+	}()
+	o.SetInput(in)
+	if err := o.Exec(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	var items []interface{}
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for item := range o.GetOutput() {
+			items = append(items, item)
+		}
+	}()
+
+	select {
+	case <-wait:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long...")
+	}
+	if len(items) != 3 || items[0] != "A" || len(items[1].([]string)) != 2 || items[2] != "D" {
+		t.Fatal("unexpected items", items)
+	}
+}
+
 func TestStreamOp_Params(t *testing.T) {
 	o := New()
 	in := make(chan interface{})
@@ -125,3 +158,44 @@ func BenchmarkStreamOp_Exec(b *testing.B) {
 	}
 	m.RUnlock()
 }
+
+func TestStreamOp_Position(t *testing.T) {
+	o := New()
+	in := make(chan interface{})
+	go func() {
+		in <- api.StreamItem{Item: []string{"A", "B", "C"}, Position: 1}
+		in <- api.StreamItem{Item: map[string]int{"D": 1, "E": 2}, Position: 2}
+		in <- api.StreamItem{Item: "F", Position: 3}
+		close(in)
+	}()
+	o.SetInput(in)
+	if err := o.Exec(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	var positions []interface{}
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for item := range o.GetOutput() {
+			_, pos := api.UnwrapPosition(item)
+			positions = append(positions, pos)
+		}
+	}()
+
+	select {
+	case <-wait:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long...")
+	}
+	// only the last element of each parent carries its position
+	expected := []interface{}{nil, nil, 1, nil, 2, 3}
+	if len(positions) != len(expected) {
+		t.Fatal("unexpected positions", positions)
+	}
+	for i, pos := range positions {
+		if pos != expected[i] {
+			t.Fatalf("expecting positions %v, got %v", expected, positions)
+		}
+	}
+}
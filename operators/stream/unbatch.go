@@ -0,0 +1,80 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// UnbatchOperator is an operator that sends the items of each incoming
+// api.ItemBatch (sent by emitters emitting batches) individually
+// downstream.  Other items are passed downstream as is.  Unlike the
+// StreamOperator, items that are slices or maps are not unpacked.
+type UnbatchOperator struct {
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+// Unbatch creates a *UnbatchOperator value
+func Unbatch() *UnbatchOperator {
+	r := new(UnbatchOperator)
+	r.output = make(chan interface{}, 1024)
+	return r
+}
+
+// SetInput sets the input channel for the executor node
+func (r *UnbatchOperator) SetInput(in <-chan interface{}) {
+	r.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (r *UnbatchOperator) GetOutput() <-chan interface{} {
+	return r.output
+}
+
+// Exec is the execution starting point for the executor node.
+func (r *UnbatchOperator) Exec(ctx context.Context) (err error) {
+	r.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(r.logf, "Unbatch operator starting")
+
+	if r.input == nil {
+		err = fmt.Errorf("No input channel found")
+		return
+	}
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			util.Logfn(r.logf, "Unbatch operator closing")
+			cancel()
+			close(r.output)
+		}()
+
+		for {
+			select {
+			case item, opened := <-r.input:
+				if !opened {
+					return
+				}
+				batch, ok := item.(api.ItemBatch)
+				if !ok {
+					batch = api.ItemBatch{item}
+				}
+				for _, item := range batch {
+					select {
+					case r.output <- item:
+					case <-exeCtx.Done():
+						return
+					}
+				}
+			case <-exeCtx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
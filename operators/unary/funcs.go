@@ -4,8 +4,17 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/api/tuple"
+	"github.com/vladimirvivien/automi/codec"
+	"github.com/vladimirvivien/automi/group"
+	"github.com/vladimirvivien/automi/sketch"
+	"github.com/vladimirvivien/automi/util"
 )
 
 type unaryFuncForm byte
@@ -110,6 +119,426 @@ func FlatMapFunc(f interface{}) (api.UnFunc, error) {
 	}), nil
 }
 
+// MapIndexedFunc returns an unary function which applies f to incoming
+// items along with their zero-based index in the order they are received.
+// The result of f is handled as with MapFunc (i.e. nil drops the item).
+// The function must be applied by a single worker.
+func MapIndexedFunc(f func(i int64, item interface{}) interface{}) api.UnFunc {
+	var index int64
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		result := f(index, data)
+		index++
+		return result
+	})
+}
+
+// FlatMapCtxFunc returns an unary function which applies the context-aware
+// function f to incoming items and returns the []interface{} of outputs of
+// each item, to be flattened downstream.  Errors returned by f are returned
+// as is (routing them to the error path) and empty results are dropped.
+func FlatMapCtxFunc(f func(context.Context, interface{}) ([]interface{}, error)) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		result, err := f(ctx, data)
+		if err != nil {
+			return err
+		}
+		if len(result) == 0 {
+			return nil
+		}
+		return result
+	})
+}
+
+// CoerceFunc returns an unary function which converts incoming items to the
+// specified target type using the conversion rules of util.Coerce (numeric
+// conversions without loss, parsing of strings, etc).  Items that cannot be
+// converted are reported, with the item, to the error path and dropped.
+func CoerceFunc(target reflect.Type) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		result, err := util.Coerce(data, target)
+		if err != nil {
+			return rejectItem(ctx, err.Error(), data)
+		}
+		return result
+	})
+}
+
+// MapValuesToTypeFunc returns an unary function which converts the value
+// of each tuple.KV item to the target type (see CoerceFunc), the key is
+// left unchanged.  Values that cannot be converted, and items that are not
+// tuple.KV, are reported, with the item, to the error path and dropped.
+func MapValuesToTypeFunc(target reflect.Type) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		kv, ok := data.(tuple.KV)
+		if !ok {
+			return rejectItem(ctx, fmt.Sprintf("MapValuesToType expects tuple.KV items, got %T", data), data)
+		}
+		val, err := util.Coerce(kv[1], target)
+		if err != nil {
+			return rejectItem(ctx, fmt.Sprintf("key %v: %s", kv[0], err), data)
+		}
+		return tuple.KV{kv[0], val}
+	})
+}
+
+// CompressFunc returns an unary function which compresses each incoming
+// []byte item with codec c.  Other items, and payloads that fail to
+// compress, are reported, with the item, to the error path and dropped.
+func CompressFunc(c codec.Codec) api.UnFunc {
+	return codecFunc("Compress", c.Compress)
+}
+
+// DecompressFunc returns an unary function which decompresses each
+// incoming []byte item with codec c.  Other items, and payloads that fail
+// to decompress, are reported, with the item, to the error path and
+// dropped.
+func DecompressFunc(c codec.Codec) api.UnFunc {
+	return codecFunc("Decompress", c.Decompress)
+}
+
+func codecFunc(name string, f func([]byte) ([]byte, error)) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		payload, ok := data.([]byte)
+		if !ok {
+			return rejectItem(ctx, fmt.Sprintf("%s expects []byte items, got %T", name, data), data)
+		}
+		result, err := f(payload)
+		if err != nil {
+			return rejectItem(ctx, fmt.Sprintf("%s: %s", name, err), data)
+		}
+		return result
+	})
+}
+
+// LookupPolicy controls what LookupJoinFunc does with items whose key is
+// missing from the lookup table
+type LookupPolicy int
+
+const (
+	// LookupDrop drops the item (default)
+	LookupDrop LookupPolicy = iota
+	// LookupPass sends the item downstream unchanged
+	LookupPass
+	// LookupError reports the item on the error path and drops it
+	LookupError
+)
+
+// LookupJoinFunc returns an unary function which joins each incoming item
+// with the reference value table[keyFn(item)] and returns combine(item, ref).
+// Items whose key is missing from table are handled according to policy,
+// items with a non-comparable key are reported (with the item) to the error
+// path and dropped.
+// The table is read only, it must not be modified while the function is
+// in use.
+func LookupJoinFunc(
+	table map[interface{}]interface{},
+	keyFn func(interface{}) interface{},
+	combine func(item, ref interface{}) interface{},
+	policy LookupPolicy,
+) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		key := keyFn(data)
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			msg := fmt.Sprintf("lookup key of type %T is not comparable", key)
+			autoctx.Err(autoctx.GetErrFunc(ctx), api.ErrorWithItem(msg, &api.StreamItem{Item: data}))
+			return nil
+		}
+		ref, ok := table[key]
+		if ok {
+			return combine(data, ref)
+		}
+		switch policy {
+		case LookupPass:
+			return data
+		case LookupError:
+			msg := fmt.Sprintf("lookup key %v not found", key)
+			autoctx.Err(autoctx.GetErrFunc(ctx), api.ErrorWithItem(msg, &api.StreamItem{Item: data}))
+			return nil
+		default:
+			return nil
+		}
+	})
+}
+
+// NormalizedLookupJoinFunc is LookupJoinFunc with the keys of table and
+// the keys returned by keyFn canonicalized with normalize (group.NormalizeKey
+// if nil) before matching, i.e. so that an int64 key matches a float64 key
+// decoded from JSON.  The table is normalized once, when the function is
+// created; table keys normalizing to the same key keep one of the values.
+func NormalizedLookupJoinFunc(
+	table map[interface{}]interface{},
+	keyFn func(interface{}) interface{},
+	combine func(item, ref interface{}) interface{},
+	policy LookupPolicy,
+	normalize func(interface{}) interface{},
+) api.UnFunc {
+	if normalize == nil {
+		normalize = group.NormalizeKey
+	}
+	normalized := make(map[interface{}]interface{}, len(table))
+	for key, ref := range table {
+		key = normalize(key)
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			continue // cannot be matched
+		}
+		normalized[key] = ref
+	}
+	return LookupJoinFunc(normalized, group.NormalizedKey(keyFn, normalize), combine, policy)
+}
+
+// AssertFunc returns an unary function which passes incoming items through
+// unchanged while checking them with the predicate pred.  When an item
+// violates the predicate, an api.StreamError (with msg and the item) is
+// reported to the error path, or, if mustPanic is true, an
+// api.PanicStreamError is returned which causes the stream to panic.
+func AssertFunc(pred func(interface{}) bool, msg string, mustPanic bool) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		if pred(data) {
+			return data
+		}
+		errMsg := fmt.Sprintf("assertion failed: %s: %v", msg, data)
+		if mustPanic {
+			return api.PanickingError(errMsg)
+		}
+		autoctx.Err(autoctx.GetErrFunc(ctx), api.ErrorWithItem(errMsg, &api.StreamItem{Item: data}))
+		return data
+	})
+}
+
+// SpreadFunc returns an unary function which spreads incoming maps into a
+// []tuple.KV{key, value}, one per entry, and incoming slices or arrays into
+// a []tuple.KV{index, element}.  Map entries are unordered unless sorted is
+// true, in which case they are sorted by key.  Other items are reported to
+// the error path and dropped.
+func SpreadFunc(sorted bool) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		val := reflect.ValueOf(data)
+		var result []tuple.KV
+		switch val.Kind() {
+		case reflect.Map:
+			keys := val.MapKeys()
+			if sorted {
+				sort.Slice(keys, func(i, j int) bool {
+					if less := util.IsLess(keys[i], keys[j]); less || util.IsLess(keys[j], keys[i]) {
+						return less
+					}
+					return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+				})
+			}
+			for _, key := range keys {
+				result = append(result, tuple.KV{key.Interface(), val.MapIndex(key).Interface()})
+			}
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < val.Len(); i++ {
+				result = append(result, tuple.KV{i, val.Index(i).Interface()})
+			}
+		default:
+			return rejectItem(ctx, fmt.Sprintf("spread: expecting map, slice, or array, got %T", data), data)
+		}
+		return result
+	})
+}
+
+// RenameFieldsFunc returns an unary function which renames the keys of
+// incoming maps (with string keys) using mapping, from old to new key.  Keys
+// not in mapping are kept.  Maps are copied, not modified in place.  Other
+// items (or maps where a key is renamed onto a key already present) are
+// passed through when passThrough is true, otherwise they are reported to
+// the error path and dropped.
+func RenameFieldsFunc(mapping map[string]string, passThrough bool) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		val := reflect.ValueOf(data)
+		if !isStringMap(val) {
+			return nonMapItem(ctx, "rename fields", data, passThrough)
+		}
+		keyType := val.Type().Key()
+		result := reflect.MakeMapWithSize(val.Type(), val.Len())
+		var renamed []reflect.Value
+		for _, key := range val.MapKeys() {
+			if _, ok := mapping[key.String()]; ok {
+				renamed = append(renamed, key)
+				continue
+			}
+			result.SetMapIndex(key, val.MapIndex(key))
+		}
+		for _, key := range renamed {
+			newKey := reflect.ValueOf(mapping[key.String()]).Convert(keyType)
+			if result.MapIndex(newKey).IsValid() {
+				if passThrough {
+					return data
+				}
+				return rejectItem(ctx, fmt.Sprintf("rename fields: key %q renamed to existing key %q", key.String(), newKey.String()), data)
+			}
+			result.SetMapIndex(newKey, val.MapIndex(key))
+		}
+		return result.Interface()
+	})
+}
+
+// SetFieldFunc returns an unary function which sets the field name of
+// incoming maps (with string keys) to the value computed by fn from the
+// map, adding or overwriting the field.  Maps are copied, not modified in
+// place.  Other items (or values not assignable to the map values) are
+// passed through when passThrough is true, otherwise they are reported to
+// the error path and dropped.
+func SetFieldFunc(name string, fn func(interface{}) interface{}, passThrough bool) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		val := reflect.ValueOf(data)
+		if !isStringMap(val) {
+			return nonMapItem(ctx, "set field", data, passThrough)
+		}
+		fieldVal := reflect.ValueOf(fn(data))
+		elemType := val.Type().Elem()
+		if !fieldVal.IsValid() {
+			fieldVal = reflect.Zero(elemType)
+		}
+		if !fieldVal.Type().AssignableTo(elemType) {
+			if passThrough {
+				return data
+			}
+			return rejectItem(ctx, fmt.Sprintf("set field %q: value of type %s not assignable to %s", name, fieldVal.Type(), elemType), data)
+		}
+		result := reflect.MakeMapWithSize(val.Type(), val.Len()+1)
+		for _, key := range val.MapKeys() {
+			result.SetMapIndex(key, val.MapIndex(key))
+		}
+		result.SetMapIndex(reflect.ValueOf(name).Convert(val.Type().Key()), fieldVal)
+		return result.Interface()
+	})
+}
+
+// isStringMap returns true if val is a map with string keys
+func isStringMap(val reflect.Value) bool {
+	return val.Kind() == reflect.Map && val.Type().Key().Kind() == reflect.String
+}
+
+// nonMapItem passes data through or reports it to the error path
+func nonMapItem(ctx context.Context, op string, data interface{}, passThrough bool) interface{} {
+	if passThrough {
+		return data
+	}
+	return rejectItem(ctx, fmt.Sprintf("%s: expecting map with string keys, got %T", op, data), data)
+}
+
+// BloomDedupFunc returns an unary function which drops incoming items whose
+// key, as returned by keyFn (or the item itself if keyFn is nil), was
+// probably seen before according to a sketch.BloomFilter sized for
+// expectedN keys with the false positive rate fpRate.  All duplicates are
+// dropped, however a fraction (about fpRate) of unique items is also dropped.
+// Items with keys that are not hashable are reported to the error path and
+// dropped.
+func BloomDedupFunc(keyFn func(interface{}) interface{}, expectedN int, fpRate float64) api.UnFunc {
+	var mu sync.Mutex
+	filter := sketch.NewBloomFilter(expectedN, fpRate)
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		key := data
+		if keyFn != nil {
+			key = keyFn(data)
+		}
+		if !sketch.Hashable(key) {
+			return rejectItem(ctx, fmt.Sprintf("bloom dedup: key of type %T is not hashable", key), data)
+		}
+		mu.Lock()
+		seen := filter.Add(key)
+		mu.Unlock()
+		if seen {
+			return nil
+		}
+		return data
+	})
+}
+
+// MaxFlattenDepth bounds the nesting depth flattened by FlattenMapFunc,
+// deeper values are kept as is.
+const MaxFlattenDepth = 32
+
+// FlattenMapFunc returns an unary function which flattens incoming nested
+// maps, with string keys, into a single-level map[string]interface{} whose
+// keys are the path of each value joined with sep (i.e. "a.b.c").  Slice and
+// array elements are keyed by their index (i.e. "a.0", "a.1").  Values
+// referencing a map or slice being flattened (a cycle) are skipped and
+// reported, with the item, to the error path.  Items that are not maps with
+// string keys are reported to the error path and dropped.
+func FlattenMapFunc(sep string) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		val := reflect.ValueOf(data)
+		if val.Kind() != reflect.Map || val.Type().Key().Kind() != reflect.String {
+			return rejectItem(ctx, fmt.Sprintf("flatten: expecting map with string keys, got %T", data), data)
+		}
+		f := &flattener{sep: sep, result: make(map[string]interface{}), path: make(map[uintptr]bool)}
+		f.flatten("", val, 0)
+		if len(f.cycles) > 0 {
+			msg := fmt.Sprintf("flatten: skipped cyclic values at %s", strings.Join(f.cycles, ", "))
+			autoctx.Err(autoctx.GetErrFunc(ctx), api.ErrorWithItem(msg, &api.StreamItem{Item: data}))
+		}
+		return f.result
+	})
+}
+
+// flattener flattens a nested value into result
+type flattener struct {
+	sep    string
+	result map[string]interface{}
+	path   map[uintptr]bool // maps and slices being flattened
+	cycles []string         // keys of the cyclic values skipped
+}
+
+func (f *flattener) flatten(prefix string, val reflect.Value, depth int) {
+	for val.Kind() == reflect.Interface && !val.IsNil() {
+		val = val.Elem()
+	}
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + f.sep + key
+	}
+
+	isMap := val.Kind() == reflect.Map && val.Type().Key().Kind() == reflect.String
+	isSlice := (val.Kind() == reflect.Slice && val.Type().Elem().Kind() != reflect.Uint8) || val.Kind() == reflect.Array
+	if depth <= MaxFlattenDepth && (isMap || isSlice) && (val.Len() > 0 || prefix == "") {
+		// track the maps and slices on the path to detect cycles
+		var addr uintptr
+		if val.Kind() != reflect.Array {
+			addr = val.Pointer()
+		}
+		if addr != 0 {
+			if f.path[addr] {
+				f.cycles = append(f.cycles, prefix)
+				return
+			}
+			f.path[addr] = true
+			defer delete(f.path, addr)
+		}
+		if isMap {
+			for _, key := range val.MapKeys() {
+				f.flatten(join(key.String()), val.MapIndex(key), depth+1)
+			}
+		} else {
+			for i := 0; i < val.Len(); i++ {
+				f.flatten(join(fmt.Sprintf("%d", i)), val.Index(i), depth+1)
+			}
+		}
+		return
+	}
+
+	if prefix == "" {
+		return
+	}
+	if val.IsValid() {
+		f.result[prefix] = val.Interface()
+	} else {
+		f.result[prefix] = nil
+	}
+}
+
+// rejectItem reports data, with msg, to the error path and drops it
+// (an api.StreamError carrying an item would send the item downstream)
+func rejectItem(ctx context.Context, msg string, data interface{}) interface{} {
+	autoctx.Err(autoctx.GetErrFunc(ctx), api.ErrorWithItem(msg, &api.StreamItem{Item: data}))
+	return nil
+}
+
 // isUnaryFuncForm ensures ftype is of supported function of
 // form func(in) out or func(context, in) out
 func isUnaryFuncForm(ftype reflect.Type) (unaryFuncForm, error) {
@@ -2,11 +2,15 @@ package unary
 
 import (
 	"context"
+	"math"
 	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/api/tuple"
+	"github.com/vladimirvivien/automi/codec"
 )
 
 type unaryFuncTestCase struct {
@@ -275,3 +279,371 @@ func TestUnaryFunc_FlatMap(t *testing.T) {
 		})
 	}
 }
+
+func TestUnaryFunc_Coerce(t *testing.T) {
+	f := CoerceFunc(reflect.TypeOf(0))
+	tests := []struct {
+		data     interface{}
+		expected interface{}
+		fails    bool
+	}{
+		{data: 12.0, expected: 12},
+		{data: int64(7), expected: 7},
+		{data: "42", expected: 42},
+		{data: 1.5, fails: true},
+		{data: "abc", fails: true},
+		{data: []int{1}, fails: true},
+		{data: uint64(math.MaxUint64), fails: true},
+		{data: 1e20, fails: true},
+	}
+	for _, test := range tests {
+		ctx, errs := errorsContext()
+		result := f(ctx, test.data)
+		if failed := len(*errs) == 1 && reflect.DeepEqual((*errs)[0].Item().Item, test.data); failed != test.fails {
+			t.Fatalf("coercing %v: unexpected errors %v", test.data, *errs)
+		}
+		if result != test.expected {
+			t.Fatalf("coercing %v: expecting %v, got %v", test.data, test.expected, result)
+		}
+	}
+}
+
+func TestUnaryFunc_Coerce_Range(t *testing.T) {
+	tests := []struct {
+		data     interface{}
+		target   reflect.Type
+		expected interface{}
+		fails    bool
+	}{
+		{data: -1, target: reflect.TypeOf(uint(0)), fails: true},
+		{data: -1.0, target: reflect.TypeOf(uint(0)), fails: true},
+		{data: 7, target: reflect.TypeOf(uint(0)), expected: uint(7)},
+		{data: uint64(math.MaxInt64) + 1, target: reflect.TypeOf(int64(0)), fails: true},
+		{data: uint64(math.MaxInt64), target: reflect.TypeOf(int64(0)), expected: int64(math.MaxInt64)},
+		{data: 300, target: reflect.TypeOf(int8(0)), fails: true},
+		{data: -128, target: reflect.TypeOf(int8(0)), expected: int8(-128)},
+		{data: 256, target: reflect.TypeOf(uint8(0)), fails: true},
+		{data: 1e40, target: reflect.TypeOf(float32(0)), fails: true},
+		{data: 0.5, target: reflect.TypeOf(float32(0)), expected: float32(0.5)},
+		{data: int64(1<<53 + 1), target: reflect.TypeOf(0.0), fails: true},
+		{data: int64(math.MaxInt64), target: reflect.TypeOf(0.0), fails: true},
+	}
+	for _, test := range tests {
+		ctx, errs := errorsContext()
+		result := CoerceFunc(test.target)(ctx, test.data)
+		if failed := len(*errs) == 1; failed != test.fails {
+			t.Fatalf("coercing %v to %s: unexpected errors %v", test.data, test.target, *errs)
+		}
+		if result != test.expected {
+			t.Fatalf("coercing %v to %s: expecting %v, got %v", test.data, test.target, test.expected, result)
+		}
+	}
+}
+
+func TestUnaryFunc_MapValuesToType(t *testing.T) {
+	f := MapValuesToTypeFunc(reflect.TypeOf(0))
+	tests := []struct {
+		data     interface{}
+		expected interface{}
+		fails    bool
+	}{
+		{data: tuple.KV{"a", 12.0}, expected: tuple.KV{"a", 12}},
+		{data: tuple.KV{1, 7.0}, expected: tuple.KV{1, 7}},
+		{data: tuple.KV{"b", "abc"}, fails: true},
+		{data: 12.0, fails: true},
+	}
+	for _, test := range tests {
+		ctx, errs := errorsContext()
+		result := f(ctx, test.data)
+		if failed := len(*errs) == 1 && (*errs)[0].Item().Item == test.data; failed != test.fails {
+			t.Fatalf("coercing %v: unexpected errors %v", test.data, *errs)
+		}
+		if result != test.expected {
+			t.Fatalf("coercing %v: expecting %v, got %v", test.data, test.expected, result)
+		}
+	}
+}
+
+func TestUnaryFunc_CompressDecompress(t *testing.T) {
+	c, err := codec.Get(codec.Gzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compress, decompress := CompressFunc(c), DecompressFunc(c)
+	compressed := compress(context.Background(), []byte("hello hello hello"))
+	if _, ok := compressed.([]byte); !ok {
+		t.Fatal("unexpected compressed result", compressed)
+	}
+	if result := decompress(context.Background(), compressed); string(result.([]byte)) != "hello hello hello" {
+		t.Fatal("unexpected decompressed result", result)
+	}
+	ctx, errs := errorsContext()
+	if result := compress(ctx, "hello"); result != nil {
+		t.Fatal("expecting non-byte item to be dropped, got", result)
+	}
+	if result := decompress(ctx, []byte("hello")); result != nil {
+		t.Fatal("expecting invalid payload to be dropped, got", result)
+	}
+	if len(*errs) != 2 || (*errs)[0].Item().Item != "hello" || string((*errs)[1].Item().Item.([]byte)) != "hello" {
+		t.Fatal("expecting rejected items on the error path, got", *errs)
+	}
+}
+
+func TestUnaryFunc_LookupJoin(t *testing.T) {
+	table := map[interface{}]interface{}{"a": 1, "b": 2}
+	key := func(item interface{}) interface{} { return item }
+	combine := func(item, ref interface{}) interface{} { return tuple.KV{item, ref} }
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		errs = append(errs, err)
+	})
+	tests := []struct {
+		policy   LookupPolicy
+		data     interface{}
+		expected interface{}
+		fails    bool
+	}{
+		{policy: LookupDrop, data: "a", expected: tuple.KV{"a", 1}},
+		{policy: LookupDrop, data: "c", expected: nil},
+		{policy: LookupPass, data: "c", expected: "c"},
+		{policy: LookupError, data: "b", expected: tuple.KV{"b", 2}},
+		{policy: LookupError, data: "c", fails: true},
+		{policy: LookupDrop, data: []int{1}, fails: true},
+	}
+	for _, test := range tests {
+		errs = nil
+		result := LookupJoinFunc(table, key, combine, test.policy)(ctx, test.data)
+		if failed := len(errs) == 1 && errs[0].Item().Item != nil; failed != test.fails {
+			t.Fatalf("looking up %v: unexpected errors %v", test.data, errs)
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Fatalf("looking up %v: expecting %v, got %v", test.data, test.expected, result)
+		}
+	}
+}
+
+func TestUnaryFunc_NormalizedLookupJoin(t *testing.T) {
+	table := map[interface{}]interface{}{float64(7): "seven", "A": "upper"}
+	key := func(item interface{}) interface{} { return item }
+	combine := func(item, ref interface{}) interface{} { return ref }
+
+	f := NormalizedLookupJoinFunc(table, key, combine, LookupDrop, nil)
+	if result := f(context.Background(), int64(7)); result != "seven" {
+		t.Fatal("expecting int64 key to match float64 table key, got", result)
+	}
+	if result := f(context.Background(), int64(8)); result != nil {
+		t.Fatal("expecting missing key dropped, got", result)
+	}
+
+	lower := func(key interface{}) interface{} {
+		if str, ok := key.(string); ok {
+			return strings.ToLower(str)
+		}
+		return key
+	}
+	f = NormalizedLookupJoinFunc(table, key, combine, LookupDrop, lower)
+	if result := f(context.Background(), "a"); result != "upper" {
+		t.Fatal("expecting custom normalizer applied to both keys, got", result)
+	}
+}
+
+func TestUnaryFunc_Assert(t *testing.T) {
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		errs = append(errs, err)
+	})
+	positive := func(item interface{}) bool { return item.(int) > 0 }
+
+	f := AssertFunc(positive, "must be positive", false)
+	if result := f(ctx, 1); result != 1 || len(errs) != 0 {
+		t.Fatal("unexpected result for valid item", result, errs)
+	}
+	if result := f(ctx, -1); result != -1 || len(errs) != 1 {
+		t.Fatal("unexpected result for violating item", result, errs)
+	}
+	if errs[0].Item().Item != -1 || !strings.Contains(errs[0].Error(), "must be positive") {
+		t.Fatal("unexpected assertion error", errs[0])
+	}
+
+	mustF := AssertFunc(positive, "must be positive", true)
+	if _, ok := mustF(ctx, -1).(api.PanicStreamError); !ok {
+		t.Fatal("expecting PanicStreamError")
+	}
+}
+
+func TestUnaryFunc_FlattenMap(t *testing.T) {
+	f := FlattenMapFunc(".")
+	data := map[string]interface{}{
+		"a": map[string]interface{}{
+			"b": map[string]interface{}{"c": 1},
+			"d": []interface{}{"x", map[string]interface{}{"e": true}},
+		},
+		"f":     "g",
+		"empty": map[string]interface{}{},
+	}
+	result := f(context.Background(), data).(map[string]interface{})
+	expected := map[string]interface{}{
+		"a.b.c":   1,
+		"a.d.0":   "x",
+		"a.d.1.e": true,
+		"f":       "g",
+	}
+	if len(result) != len(expected)+1 {
+		t.Fatal("unexpected flattened keys", result)
+	}
+	for k, v := range expected {
+		if result[k] != v {
+			t.Fatalf("key %s: expecting %v, got %v", k, v, result[k])
+		}
+	}
+
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		errs = append(errs, err)
+	})
+
+	// cyclic values are skipped and reported
+	cyclic := map[string]interface{}{"a": 1}
+	cyclic["self"] = cyclic
+	cyclic["again"] = cyclic
+	cyclic["list"] = []interface{}{2, cyclic}
+	result, ok := f(ctx, cyclic).(map[string]interface{})
+	if !ok || len(result) != 2 || result["a"] != 1 || result["list.0"] != 2 {
+		t.Fatal("expecting cyclic values skipped, got", result)
+	}
+	if len(errs) != 1 || errs[0].Item().Item == nil || !strings.Contains(errs[0].Error(), "self") {
+		t.Fatal("expecting one error reporting the cycles, got", errs)
+	}
+
+	// shared values are not cycles
+	errs = nil
+	shared := map[string]interface{}{"x": 1}
+	result = f(ctx, map[string]interface{}{"a": shared, "b": shared}).(map[string]interface{})
+	if len(result) != 2 || result["a.x"] != 1 || result["b.x"] != 1 || len(errs) != 0 {
+		t.Fatal("expecting shared values flattened, got", result, errs)
+	}
+
+	if result := f(ctx, "not a map"); result != nil || len(errs) != 1 {
+		t.Fatal("expecting non-map item reported and dropped, got", result, errs)
+	}
+}
+
+func TestUnaryFunc_Spread(t *testing.T) {
+	f := SpreadFunc(true)
+	result := f(context.Background(), map[string]int{"c": 3, "a": 1, "b": 2}).([]tuple.KV)
+	if len(result) != 3 || result[0][0] != "a" || result[2][1] != 3 {
+		t.Fatal("unexpected sorted spread", result)
+	}
+	result = f(context.Background(), []string{"x", "y"}).([]tuple.KV)
+	if len(result) != 2 || result[1][0] != 1 || result[1][1] != "y" {
+		t.Fatal("unexpected indexed spread", result)
+	}
+	ctx, errs := errorsContext()
+	if result := f(ctx, 42); result != nil || len(*errs) != 1 || (*errs)[0].Item().Item != 42 {
+		t.Fatal("expecting scalar item reported and dropped, got", result, *errs)
+	}
+}
+
+// errorsContext returns a context collecting the errors reported
+func errorsContext() (context.Context, *[]api.StreamError) {
+	errs := new([]api.StreamError)
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		*errs = append(*errs, err)
+	})
+	return ctx, errs
+}
+
+func TestUnaryFunc_RenameFields(t *testing.T) {
+	f := RenameFieldsFunc(map[string]string{"fname": "first_name"}, false)
+	item := map[string]interface{}{"fname": "ann", "age": 30}
+	result := f(context.Background(), item).(map[string]interface{})
+	if result["first_name"] != "ann" || result["age"] != 30 || len(result) != 2 {
+		t.Fatal("unexpected renamed map", result)
+	}
+	if _, ok := item["first_name"]; ok {
+		t.Fatal("source map should not be modified")
+	}
+	ctx, errs := errorsContext()
+	if result := f(ctx, 42); result != nil {
+		t.Fatal("expecting non-map item to be dropped, got", result)
+	}
+	if len(*errs) != 1 || (*errs)[0].Item().Item != 42 {
+		t.Fatal("expecting non-map item on the error path, got", *errs)
+	}
+	if RenameFieldsFunc(nil, true)(context.Background(), 42) != 42 {
+		t.Fatal("expecting non-map item passed through")
+	}
+}
+
+func TestUnaryFunc_RenameFields_Collision(t *testing.T) {
+	f := RenameFieldsFunc(map[string]string{"a": "b", "b": "c"}, false)
+	result := f(context.Background(), map[string]int{"a": 1, "b": 2}).(map[string]int)
+	if result["b"] != 1 || result["c"] != 2 || len(result) != 2 {
+		t.Fatal("unexpected renamed map", result)
+	}
+
+	f = RenameFieldsFunc(map[string]string{"a": "b"}, false)
+	ctx, errs := errorsContext()
+	item := map[string]int{"a": 1, "b": 2}
+	for i := 0; i < 10; i++ {
+		if result := f(ctx, item); result != nil {
+			t.Fatal("expecting colliding item to be dropped, got", result)
+		}
+	}
+	if len(*errs) != 10 {
+		t.Fatal("expecting colliding items on the error path, got", *errs)
+	}
+	passed := RenameFieldsFunc(map[string]string{"a": "b"}, true)(context.Background(), item).(map[string]int)
+	if passed["a"] != 1 || passed["b"] != 2 {
+		t.Fatal("expecting colliding item passed through, got", passed)
+	}
+}
+
+func TestUnaryFunc_SetField(t *testing.T) {
+	f := SetFieldFunc("total", func(item interface{}) interface{} {
+		m := item.(map[string]float64)
+		return m["price"] * m["qty"]
+	}, false)
+	result := f(context.Background(), map[string]float64{"price": 2.5, "qty": 4}).(map[string]float64)
+	if result["total"] != 10 {
+		t.Fatal("unexpected derived field", result)
+	}
+
+	bad := SetFieldFunc("total", func(interface{}) interface{} { return "x" }, false)
+	ctx, errs := errorsContext()
+	if result := bad(ctx, map[string]float64{}); result != nil {
+		t.Fatal("expecting item with unassignable value to be dropped, got", result)
+	}
+	if result := bad(ctx, "x"); result != nil {
+		t.Fatal("expecting non-map item to be dropped, got", result)
+	}
+	if len(*errs) != 2 {
+		t.Fatal("expecting 2 items on the error path, got", *errs)
+	}
+}
+
+func TestUnaryFunc_BloomDedup_NotHashable(t *testing.T) {
+	f := BloomDedupFunc(nil, 100, 0.01)
+	ctx, errs := errorsContext()
+	if result := f(ctx, []int{1}); result != nil {
+		t.Fatal("expecting item with non-hashable key to be dropped, got", result)
+	}
+	if len(*errs) != 1 {
+		t.Fatal("expecting item on the error path, got", *errs)
+	}
+	if f(ctx, 1) != 1 || f(ctx, 1) != nil {
+		t.Fatal("expecting duplicate to be dropped")
+	}
+}
+
+func TestUnaryFunc_MapIndexed(t *testing.T) {
+	op := MapIndexedFunc(func(i int64, item interface{}) interface{} {
+		return tuple.KV{i, item}
+	})
+	for i, item := range []string{"a", "b", "c"} {
+		kv := op.Apply(context.TODO(), item).(tuple.KV)
+		if kv[0] != int64(i) || kv[1] != item {
+			t.Fatal("unexpected indexed result", kv)
+		}
+	}
+}
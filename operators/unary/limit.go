@@ -0,0 +1,107 @@
+package unary
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// LimitBytesOperator is an executor node that passes items downstream until
+// their cumulative size, as returned by a size function, reaches a byte
+// budget.  The operator then closes its output, stops the source of the
+// stream (see context.StopSource) and discards the items still sent from
+// upstream.  The item that would exceed the budget is passed downstream
+// only if the operator is inclusive.
+type LimitBytesOperator struct {
+	max       int64
+	sizeFn    func(interface{}) int64
+	inclusive bool
+	input     <-chan interface{}
+	output    chan interface{}
+	logf      api.LogFunc
+}
+
+// NewLimitBytes creates a *LimitBytesOperator with a budget of max bytes
+func NewLimitBytes(max int64, sizeFn func(interface{}) int64, inclusive bool) *LimitBytesOperator {
+	return &LimitBytesOperator{
+		max:       max,
+		sizeFn:    sizeFn,
+		inclusive: inclusive,
+		output:    make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (o *LimitBytesOperator) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+// GetOutput returns the output channel for the executor node
+func (o *LimitBytesOperator) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+// Exec is the execution starting point for the operator node.
+func (o *LimitBytesOperator) Exec(ctx context.Context) error {
+	o.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(o.logf, "LimitBytes operator starting")
+
+	if o.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+	if o.sizeFn == nil {
+		return fmt.Errorf("LimitBytes size function not provided")
+	}
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			util.Logfn(o.logf, "LimitBytes operator done")
+			cancel()
+		}()
+		o.doOp(exeCtx)
+	}()
+	return nil
+}
+
+func (o *LimitBytesOperator) doOp(ctx context.Context) {
+	var total int64
+	closed := false
+	defer func() {
+		if !closed {
+			close(o.output)
+		}
+	}()
+
+	for {
+		select {
+		case item, opened := <-o.input:
+			if !opened {
+				return
+			}
+			if closed {
+				continue // budget reached, drain upstream
+			}
+			data, _ := api.UnwrapPosition(item)
+			total += o.sizeFn(data)
+			if total <= o.max || o.inclusive {
+				select {
+				case o.output <- item:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if total >= o.max {
+				util.Logfn(o.logf, fmt.Sprintf("LimitBytes operator reached %d bytes", o.max))
+				close(o.output)
+				closed = true
+				autoctx.StopSource(ctx)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package unary
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	autoctx "github.com/vladimirvivien/automi/api/context"
+)
+
+func TestLimitBytesOp_Exec(t *testing.T) {
+	sizeFn := func(item interface{}) int64 { return int64(len(item.(string))) }
+	tests := []struct {
+		name      string
+		max       int64
+		inclusive bool
+		expected  []string
+	}{
+		{name: "exclusive", max: 10, expected: []string{"aaaa", "bbbb"}},
+		{name: "inclusive", max: 10, inclusive: true, expected: []string{"aaaa", "bbbb", "cccc"}},
+		{name: "exact boundary", max: 8, expected: []string{"aaaa", "bbbb"}},
+	}
+
+	for _, test := range tests {
+		in := make(chan interface{})
+		go func() {
+			for _, item := range []string{"aaaa", "bbbb", "cccc", "dddd"} {
+				in <- item
+			}
+			close(in)
+		}()
+
+		op := NewLimitBytes(test.max, sizeFn, test.inclusive)
+		op.SetInput(in)
+		if err := op.Exec(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		var result []interface{}
+		for item := range op.GetOutput() {
+			result = append(result, item)
+		}
+		if len(result) != len(test.expected) {
+			t.Fatalf("%s: unexpected items %v", test.name, result)
+		}
+		for i := range result {
+			if result[i] != test.expected[i] {
+				t.Fatalf("%s: unexpected items %v", test.name, result)
+			}
+		}
+	}
+}
+
+func TestLimitBytesOp_StopSource(t *testing.T) {
+	in := make(chan interface{})
+	stopped := make(chan struct{})
+	go func() { // sends until stopped, then closes its output
+		defer close(in)
+		for {
+			select {
+			case in <- "aaaa":
+			case <-stopped:
+				return
+			}
+		}
+	}()
+
+	ctx := autoctx.WithSourceStop(context.Background(), func() { close(stopped) })
+	op := NewLimitBytes(10, func(item interface{}) int64 { return int64(len(item.(string))) }, false)
+	op.SetInput(in)
+	if err := op.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+	var result []interface{}
+	for item := range op.GetOutput() {
+		result = append(result, item)
+	}
+	if len(result) != 2 {
+		t.Fatal("unexpected items", result)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("expecting the source to be stopped")
+	}
+}
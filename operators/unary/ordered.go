@@ -0,0 +1,192 @@
+package unary
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// OrderedOperator is an executor node that applies a unary operation using
+// several concurrent workers while emitting results in the order of their
+// incoming items.  At most maxPending items can be dispatched and waiting
+// for in-order emission, which bounds memory when a few items are slow.
+type OrderedOperator struct {
+	op         api.UnOperation
+	workers    int
+	maxPending int
+	pending    chan struct{}
+	input      <-chan interface{}
+	output     chan interface{}
+	logf       api.LogFunc
+	errf       api.ErrorFunc
+}
+
+// NewOrdered creates a *OrderedOperator value
+func NewOrdered(workers, maxPending int) *OrderedOperator {
+	o := new(OrderedOperator)
+	o.workers = workers
+	if o.workers < 1 {
+		o.workers = 1
+	}
+	o.maxPending = maxPending
+	if o.maxPending < o.workers {
+		o.maxPending = o.workers
+	}
+	o.pending = make(chan struct{}, o.maxPending)
+	o.output = make(chan interface{}, 1024)
+	return o
+}
+
+// SetOperation sets the executor operation
+func (o *OrderedOperator) SetOperation(op api.UnOperation) {
+	o.op = op
+}
+
+// SetInput sets the input channel for the executor node
+func (o *OrderedOperator) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+// GetOutput returns the output channel for the executor node
+func (o *OrderedOperator) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+// Pending returns the number of items dispatched but not yet emitted
+func (o *OrderedOperator) Pending() int {
+	return len(o.pending)
+}
+
+type orderedJob struct {
+	item   interface{}
+	result chan interface{}
+}
+
+// Exec is the entry point for the executor
+func (o *OrderedOperator) Exec(ctx context.Context) (err error) {
+	o.logf = autoctx.GetLogFunc(ctx)
+	o.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(o.logf, "Ordered unary operator started")
+
+	if o.input == nil {
+		err = fmt.Errorf("No input channel found")
+		return
+	}
+	if o.op == nil {
+		err = fmt.Errorf("Ordered unary operator missing operation")
+		return
+	}
+
+	exeCtx, cancel := context.WithCancel(ctx)
+	jobs := make(chan orderedJob, o.maxPending)
+	queue := make(chan chan interface{}, o.maxPending)
+
+	// workers
+	var wg sync.WaitGroup
+	wg.Add(o.workers)
+	for i := 0; i < o.workers; i++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				item, pos := api.UnwrapPosition(job.item)
+				job.result <- api.WithPosition(o.op.Apply(exeCtx, item), pos)
+			}
+		}()
+	}
+
+	// dispatcher, blocks when maxPending results are awaiting emission
+	go func() {
+		defer func() {
+			close(jobs)
+			close(queue)
+			wg.Wait()
+		}()
+		for {
+			select {
+			case item, opened := <-o.input:
+				if !opened {
+					return
+				}
+				select {
+				case o.pending <- struct{}{}:
+				case <-exeCtx.Done():
+					return
+				}
+				job := orderedJob{item: item, result: make(chan interface{}, 1)}
+				queue <- job.result
+				jobs <- job
+			case <-exeCtx.Done():
+				return
+			}
+		}
+	}()
+
+	// in-order emitter
+	go func() {
+		defer func() {
+			util.Logfn(o.logf, "Ordered unary operator done")
+			cancel()
+			// drain pending results so workers can exit
+			for range queue {
+			}
+			close(o.output)
+		}()
+		for result := range queue {
+			var val interface{}
+			select {
+			case val = <-result:
+			case <-exeCtx.Done():
+				return
+			}
+			<-o.pending
+			if !o.emit(exeCtx, cancel, val) {
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// emit handles an operation result, it returns false if the
+// operator should stop.
+func (o *OrderedOperator) emit(ctx context.Context, cancel context.CancelFunc, result interface{}) bool {
+	switch val := result.(type) {
+	case nil:
+		return true
+	case api.StreamError:
+		util.Logfn(o.logf, val)
+		autoctx.Err(o.errf, val)
+		if item := val.Item(); item != nil {
+			select {
+			case o.output <- *item:
+			case <-ctx.Done():
+				return false
+			}
+		}
+		return true
+	case api.PanicStreamError:
+		util.Logfn(o.logf, val)
+		autoctx.Err(o.errf, api.StreamError(val))
+		panic(val)
+	case api.CancelStreamError:
+		util.Logfn(o.logf, val)
+		autoctx.Err(o.errf, api.StreamError(val))
+		cancel()
+		return false
+	case error:
+		util.Logfn(o.logf, val)
+		autoctx.Err(o.errf, api.Error(val.Error()))
+		return true
+	default:
+		select {
+		case o.output <- val:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package unary
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+)
+
+func TestOrderedOp_Exec(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		for i := 0; i < 40; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+
+	o := NewOrdered(4, 8)
+	var m sync.Mutex
+	peak := 0
+	o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		m.Lock()
+		if p := o.Pending(); p > peak {
+			peak = p
+		}
+		m.Unlock()
+		if data.(int) == 3 {
+			time.Sleep(20 * time.Millisecond) // slow item
+		}
+		return data.(int) * 10
+	}))
+	o.SetInput(in)
+
+	if err := o.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := 0
+	wait := make(chan struct{})
+	go func() {
+		defer close(wait)
+		for data := range o.GetOutput() {
+			if data.(int) != expected*10 {
+				t.Errorf("expecting %d, got %v", expected*10, data)
+				return
+			}
+			expected++
+		}
+	}()
+
+	select {
+	case <-wait:
+	case <-time.After(time.Second):
+		t.Fatal("took too long")
+	}
+
+	if expected != 40 {
+		t.Fatal("expecting 40 items, got", expected)
+	}
+	m.Lock()
+	defer m.Unlock()
+	if peak > 8 {
+		t.Fatal("pending items exceeded bound:", peak)
+	}
+}
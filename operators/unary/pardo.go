@@ -0,0 +1,156 @@
+package unary
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// EmitFunc is used by a ParDoFunc to send an output downstream.  Outputs
+// with an empty tag are sent on the operator's main output, others are
+// sent on the output declared for the tag.
+type EmitFunc func(tag string, out interface{})
+
+// ParDoFunc is a user-defined function that processes a streamed item and
+// emits zero or more (tagged) outputs for it.
+type ParDoFunc func(ctx context.Context, item interface{}, emit EmitFunc)
+
+// ParDoOperator is an operator that applies a ParDoFunc to each streamed
+// item, routing the emitted outputs to a main output and a set of tagged
+// outputs.  All outputs are closed once the upstream closes.
+type ParDoOperator struct {
+	f      ParDoFunc
+	input  <-chan interface{}
+	output chan interface{}
+	tagged map[string]chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// NewParDo creates a *ParDoOperator with an output for each of the
+// specified tags (in addition to the main output).
+func NewParDo(f ParDoFunc, tags ...string) *ParDoOperator {
+	o := &ParDoOperator{
+		f:      f,
+		output: make(chan interface{}, 1024),
+		tagged: make(map[string]chan interface{}),
+	}
+	for _, tag := range tags {
+		if tag != "" {
+			o.tagged[tag] = make(chan interface{}, 1024)
+		}
+	}
+	return o
+}
+
+// SetInput sets the input channel for the executor node
+func (o *ParDoOperator) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+// GetOutput returns the main (untagged) output channel
+func (o *ParDoOperator) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+// Tagged returns an api.Source emitting the outputs for the tag,
+// or nil if the tag was not declared.  Every tagged source must be
+// consumed, otherwise the operator blocks once its buffer is full.
+func (o *ParDoOperator) Tagged(tag string) api.Source {
+	ch, ok := o.tagged[tag]
+	if !ok {
+		return nil
+	}
+	return &taggedSource{output: ch}
+}
+
+// Exec is the entry point for the executor
+func (o *ParDoOperator) Exec(ctx context.Context) error {
+	o.logf = autoctx.GetLogFunc(ctx)
+	o.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(o.logf, "ParDo operator started")
+
+	if o.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+	if o.f == nil {
+		return fmt.Errorf("ParDo operator missing function")
+	}
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			util.Logfn(o.logf, "ParDo operator done")
+			cancel()
+			close(o.output)
+			for _, ch := range o.tagged {
+				close(ch)
+			}
+		}()
+		o.doOp(exeCtx)
+	}()
+	return nil
+}
+
+func (o *ParDoOperator) doOp(ctx context.Context) {
+	for {
+		select {
+		case item, opened := <-o.input:
+			if !opened {
+				return
+			}
+			// outputs are held back by one, so only the last output
+			// emitted for the item carries its position
+			data, pos := api.UnwrapPosition(item)
+			var pendingCh chan interface{}
+			var pending interface{}
+			send := func(ch chan interface{}, out interface{}) bool {
+				select {
+				case ch <- out:
+					return true
+				case <-ctx.Done():
+					return false
+				}
+			}
+			o.f(ctx, data, func(tag string, out interface{}) {
+				ch := o.output
+				if tag != "" {
+					tagged, ok := o.tagged[tag]
+					if !ok {
+						err := api.ErrorWithItem(fmt.Sprintf("ParDo: undeclared tag %q", tag), &api.StreamItem{Item: out})
+						util.Logfn(o.logf, err)
+						autoctx.Err(o.errf, err)
+						return
+					}
+					ch = tagged
+				}
+				if pendingCh != nil {
+					send(pendingCh, pending)
+				}
+				pendingCh, pending = ch, out
+			})
+			if pendingCh != nil && !send(pendingCh, api.WithPosition(pending, pos)) {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// taggedSource emits the items of a tagged output
+type taggedSource struct {
+	output <-chan interface{}
+}
+
+func (s *taggedSource) GetOutput() <-chan interface{} {
+	return s.output
+}
+
+// Open is a no-op, items flow once the ParDo operator executes
+func (s *taggedSource) Open(context.Context) error {
+	return nil
+}
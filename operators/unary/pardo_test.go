@@ -0,0 +1,93 @@
+package unary
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+)
+
+func TestParDoOperator(t *testing.T) {
+	in := make(chan interface{}, 2)
+	in <- "hello"
+	in <- "world"
+	close(in)
+
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		errs = append(errs, err)
+	})
+
+	op := NewParDo(func(ctx context.Context, item interface{}, emit EmitFunc) {
+		emit("len", len(item.(string)))
+		emit("first", item.(string)[:1])
+		emit("unknown", item)
+	}, "len", "first")
+	op.SetInput(in)
+	if op.Tagged("unknown") != nil {
+		t.Fatal("expecting nil source for undeclared tag")
+	}
+	lens, firsts := op.Tagged("len"), op.Tagged("first")
+	if err := op.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	for range op.GetOutput() {
+		count++
+	}
+	var gotLens, gotFirsts []interface{}
+	for item := range lens.GetOutput() {
+		gotLens = append(gotLens, item)
+	}
+	for item := range firsts.GetOutput() {
+		gotFirsts = append(gotFirsts, item)
+	}
+
+	if count != 0 {
+		t.Fatal("unexpected items on main output", count)
+	}
+	if len(gotLens) != 2 || gotLens[0] != 5 {
+		t.Fatal("unexpected len outputs", gotLens)
+	}
+	if len(gotFirsts) != 2 || gotFirsts[1] != "w" {
+		t.Fatal("unexpected first outputs", gotFirsts)
+	}
+	if len(errs) != 2 {
+		t.Fatal("expecting errors for undeclared tag, got", len(errs))
+	}
+}
+
+func TestParDoOperator_Position(t *testing.T) {
+	in := make(chan interface{}, 2)
+	in <- api.StreamItem{Item: "ab", Position: 1}
+	in <- api.StreamItem{Item: "cde", Position: 2}
+	close(in)
+
+	op := NewParDo(func(ctx context.Context, item interface{}, emit EmitFunc) {
+		for _, r := range item.(string) {
+			emit("", string(r))
+		}
+	})
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var positions []interface{}
+	for item := range op.GetOutput() {
+		_, pos := api.UnwrapPosition(item)
+		positions = append(positions, pos)
+	}
+	// only the last output of each item carries its position
+	expected := []interface{}{nil, 1, nil, nil, 2}
+	if len(positions) != len(expected) {
+		t.Fatal("unexpected positions", positions)
+	}
+	for i, pos := range positions {
+		if pos != expected[i] {
+			t.Fatalf("expecting positions %v, got %v", expected, positions)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package unary
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vladimirvivien/automi/api"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// FieldSpec declares a field of a SchemaSpec.  Type is the expected type of
+// the field value (nil accepts any type), values of other types are coerced
+// to Type using util.Coerce.  For struct items only interface fields are
+// coerced, fields of other types must already be of Type.  A missing
+// required field is a violation, a missing optional field is set to Default
+// when Default is not nil.  A map field is missing when its key is absent or
+// its value is nil.  A struct field is missing when it is a nil pointer or
+// interface, fields of other kinds are always present (i.e. a legitimate 0
+// or false), so use pointer fields for optional struct values.
+type FieldSpec struct {
+	Name     string
+	Type     reflect.Type
+	Required bool
+	Default  interface{}
+}
+
+// SchemaSpec declares the fields expected of streamed map or struct items
+type SchemaSpec []FieldSpec
+
+// SchemaFunc returns an unary function which enforces spec on incoming
+// map items (with string keys) or struct items.  Conforming items are
+// returned with their fields coerced and optional defaults applied (maps are
+// copied, not modified in place).  Items that violate the schema are returned
+// as api.StreamError values listing each offending field, routing them to the
+// error path.
+func SchemaFunc(spec SchemaSpec) api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		var result interface{}
+		var violations []string
+		val := reflect.ValueOf(data)
+		switch {
+		case val.Kind() == reflect.Map && val.Type().Key().Kind() == reflect.String:
+			result, violations = applyMapSchema(spec, val)
+		case val.Kind() == reflect.Struct:
+			result, violations = applyStructSchema(spec, val)
+		default:
+			return api.Error(fmt.Sprintf("schema: expecting map or struct, got %T", data))
+		}
+		if len(violations) > 0 {
+			return api.Error(fmt.Sprintf("schema: %s: %v", strings.Join(violations, "; "), data))
+		}
+		return result
+	})
+}
+
+func applyMapSchema(spec SchemaSpec, item reflect.Value) (interface{}, []string) {
+	var violations []string
+	result := reflect.MakeMapWithSize(item.Type(), item.Len())
+	for _, key := range item.MapKeys() {
+		result.SetMapIndex(key, item.MapIndex(key))
+	}
+	elemType := item.Type().Elem()
+	for _, field := range spec {
+		key := reflect.ValueOf(field.Name).Convert(item.Type().Key())
+		value := item.MapIndex(key)
+		if !value.IsValid() || (value.Kind() == reflect.Interface && value.IsNil()) {
+			switch {
+			case field.Required:
+				violations = append(violations, fmt.Sprintf("field %q: required", field.Name))
+			case field.Default != nil:
+				defVal := reflect.ValueOf(field.Default)
+				if !defVal.Type().AssignableTo(elemType) {
+					violations = append(violations, fmt.Sprintf("field %q: default of type %T not assignable to %s", field.Name, field.Default, elemType))
+					continue
+				}
+				result.SetMapIndex(key, defVal)
+			}
+			continue
+		}
+		coerced, err := coerceField(field, value.Interface())
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("field %q: %s", field.Name, err))
+			continue
+		}
+		coercedVal := reflect.ValueOf(coerced)
+		if !coercedVal.Type().AssignableTo(elemType) {
+			violations = append(violations, fmt.Sprintf("field %q: %s not assignable to %s", field.Name, coercedVal.Type(), elemType))
+			continue
+		}
+		result.SetMapIndex(key, coercedVal)
+	}
+	return result.Interface(), violations
+}
+
+func applyStructSchema(spec SchemaSpec, item reflect.Value) (interface{}, []string) {
+	var violations []string
+	result := reflect.New(item.Type()).Elem()
+	result.Set(item)
+	for _, field := range spec {
+		value := result.FieldByName(field.Name)
+		if !value.IsValid() || !value.CanSet() {
+			if field.Required {
+				violations = append(violations, fmt.Sprintf("field %q: required", field.Name))
+			}
+			continue
+		}
+		if isMissing(value) {
+			switch {
+			case field.Required:
+				violations = append(violations, fmt.Sprintf("field %q: required", field.Name))
+				continue
+			case field.Default != nil:
+				defVal := reflect.ValueOf(field.Default)
+				if !defVal.Type().AssignableTo(value.Type()) {
+					violations = append(violations, fmt.Sprintf("field %q: default of type %T not assignable to %s", field.Name, field.Default, value.Type()))
+					continue
+				}
+				value.Set(defVal)
+			}
+		}
+		switch {
+		case field.Type == nil:
+		case value.Kind() == reflect.Interface:
+			// interface fields hold any value, coerce it like a map value
+			if value.IsNil() {
+				continue
+			}
+			coerced, err := coerceField(field, value.Elem().Interface())
+			if err != nil {
+				violations = append(violations, fmt.Sprintf("field %q: %s", field.Name, err))
+				continue
+			}
+			coercedVal := reflect.ValueOf(coerced)
+			if !coercedVal.Type().AssignableTo(value.Type()) {
+				violations = append(violations, fmt.Sprintf("field %q: %s not assignable to %s", field.Name, coercedVal.Type(), value.Type()))
+				continue
+			}
+			value.Set(coercedVal)
+		case value.Type() != field.Type:
+			violations = append(violations, fmt.Sprintf("field %q: expecting %s, got %s", field.Name, field.Type, value.Type()))
+		}
+	}
+	return result.Interface(), violations
+}
+
+// coerceField converts value to the type declared by field, if any
+func coerceField(field FieldSpec, value interface{}) (interface{}, error) {
+	if field.Type == nil {
+		return value, nil
+	}
+	return util.Coerce(value, field.Type)
+}
+
+// isMissing reports whether the struct field val holds no value, a nil
+// pointer or interface
+func isMissing(val reflect.Value) bool {
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return val.IsNil()
+	}
+	return false
+}
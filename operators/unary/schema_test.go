@@ -0,0 +1,87 @@
+package unary
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/vladimirvivien/automi/api"
+)
+
+func TestSchemaFunc_Map(t *testing.T) {
+	f := SchemaFunc(SchemaSpec{
+		{Name: "id", Type: reflect.TypeOf(0), Required: true},
+		{Name: "name", Type: reflect.TypeOf(""), Required: true},
+		{Name: "active", Type: reflect.TypeOf(false), Default: true},
+	})
+
+	item := map[string]interface{}{"id": "42", "name": "ann"}
+	result, ok := f(context.Background(), item).(map[string]interface{})
+	if !ok {
+		t.Fatal("unexpected result", result)
+	}
+	if result["id"] != 42 || result["active"] != true {
+		t.Fatal("expecting coerced id and default active, got", result)
+	}
+	if item["id"] != "42" {
+		t.Fatal("source item should not be modified")
+	}
+
+	if _, ok := f(context.Background(), map[string]interface{}{"id": 1}).(api.StreamError); !ok {
+		t.Fatal("expecting missing field violation")
+	}
+	if _, ok := f(context.Background(), map[string]interface{}{"id": "x", "name": "bob"}).(api.StreamError); !ok {
+		t.Fatal("expecting coercion violation")
+	}
+}
+
+func TestSchemaFunc_Struct(t *testing.T) {
+	type user struct {
+		ID     *int
+		Role   interface{}
+		Age    int
+		Active bool
+	}
+	f := SchemaFunc(SchemaSpec{
+		{Name: "ID", Required: true},
+		{Name: "Role", Default: "guest"},
+		{Name: "Age", Type: reflect.TypeOf(0), Required: true, Default: 18},
+		{Name: "Active", Required: true, Default: true},
+	})
+	id := 1
+	result, ok := f(context.Background(), user{ID: &id}).(user)
+	if !ok || result.Role != "guest" {
+		t.Fatal("unexpected result", result)
+	}
+	// legitimate zero values are neither missing nor overwritten
+	if result.Age != 0 || result.Active {
+		t.Fatal("expecting zero fields kept, got", result)
+	}
+	if _, ok := f(context.Background(), user{Role: "admin", Age: 30}).(api.StreamError); !ok {
+		t.Fatal("expecting missing field violation")
+	}
+}
+
+func TestSchemaFunc_StructCoerce(t *testing.T) {
+	type order struct {
+		Qty   interface{}
+		Price float64
+	}
+	f := SchemaFunc(SchemaSpec{
+		{Name: "Qty", Type: reflect.TypeOf(0), Required: true},
+		{Name: "Price", Type: reflect.TypeOf(0.0)},
+	})
+	result, ok := f(context.Background(), order{Qty: "42", Price: 2.5}).(order)
+	if !ok || result.Qty != 42 {
+		t.Fatal("expecting coerced interface field, got", result)
+	}
+	if _, ok := f(context.Background(), order{Qty: "x"}).(api.StreamError); !ok {
+		t.Fatal("expecting coercion violation")
+	}
+
+	// fields of other types are only validated
+	f = SchemaFunc(SchemaSpec{{Name: "Price", Type: reflect.TypeOf("")}})
+	if _, ok := f(context.Background(), order{Price: 2.5}).(api.StreamError); !ok {
+		t.Fatal("expecting type violation")
+	}
+}
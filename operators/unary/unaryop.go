@@ -7,6 +7,7 @@ import (
 
 	"github.com/vladimirvivien/automi/api"
 	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/group"
 	"github.com/vladimirvivien/automi/util"
 )
 
@@ -22,6 +23,8 @@ func pack(vals ...interface{}) packed {
 type UnaryOperator struct {
 	op          api.UnOperation
 	concurrency int
+	limiter     *group.SemaphoreLimiter
+	itemErrf    func(item interface{}, err error) (interface{}, bool)
 	input       <-chan interface{}
 	output      chan interface{}
 	logf        api.LogFunc
@@ -53,6 +56,26 @@ func (o *UnaryOperator) SetConcurrency(concurr int) {
 	}
 }
 
+// SetLimiter sets a semaphore used to cap the number of in-flight
+// applications of the operation, independently of the concurrency level.
+// This is useful for I/O-bound operations (i.e. max N concurrent HTTP calls).
+// The concurrency level (number of workers) is only honored when a limiter
+// is set, otherwise the operator runs with a single worker which preserves
+// the order of items.
+func (o *UnaryOperator) SetLimiter(limiter *group.SemaphoreLimiter) {
+	o.limiter = limiter
+}
+
+// SetItemErrorHandler sets a handler for the errors returned by the
+// operation, which are then handled by the operator instead of the stream
+// error path.  The handler receives the item that caused the error, if it
+// returns (value, true) the value is sent downstream in place of the item,
+// if it returns false the item is dropped.  Panic and cancel errors are
+// not passed to the handler.
+func (o *UnaryOperator) SetItemErrorHandler(handler func(item interface{}, err error) (interface{}, bool)) {
+	o.itemErrf = handler
+}
+
 // SetInput sets the input channel for the executor node
 func (o *UnaryOperator) SetInput(in <-chan interface{}) {
 	o.input = in
@@ -80,23 +103,37 @@ func (o *UnaryOperator) Exec(ctx context.Context) (err error) {
 			close(o.output)
 		}()
 
-		o.doOp(ctx)
-	}()
-	return nil
-}
+		if o.op == nil {
+			util.Logfn(o.logf, "Unary operator missing operation")
+			return
+		}
 
-func (o *UnaryOperator) doOp(ctx context.Context) {
-	if o.op == nil {
-		util.Logfn(o.logf, "Unary operator missing operation")
-		return
-	}
-	exeCtx, cancel := context.WithCancel(ctx)
+		exeCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			util.Logfn(o.logf, "unary operator done, cancelling future items")
+			cancel()
+		}()
 
-	defer func() {
-		util.Logfn(o.logf, "unary operator done, cancelling future items")
-		cancel()
+		// workers fan-out only applies to limited operations
+		workers := 1
+		if o.limiter != nil {
+			workers = o.concurrency
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(workers)
+		for i := 0; i < workers; i++ {
+			go func() {
+				defer wg.Done()
+				o.doOp(exeCtx, cancel)
+			}()
+		}
+		wg.Wait()
 	}()
+	return nil
+}
 
+func (o *UnaryOperator) doOp(exeCtx context.Context, cancel context.CancelFunc) {
 	for {
 		select {
 		// process incoming item
@@ -105,41 +142,17 @@ func (o *UnaryOperator) doOp(ctx context.Context) {
 				return
 			}
 
-			result := o.op.Apply(exeCtx, item)
-
-			switch val := result.(type) {
-			case nil:
-				continue
-			case api.StreamError:
-				util.Logfn(o.logf, val)
-				autoctx.Err(o.errf, val)
-				if item := val.Item(); item != nil {
-					select {
-					case o.output <- *item:
-					case <-exeCtx.Done():
+			// batches sent by emitters are processed item by item
+			if batch, ok := item.(api.ItemBatch); ok {
+				for _, item := range batch {
+					if !o.process(exeCtx, cancel, item) {
 						return
 					}
 				}
 				continue
-			case api.PanicStreamError:
-				util.Logfn(o.logf, val)
-				autoctx.Err(o.errf, api.StreamError(val))
-				panic(val)
-			case api.CancelStreamError:
-				util.Logfn(o.logf, val)
-				autoctx.Err(o.errf, api.StreamError(val))
+			}
+			if !o.process(exeCtx, cancel, item) {
 				return
-			case error:
-				util.Logfn(o.logf, val)
-				autoctx.Err(o.errf, api.Error(val.Error()))
-				continue
-
-			default:
-				select {
-				case o.output <- val:
-				case <-exeCtx.Done():
-					return
-				}
 			}
 
 		// is cancelling
@@ -148,3 +161,92 @@ func (o *UnaryOperator) doOp(ctx context.Context) {
 		}
 	}
 }
+
+// process applies the operation on item and sends the result downstream.
+// It returns false when the operator must stop.
+func (o *UnaryOperator) process(exeCtx context.Context, cancel context.CancelFunc, item interface{}) bool {
+	result, ok := o.apply(exeCtx, item)
+	if !ok {
+		return false
+	}
+	if o.itemErrf != nil {
+		result = o.handleItemErr(item, result)
+	}
+
+	switch val := result.(type) {
+	case nil:
+		return true
+	case api.StreamError:
+		util.Logfn(o.logf, val)
+		autoctx.Err(o.errf, val)
+		if item := val.Item(); item != nil {
+			select {
+			case o.output <- *item:
+			case <-exeCtx.Done():
+				return false
+			}
+		}
+		return true
+	case api.PanicStreamError:
+		util.Logfn(o.logf, val)
+		autoctx.Err(o.errf, api.StreamError(val))
+		panic(val)
+	case api.CancelStreamError:
+		util.Logfn(o.logf, val)
+		autoctx.Err(o.errf, api.StreamError(val))
+		cancel()
+		return false
+	case error:
+		util.Logfn(o.logf, val)
+		autoctx.Err(o.errf, api.Error(val.Error()))
+		return true
+
+	default:
+		select {
+		case o.output <- val:
+		case <-exeCtx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// handleItemErr passes the error result of the operation on item to the
+// item error handler and returns the substitute for the item (or nil).
+// Other results are returned as is.
+func (o *UnaryOperator) handleItemErr(item, result interface{}) interface{} {
+	var err error
+	switch val := result.(type) {
+	case api.PanicStreamError, api.CancelStreamError:
+		return result
+	case error:
+		err = val
+	default:
+		return result
+	}
+
+	data, pos := api.UnwrapPosition(item)
+	sub, ok := o.itemErrf(data, err)
+	if !ok {
+		util.Logfn(o.logf, fmt.Sprintf("Unary operator dropped item on error: %s", err))
+		return nil
+	}
+	return api.WithPosition(sub, pos)
+}
+
+// apply applies the operation on item, holding a limiter slot (if any)
+// for the duration of the call. It returns false if the slot could not
+// be acquired because the context is done.
+func (o *UnaryOperator) apply(ctx context.Context, item interface{}) (interface{}, bool) {
+	if o.limiter != nil {
+		if err := o.limiter.Acquire(ctx); err != nil {
+			return nil, false
+		}
+		defer o.limiter.Release()
+	}
+
+	// items carrying a source position are unwrapped for the operation
+	// and the result is re-wrapped so the position reaches the sink.
+	data, pos := api.UnwrapPosition(item)
+	return api.WithPosition(o.op.Apply(ctx, data), pos), true
+}
@@ -2,12 +2,16 @@ package unary
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/group"
 	"github.com/vladimirvivien/automi/testutil"
 )
 
@@ -332,3 +336,158 @@ func BenchmarkUnaryOp_Exec(b *testing.B) {
 	}
 	m.RUnlock()
 }
+
+func TestUnaryOp_Limiter(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		for i := 0; i < 50; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+
+	var inflight, peak int32
+	o := New()
+	o.SetConcurrency(10)
+	o.SetLimiter(group.Semaphore(3))
+	o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		cur := atomic.AddInt32(&inflight, 1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if cur <= old || atomic.CompareAndSwapInt32(&peak, old, cur) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&inflight, -1)
+		return data
+	}))
+	o.SetInput(in)
+
+	wait := make(chan struct{})
+	count := 0
+	go func() {
+		defer close(wait)
+		for range o.GetOutput() {
+			count++
+		}
+	}()
+
+	if err := o.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-wait:
+	case <-time.After(time.Second):
+		t.Fatal("took too long")
+	}
+
+	if count != 50 {
+		t.Fatal("expecting 50 items, got", count)
+	}
+	if p := atomic.LoadInt32(&peak); p > 3 || p < 1 {
+		t.Fatal("expecting at most 3 concurrent operations, got", p)
+	}
+}
+
+func TestUnaryOp_ConcurrencyWithoutLimiter(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+
+	o := New()
+	o.SetConcurrency(8) // no limiter: single worker, order preserved
+	o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		return data
+	}))
+	o.SetInput(in)
+
+	if err := o.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := 0
+	for data := range o.GetOutput() {
+		if data.(int) != expected {
+			t.Fatalf("expecting item %d, got %v", expected, data)
+		}
+		expected++
+	}
+	if expected != 100 {
+		t.Fatal("expecting 100 items, got", expected)
+	}
+}
+
+func TestUnaryOp_ErrorItemPosition(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- api.StreamItem{Item: "bad", Position: 7}
+		close(in)
+	}()
+
+	o := New()
+	o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		return api.ErrorWithItem("bad item", &api.StreamItem{Item: data})
+	}))
+	o.SetInput(in)
+	if err := o.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	item := (<-o.GetOutput()).(api.StreamItem)
+	if item.Position != 7 || item.Item != "bad" {
+		t.Fatal("error item did not carry source position:", item)
+	}
+}
+
+func TestUnaryOp_ItemErrorHandler(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		for _, item := range []interface{}{"1", "x", "3", "skip"} {
+			in <- item
+		}
+		close(in)
+	}()
+
+	var reported []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) {
+		reported = append(reported, err)
+	})
+
+	o := New()
+	o.SetOperation(api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		switch data {
+		case "x":
+			return fmt.Errorf("bad number")
+		case "skip":
+			return api.Error("skipped")
+		}
+		return data
+	}))
+	o.SetItemErrorHandler(func(item interface{}, err error) (interface{}, bool) {
+		if item == "x" {
+			return "0", true
+		}
+		return nil, false
+	})
+	o.SetInput(in)
+	if err := o.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var result []interface{}
+	for item := range o.GetOutput() {
+		result = append(result, item)
+	}
+	if len(result) != 3 || result[0] != "1" || result[1] != "0" || result[2] != "3" {
+		t.Fatal("unexpected result", result)
+	}
+	if len(reported) != 0 {
+		t.Fatal("expecting handled errors not to reach the error path, got", reported)
+	}
+}
@@ -0,0 +1,324 @@
+package window
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// EmitPolicy controls when a windowed aggregate is sent downstream
+type EmitPolicy int
+
+const (
+	// EmitOnClose sends the final aggregate of each key when its window closes
+	EmitOnClose EmitPolicy = iota
+	// EmitOnUpdate also sends the partial aggregate of a key for each item
+	// aggregated, before the final aggregate is sent when the window closes
+	EmitOnUpdate
+)
+
+// AggregateFunc folds item into the aggregate acc, which is nil
+// for the first item of a key in a window
+type AggregateFunc func(acc, item interface{}) interface{}
+
+// Aggregate is the (partial or final) aggregate of a key in a window
+type Aggregate struct {
+	Key    interface{}
+	Value  interface{}
+	Window time.Time // start of the window
+	Final  bool
+}
+
+// KeyCount is the number of items of a key in a window
+type KeyCount struct {
+	WindowStart time.Time
+	Key         interface{}
+	Count       int64
+}
+
+// CountAggregate is an AggregateFunc counting items, as int64
+func CountAggregate(acc, _ interface{}) interface{} {
+	if acc == nil {
+		return int64(1)
+	}
+	return acc.(int64) + 1
+}
+
+// KeyCountFunc returns an api.UnFunc which converts the final Aggregate
+// values of CountAggregate into KeyCount values, partial aggregates are
+// dropped.
+func KeyCountFunc() api.UnFunc {
+	return api.UnFunc(func(ctx context.Context, data interface{}) interface{} {
+		agg, ok := data.(Aggregate)
+		if !ok || !agg.Final {
+			return nil
+		}
+		count, _ := agg.Value.(int64)
+		return KeyCount{WindowStart: agg.Window, Key: agg.Key, Count: count}
+	})
+}
+
+// AggregateOperator is an executor node that aggregates incoming items,
+// per key, within tumbling event-time windows.  Windows are aligned to their
+// size and close once the latest event time seen passes their end (plus
+// the allowed lateness) or when the upstream closes: the final Aggregate of
+// each key is then sent downstream, in the order the keys first appeared in
+// the window.  Items of a window that is already closed are late, they are
+// reported on the error path and dropped, unless the late data output is
+// used (see GetLateData).  The final aggregates of positioned items carry the
+// latest position not preceded by an item of an open window.
+type AggregateOperator struct {
+	size     time.Duration
+	keyFn    func(interface{}) interface{}
+	aggFn    AggregateFunc
+	policy   EmitPolicy
+	lateness time.Duration
+	late     chan interface{}
+	input    <-chan interface{}
+	output   chan interface{}
+	logf     api.LogFunc
+	errf     api.ErrorFunc
+}
+
+// NewAggregate creates an *AggregateOperator for windows of the specified size
+func NewAggregate(size time.Duration, keyFn func(interface{}) interface{}, aggFn AggregateFunc, policy EmitPolicy) *AggregateOperator {
+	return &AggregateOperator{
+		size:   size,
+		keyFn:  keyFn,
+		aggFn:  aggFn,
+		policy: policy,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// AllowedLateness keeps windows open for d past their end, in event time,
+// so that out-of-order items can still be aggregated in their window.
+func (op *AggregateOperator) AllowedLateness(d time.Duration) *AggregateOperator {
+	op.lateness = d
+	return op
+}
+
+// GetLateData returns an api.Source emitting the late items, as they were
+// received but without their source position, instead of reporting them on
+// the error path.  The positions of late items are held and carried by the
+// final aggregates of the main output, once no open window precedes them,
+// so only the main output commits positions.  It must be called
+// before the operator executes and the source must be consumed, otherwise
+// the operator blocks once its buffer is full.
+func (op *AggregateOperator) GetLateData() api.Source {
+	if op.late == nil {
+		op.late = make(chan interface{}, 1024)
+	}
+	return &lateSource{output: op.late}
+}
+
+// SetInput sets the input channel for the executor node
+func (op *AggregateOperator) SetInput(in <-chan interface{}) {
+	op.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (op *AggregateOperator) GetOutput() <-chan interface{} {
+	return op.output
+}
+
+// Exec is the execution starting point for the operator node.
+func (op *AggregateOperator) Exec(ctx context.Context) (err error) {
+	op.logf = autoctx.GetLogFunc(ctx)
+	op.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(op.logf, "Aggregate operator starting")
+
+	if op.input == nil {
+		err = fmt.Errorf("No input channel found")
+		return
+	}
+	if op.size <= 0 {
+		err = fmt.Errorf("Window size must be greater than zero")
+		return
+	}
+	if op.keyFn == nil || op.aggFn == nil {
+		err = fmt.Errorf("Aggregate operator missing key or aggregate function")
+		return
+	}
+
+	go func() {
+		var windows []*aggWindow // open windows, by start time
+		var watermark time.Time  // latest event time seen
+		var held heldPositions
+		exeCtx, cancel := context.WithCancel(ctx)
+
+		send := func(item interface{}) bool {
+			select {
+			case op.output <- item:
+				return true
+			case <-exeCtx.Done():
+				return false
+			}
+		}
+		// closeWindows sends the final aggregates of the windows
+		// ending, with the allowed lateness, before the deadline.  Positions
+		// from the item numbered pending on (not windowed yet) are held.
+		closeWindows := func(deadline time.Time, all bool, pending int64) bool {
+			for len(windows) > 0 {
+				win := windows[0]
+				if !all && win.start.Add(op.size+op.lateness).After(deadline) {
+					return true
+				}
+				windows = windows[1:]
+				// windows close in start order, not in the order of
+				// their items: the last aggregate carries the positions
+				// of the items before the oldest item of an open window
+				oldest := pending
+				for _, open := range windows {
+					if open.first < oldest {
+						oldest = open.first
+					}
+				}
+				pos := held.release(oldest)
+				for i, key := range win.keys {
+					agg := Aggregate{Key: key, Value: win.accs[key], Window: win.start, Final: true}
+					if i == len(win.keys)-1 {
+						if !send(api.WithPosition(agg, pos)) {
+							return false
+						}
+						continue
+					}
+					if !send(agg) {
+						return false
+					}
+				}
+			}
+			return true
+		}
+
+		defer func() {
+			util.Logfn(op.logf, "Closing aggregate operator")
+			closeWindows(watermark, true, noOpenGroup)
+			cancel()
+			close(op.output)
+			if op.late != nil {
+				close(op.late)
+			}
+		}()
+
+		for {
+			select {
+			case item, opened := <-op.input:
+				if !opened {
+					return
+				}
+				// positions of late or dropped items are held too, they
+				// are released by the main output
+				item, pos := api.UnwrapPosition(item)
+				seq := held.add(pos)
+				stamp, data := op.eventTime(item)
+				start := stamp.Truncate(op.size)
+
+				// item of a window already closed
+				if !watermark.IsZero() && !start.Add(op.size+op.lateness).After(watermark) {
+					if op.late != nil {
+						select {
+						case op.late <- item:
+						case <-exeCtx.Done():
+							return
+						}
+						continue
+					}
+					err := api.ErrorWithItem(fmt.Sprintf("aggregate item for closed window %s is late", start), &api.StreamItem{Item: data})
+					util.Logfn(op.logf, err)
+					autoctx.Err(op.errf, err)
+					continue
+				}
+
+				key := op.keyFn(data)
+				if key != nil && !reflect.TypeOf(key).Comparable() {
+					err := api.ErrorWithItem(fmt.Sprintf("aggregate key of type %T is not comparable", key), &api.StreamItem{Item: data})
+					util.Logfn(op.logf, err)
+					autoctx.Err(op.errf, err)
+					continue
+				}
+
+				// advance event time, closing the windows it passed
+				// (never the window of the item)
+				if stamp.After(watermark) {
+					watermark = stamp
+					if !closeWindows(watermark, false, seq) {
+						return
+					}
+				}
+
+				win := op.window(&windows, start, seq)
+				acc, seen := win.accs[key]
+				if !seen {
+					win.keys = append(win.keys, key)
+				}
+				acc = op.aggFn(acc, data)
+				win.accs[key] = acc
+
+				if op.policy == EmitOnUpdate {
+					if !send(Aggregate{Key: key, Value: acc, Window: win.start}) {
+						return
+					}
+				}
+
+			case <-exeCtx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// aggWindow holds the aggregates of the keys of an open window
+type aggWindow struct {
+	start time.Time
+	keys  []interface{} // in order of first appearance
+	accs  map[interface{}]interface{}
+	first int64 // sequence of first item in window (see heldPositions)
+}
+
+// window returns the open window starting at start, creating it
+// in start order, for the item numbered seq, if needed
+func (op *AggregateOperator) window(windows *[]*aggWindow, start time.Time, seq int64) *aggWindow {
+	i := 0
+	for ; i < len(*windows); i++ {
+		win := (*windows)[i]
+		if win.start.Equal(start) {
+			return win
+		}
+		if win.start.After(start) {
+			break
+		}
+	}
+	win := &aggWindow{start: start, accs: make(map[interface{}]interface{}), first: seq}
+	*windows = append(*windows, nil)
+	copy((*windows)[i+1:], (*windows)[i:])
+	(*windows)[i] = win
+	return win
+}
+
+// eventTime returns the event time and data of item
+func (op *AggregateOperator) eventTime(item interface{}) (time.Time, interface{}) {
+	if timed, ok := item.(api.TimedItem); ok {
+		return timed.Time, timed.Item
+	}
+	return time.Now(), item
+}
+
+type lateSource struct {
+	output <-chan interface{}
+}
+
+func (s *lateSource) GetOutput() <-chan interface{} {
+	return s.output
+}
+
+// Open is a no-op, items flow once the aggregate operator executes
+func (s *lateSource) Open(context.Context) error {
+	return nil
+}
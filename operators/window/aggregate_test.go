@@ -0,0 +1,247 @@
+package window
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+)
+
+func sumAggregate(acc, item interface{}) interface{} {
+	if acc == nil {
+		return item.(int)
+	}
+	return acc.(int) + item.(int)
+}
+
+func TestAggregateOp_Exec(t *testing.T) {
+	start := time.Unix(0, 0)
+	in := make(chan interface{})
+	go func() {
+		for i, sec := range []int{0, 1, 2, 6} {
+			in <- api.TimedItem{Time: start.Add(time.Duration(sec) * time.Second), Item: i + 1}
+		}
+		close(in)
+	}()
+
+	keyFn := func(item interface{}) interface{} { return item.(int) % 2 }
+	op := NewAggregate(5*time.Second, keyFn, sumAggregate, EmitOnUpdate)
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var aggs []Aggregate
+	for item := range op.GetOutput() {
+		aggs = append(aggs, item.(Aggregate))
+	}
+	// window 0: partials 1, 2, 4 (key 1: 1+3), finals key 1 = 4, key 0 = 2
+	// window 5: partial 4, final 4
+	expected := []Aggregate{
+		{Key: 1, Value: 1}, {Key: 0, Value: 2}, {Key: 1, Value: 4},
+		{Key: 1, Value: 4, Final: true}, {Key: 0, Value: 2, Final: true},
+		{Key: 0, Value: 4, Window: start.Add(5 * time.Second)},
+		{Key: 0, Value: 4, Window: start.Add(5 * time.Second), Final: true},
+	}
+	if len(aggs) != len(expected) {
+		t.Fatal("unexpected aggregates", aggs)
+	}
+	for i := range expected {
+		if expected[i].Window.IsZero() {
+			expected[i].Window = start
+		}
+		if aggs[i].Key != expected[i].Key || aggs[i].Value != expected[i].Value ||
+			aggs[i].Final != expected[i].Final || !aggs[i].Window.Equal(expected[i].Window) {
+			t.Fatalf("aggregate %d: expecting %v, got %v", i, expected[i], aggs[i])
+		}
+	}
+}
+
+func TestAggregateOp_EmitOnClose(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		for i := 1; i <= 4; i++ {
+			in <- api.TimedItem{Time: time.Unix(0, 0), Item: i}
+		}
+		close(in)
+	}()
+
+	op := NewAggregate(time.Second, func(interface{}) interface{} { return "all" }, sumAggregate, EmitOnClose)
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var aggs []Aggregate
+	for item := range op.GetOutput() {
+		aggs = append(aggs, item.(Aggregate))
+	}
+	if len(aggs) != 1 || aggs[0].Value != 10 || !aggs[0].Final {
+		t.Fatal("expecting a single final aggregate, got", aggs)
+	}
+}
+
+func TestAggregateOp_AllowedLateness(t *testing.T) {
+	start := time.Unix(0, 0)
+	in := make(chan interface{})
+	go func() {
+		// window 0 is kept open until event time 7 (size 5 + lateness 2)
+		for i, sec := range []int{1, 6, 3, 8, 4} {
+			in <- api.TimedItem{Time: start.Add(time.Duration(sec) * time.Second), Item: i + 1}
+		}
+		close(in)
+	}()
+
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) { errs = append(errs, err) })
+	op := NewAggregate(5*time.Second, func(interface{}) interface{} { return "all" }, sumAggregate, EmitOnClose).
+		AllowedLateness(2 * time.Second)
+	op.SetInput(in)
+	if err := op.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var aggs []Aggregate
+	for item := range op.GetOutput() {
+		aggs = append(aggs, item.(Aggregate))
+	}
+	// window 0: 1 + 3 (late within lateness), window 5: 2 + 4, item 5 is too late
+	if len(aggs) != 2 || aggs[0].Value != 4 || !aggs[0].Window.Equal(start) ||
+		aggs[1].Value != 6 || !aggs[1].Window.Equal(start.Add(5*time.Second)) {
+		t.Fatal("unexpected aggregates", aggs)
+	}
+	if len(errs) != 1 || errs[0].Item() == nil || errs[0].Item().Item != 5 {
+		t.Fatal("expecting late item on error path, got", errs)
+	}
+}
+
+func TestAggregateOp_Positions(t *testing.T) {
+	start := time.Unix(0, 0)
+	in := make(chan interface{})
+	go func() {
+		// out of order within the lateness, window 0 closes first
+		for i, sec := range []int{12, 3, 16} {
+			timed := api.TimedItem{Time: start.Add(time.Duration(sec) * time.Second), Item: i + 1}
+			in <- api.StreamItem{Item: timed, Position: i + 1}
+		}
+		close(in)
+	}()
+
+	op := NewAggregate(10*time.Second, func(interface{}) interface{} { return "all" }, CountAggregate, EmitOnClose).
+		AllowedLateness(5 * time.Second)
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var aggs []Aggregate
+	var positions []interface{}
+	for item := range op.GetOutput() {
+		agg, pos := api.UnwrapPosition(item)
+		aggs = append(aggs, agg.(Aggregate))
+		positions = append(positions, pos)
+	}
+	if len(aggs) != 2 || !aggs[0].Window.Equal(start) || aggs[1].Value != int64(2) {
+		t.Fatal("unexpected aggregates", aggs)
+	}
+	// window 10 still holds position 1 when window 0 closes
+	if positions[0] != nil || positions[1] != 3 {
+		t.Fatal("expecting positions nil then 3, got", positions)
+	}
+}
+
+func TestAggregateOp_LateData(t *testing.T) {
+	start := time.Unix(0, 0)
+	in := make(chan interface{})
+	go func() {
+		for i, sec := range []int{1, 6, 3, 8, 4} {
+			in <- api.TimedItem{Time: start.Add(time.Duration(sec) * time.Second), Item: i + 1}
+		}
+		close(in)
+	}()
+
+	var errs []api.StreamError
+	ctx := autoctx.WithErrorFunc(context.Background(), func(err api.StreamError) { errs = append(errs, err) })
+	op := NewAggregate(5*time.Second, func(interface{}) interface{} { return "all" }, sumAggregate, EmitOnClose).
+		AllowedLateness(2 * time.Second)
+	late := op.GetLateData()
+	op.SetInput(in)
+	if err := op.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var lateItems []interface{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for item := range late.GetOutput() {
+			lateItems = append(lateItems, item)
+		}
+	}()
+
+	var aggs []Aggregate
+	for item := range op.GetOutput() {
+		aggs = append(aggs, item.(Aggregate))
+	}
+	select {
+	case <-done:
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("expecting late data output to close")
+	}
+
+	if len(aggs) != 2 || aggs[0].Value != 4 || aggs[1].Value != 6 {
+		t.Fatal("unexpected aggregates", aggs)
+	}
+	if len(lateItems) != 1 || lateItems[0].(api.TimedItem).Item != 5 {
+		t.Fatal("expecting item beyond lateness on late data output, got", lateItems)
+	}
+	if len(errs) != 0 {
+		t.Fatal("expecting no late item on error path, got", errs)
+	}
+}
+
+func TestAggregateOp_LateDataPositions(t *testing.T) {
+	start := time.Unix(0, 0)
+	in := make(chan interface{})
+	go func() {
+		// item 3 is late, window 20 is still open
+		for i, sec := range []int{12, 25, 3} {
+			timed := api.TimedItem{Time: start.Add(time.Duration(sec) * time.Second), Item: i + 1}
+			in <- api.StreamItem{Item: timed, Position: i + 1}
+		}
+		close(in)
+	}()
+
+	op := NewAggregate(10*time.Second, func(interface{}) interface{} { return "all" }, CountAggregate, EmitOnClose)
+	late := op.GetLateData()
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var lateItems []interface{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for item := range late.GetOutput() {
+			lateItems = append(lateItems, item)
+		}
+	}()
+
+	var positions []interface{}
+	for item := range op.GetOutput() {
+		_, pos := api.UnwrapPosition(item)
+		positions = append(positions, pos)
+	}
+	<-done
+
+	if len(lateItems) != 1 || lateItems[0].(api.TimedItem).Item != 3 {
+		t.Fatal("expecting late item without position, got", lateItems)
+	}
+	// the position of the late item is committed by the main output
+	if len(positions) != 2 || positions[0] != 1 || positions[1] != 3 {
+		t.Fatal("expecting positions 1 then 3, got", positions)
+	}
+}
@@ -0,0 +1,34 @@
+package window
+
+import (
+	"context"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+)
+
+// AssignTimestampsFunc returns an api.UnFunc that wraps each incoming item as
+// an api.TimedItem with a synthetic, monotonically increasing, event time.
+// The first item is stamped with start and each subsequent item is stamped
+// step later than the previous one.  If step <= 0, one nanosecond is used
+// (one tick per item).  The function must be applied by a single worker.
+func AssignTimestampsFunc(start time.Time, step time.Duration) api.UnFunc {
+	if step <= 0 {
+		step = time.Nanosecond
+	}
+	var index int64
+	return api.UnFunc(func(ctx context.Context, param0 interface{}) interface{} {
+		stamp := start.Add(time.Duration(index) * step)
+		index++
+		return api.TimedItem{Time: stamp, Item: param0}
+	})
+}
+
+// EventTime returns the event time of item if it is an api.TimedItem
+// and true.  Otherwise, it returns the zero time and false.
+func EventTime(item interface{}) (time.Time, bool) {
+	if timed, ok := item.(api.TimedItem); ok {
+		return timed.Time, true
+	}
+	return time.Time{}, false
+}
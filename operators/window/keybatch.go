@@ -0,0 +1,184 @@
+package window
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// KeyBatch is a batch of items of the same key
+type KeyBatch struct {
+	Key   interface{}
+	Items []interface{}
+}
+
+// KeyBatchOperator is an executor node that batches incoming items per key.
+// The batch of a key is sent downstream, as a KeyBatch, when it reaches
+// maxCount items or when maxWait elapses since its first item, whichever
+// comes first.  Open batches are flushed when the upstream closes.  Batches
+// of positioned items carry the latest position not preceded by an item of
+// another open batch.
+type KeyBatchOperator struct {
+	keyFn    func(interface{}) interface{}
+	maxCount int
+	maxWait  time.Duration
+	input    <-chan interface{}
+	output   chan interface{}
+	logf     api.LogFunc
+	errf     api.ErrorFunc
+}
+
+type keyBatch struct {
+	items []interface{}
+	first int64 // sequence of first item in batch (see heldPositions)
+	gen   int
+	timer *time.Timer
+}
+
+type keyBatchExpiry struct {
+	key interface{}
+	gen int
+}
+
+// NewKeyBatch creates a *KeyBatchOperator.  A maxCount (or maxWait) of
+// zero disables flushing by count (or by time), but not both.
+func NewKeyBatch(keyFn func(interface{}) interface{}, maxCount int, maxWait time.Duration) *KeyBatchOperator {
+	op := new(KeyBatchOperator)
+	op.keyFn = keyFn
+	op.maxCount = maxCount
+	op.maxWait = maxWait
+	op.output = make(chan interface{}, 1024)
+	return op
+}
+
+// SetInput sets the input channel for the executor node
+func (op *KeyBatchOperator) SetInput(in <-chan interface{}) {
+	op.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (op *KeyBatchOperator) GetOutput() <-chan interface{} {
+	return op.output
+}
+
+// Exec is the execution starting point for the operator node.
+func (op *KeyBatchOperator) Exec(ctx context.Context) (err error) {
+	op.logf = autoctx.GetLogFunc(ctx)
+	op.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(op.logf, "Key batch operator starting")
+
+	if op.input == nil {
+		err = fmt.Errorf("No input channel found")
+		return
+	}
+	if op.keyFn == nil {
+		err = fmt.Errorf("Key batch operator requires a key function")
+		return
+	}
+	if op.maxCount <= 0 && op.maxWait <= 0 {
+		err = fmt.Errorf("Key batch operator requires a max count or a max wait greater than zero")
+		return
+	}
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		batches := make(map[interface{}]*keyBatch)
+		var order []interface{} // batch keys, oldest first
+		var gen int             // identifies batches for their timer
+		var held heldPositions
+		expired := make(chan keyBatchExpiry)
+
+		flush := func(key interface{}) bool {
+			batch := batches[key]
+			if batch.timer != nil {
+				batch.timer.Stop()
+			}
+			delete(batches, key)
+			for i, k := range order {
+				if k == key {
+					order = append(order[:i], order[i+1:]...)
+					break
+				}
+			}
+			oldest := noOpenGroup
+			if len(order) > 0 {
+				oldest = batches[order[0]].first
+			}
+			result := KeyBatch{Key: key, Items: batch.items}
+			select {
+			case op.output <- api.WithPosition(result, held.release(oldest)):
+				return true
+			case <-exeCtx.Done():
+				return false
+			}
+		}
+
+		defer func() {
+			util.Logfn(op.logf, "Closing key batch operator")
+			for len(order) > 0 {
+				if !flush(order[0]) {
+					break
+				}
+			}
+			cancel()
+			close(op.output)
+		}()
+
+		for {
+			select {
+			case item, opened := <-op.input:
+				if !opened {
+					return
+				}
+				item, pos := api.UnwrapPosition(item)
+				key := op.keyFn(item)
+				if key != nil && !reflect.TypeOf(key).Comparable() {
+					msg := fmt.Sprintf("batch key of type %T is not comparable", key)
+					util.Logfn(op.logf, msg)
+					autoctx.Err(op.errf, api.Error(msg))
+					continue
+				}
+				seq := held.add(pos)
+				batch, ok := batches[key]
+				if !ok {
+					gen++
+					batch = &keyBatch{first: seq, gen: gen}
+					batches[key] = batch
+					order = append(order, key)
+					if op.maxWait > 0 {
+						expiry := keyBatchExpiry{key: key, gen: batch.gen}
+						batch.timer = time.AfterFunc(op.maxWait, func() {
+							select {
+							case expired <- expiry:
+							case <-exeCtx.Done():
+							}
+						})
+					}
+				}
+				batch.items = append(batch.items, item)
+				if op.maxCount > 0 && len(batch.items) >= op.maxCount {
+					if !flush(key) {
+						return
+					}
+				}
+
+			case expiry := <-expired:
+				// ignore timers of batches already flushed by count
+				if batch, ok := batches[expiry.key]; ok && batch.gen == expiry.gen {
+					if !flush(expiry.key) {
+						return
+					}
+				}
+
+			case <-exeCtx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,94 @@
+package window
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+)
+
+func TestKeyBatchOp_Exec(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- "a:1"
+		in <- "b:1"
+		in <- "a:2" // a flushed by count
+		in <- "a:3"
+		time.Sleep(60 * time.Millisecond) // b and a flushed by time
+		in <- "b:2"
+		close(in) // b flushed on close
+	}()
+
+	op := NewKeyBatch(func(item interface{}) interface{} {
+		return item.(string)[:1]
+	}, 2, 20*time.Millisecond)
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var batches []KeyBatch
+	for batch := range op.GetOutput() {
+		batches = append(batches, batch.(KeyBatch))
+	}
+
+	if len(batches) != 4 {
+		t.Fatal("expecting 4 batches, got", batches)
+	}
+	if batches[0].Key != "a" || len(batches[0].Items) != 2 {
+		t.Fatal("expecting batch of a flushed by count first, got", batches[0])
+	}
+	timed := map[interface{}]int{}
+	for _, batch := range batches[1:3] {
+		timed[batch.Key] = len(batch.Items)
+	}
+	if timed["a"] != 1 || timed["b"] != 1 {
+		t.Fatal("expecting a and b flushed by time, got", batches[1:3])
+	}
+	if batches[3].Key != "b" || batches[3].Items[0] != "b:2" {
+		t.Fatal("expecting open batch of b flushed on close, got", batches[3])
+	}
+}
+
+func TestKeyBatchOp_Exec_NoTrigger(t *testing.T) {
+	op := NewKeyBatch(func(item interface{}) interface{} { return item }, 0, 0)
+	op.SetInput(make(chan interface{}))
+	if err := op.Exec(context.Background()); err == nil {
+		t.Fatal("expecting error without max count and max wait")
+	}
+}
+
+func TestKeyBatchOp_Positions(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		for i, item := range []string{"a", "b", "b", "a", "c", "d", "c"} {
+			in <- api.StreamItem{Item: item, Position: i + 1}
+		}
+		close(in)
+	}()
+
+	op := NewKeyBatch(func(item interface{}) interface{} { return item }, 2, 0)
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var keys, positions []interface{}
+	for item := range op.GetOutput() {
+		batch, pos := api.UnwrapPosition(item)
+		keys = append(keys, batch.(KeyBatch).Key)
+		positions = append(positions, pos)
+	}
+
+	// a batch never carries a position past the first item of an open batch
+	expected := []struct{ key, pos interface{} }{{"b", nil}, {"a", 4}, {"c", 5}, {"d", 7}}
+	if len(keys) != len(expected) {
+		t.Fatal("unexpected batches", keys, positions)
+	}
+	for i, exp := range expected {
+		if keys[i] != exp.key || positions[i] != exp.pos {
+			t.Fatalf("batch %d: expecting key %v at position %v, got %v at %v", i, exp.key, exp.pos, keys[i], positions[i])
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package window
+
+import "math"
+
+// heldPositions holds back the source positions of items grouped by key
+// (i.e. sessions, key batches).  Groups close in any order, so a group may
+// only carry a position that no item of a group still open precedes,
+// otherwise positions would be committed (or in-flight slots released) past
+// items that are not delivered yet.  Each item is numbered as it arrives
+// and each group records the sequence of its first item, the positions of
+// items before the first item of the oldest open group are released with
+// the next group sent downstream.
+type heldPositions struct {
+	seq     int64 // sequence of the last item added
+	pending []seqPosition
+}
+
+type seqPosition struct {
+	seq int64
+	pos interface{}
+}
+
+// add numbers an item and holds its position, if any.  It returns the
+// sequence of the item.
+func (h *heldPositions) add(pos interface{}) int64 {
+	h.seq++
+	if pos != nil {
+		h.pending = append(h.pending, seqPosition{seq: h.seq, pos: pos})
+	}
+	return h.seq
+}
+
+// release drops the positions of items before oldest, the sequence of the
+// first item of the oldest open group, and returns the last of them (nil if
+// none).  Use noOpenGroup when all groups are closed.
+func (h *heldPositions) release(oldest int64) interface{} {
+	var pos interface{}
+	i := 0
+	for ; i < len(h.pending) && h.pending[i].seq < oldest; i++ {
+		pos = h.pending[i].pos
+	}
+	h.pending = h.pending[i:]
+	return pos
+}
+
+// noOpenGroup is passed to heldPositions.release when no group is open
+const noOpenGroup = int64(math.MaxInt64)
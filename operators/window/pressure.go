@@ -0,0 +1,148 @@
+package window
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// pressureWeight is the weight given to the latest send in the pressure
+const pressureWeight = 0.2
+
+// PressureStats reports the backpressure observed by a PressureOperator
+type PressureStats struct {
+	// Items is the number of items sent downstream
+	Items int64
+	// Blocked is the number of sends that waited on downstream
+	Blocked int64
+	// Pressure is the moving ratio (0 to 1) of sends that waited
+	Pressure float64
+	// Delay is the current delay applied before reading the next item
+	Delay time.Duration
+}
+
+// PressureOperator is an executor node that monitors how often sending an
+// item downstream blocks and exposes it as a pressure metric.  Before
+// reading the next item from upstream, the operator waits for a delay
+// proportional to the pressure (up to maxDelay) which slows the source
+// down to the speed of downstream.  The output of the operator is not
+// buffered so each send reflects the actual downstream speed.
+type PressureOperator struct {
+	maxDelay time.Duration
+	report   func(PressureStats)
+	input    <-chan interface{}
+	output   chan interface{}
+	logf     api.LogFunc
+
+	mutex sync.RWMutex
+	stats PressureStats
+}
+
+// NewPressure creates a *PressureOperator slowing upstream by up to maxDelay
+// (0 only monitors).  If report is not nil, it is called with the current
+// stats after each item is sent.
+func NewPressure(maxDelay time.Duration, report func(PressureStats)) *PressureOperator {
+	return &PressureOperator{
+		maxDelay: maxDelay,
+		report:   report,
+		output:   make(chan interface{}),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (op *PressureOperator) SetInput(in <-chan interface{}) {
+	op.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (op *PressureOperator) GetOutput() <-chan interface{} {
+	return op.output
+}
+
+// Stats returns the current pressure stats of the operator
+func (op *PressureOperator) Stats() PressureStats {
+	op.mutex.RLock()
+	defer op.mutex.RUnlock()
+	return op.stats
+}
+
+// update records a send and returns the updated stats
+func (op *PressureOperator) update(blocked bool) PressureStats {
+	op.mutex.Lock()
+	defer op.mutex.Unlock()
+	op.stats.Items++
+	sample := 0.0
+	if blocked {
+		op.stats.Blocked++
+		sample = 1.0
+	}
+	op.stats.Pressure = op.stats.Pressure*(1-pressureWeight) + sample*pressureWeight
+	op.stats.Delay = time.Duration(op.stats.Pressure * float64(op.maxDelay))
+	return op.stats
+}
+
+// Exec is the execution starting point for the operator node.
+func (op *PressureOperator) Exec(ctx context.Context) (err error) {
+	op.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(op.logf, "Pressure operator starting")
+
+	if op.input == nil {
+		err = fmt.Errorf("No input channel found")
+		return
+	}
+	if op.maxDelay < 0 {
+		err = fmt.Errorf("Pressure delay must not be negative")
+		return
+	}
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		defer func() {
+			util.Logfn(op.logf, "Closing pressure operator")
+			cancel()
+			close(op.output)
+		}()
+
+		for {
+			select {
+			case item, opened := <-op.input:
+				if !opened {
+					return
+				}
+				blocked := false
+				select {
+				case op.output <- item:
+				default:
+					blocked = true
+					select {
+					case op.output <- item:
+					case <-exeCtx.Done():
+						return
+					}
+				}
+
+				stats := op.update(blocked)
+				if op.report != nil {
+					op.report(stats)
+				}
+				if stats.Delay <= 0 {
+					continue
+				}
+				select {
+				case <-time.After(stats.Delay):
+				case <-exeCtx.Done():
+					return
+				}
+
+			case <-exeCtx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,76 @@
+package window
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPressureOp_SlowDownstream(t *testing.T) {
+	maxDelay := 2 * time.Millisecond
+	in := make(chan interface{})
+	var sent []time.Time
+	go func() {
+		for i := 0; i < 20; i++ {
+			in <- i
+			sent = append(sent, time.Now())
+		}
+		close(in)
+	}()
+
+	op := NewPressure(maxDelay, nil)
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	for range op.GetOutput() {
+		time.Sleep(3 * time.Millisecond) // slower than max delay
+		count++
+	}
+	if count != 20 {
+		t.Fatal("expecting all items, got", count)
+	}
+
+	stats := op.Stats()
+	if stats.Items != 20 || stats.Blocked < 18 {
+		t.Fatalf("expecting most sends to block, got %+v", stats)
+	}
+	if stats.Pressure < 0.9 || stats.Delay < maxDelay*9/10 {
+		t.Fatalf("expecting pressure to rise, got %+v", stats)
+	}
+
+	// source is paced at the speed of downstream
+	if gap := sent[19].Sub(sent[10]) / 9; gap < 3*time.Millisecond {
+		t.Fatal("expecting source to slow down, got items every", gap)
+	}
+}
+
+func TestPressureOp_Report(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+
+	var reports []PressureStats
+	op := NewPressure(0, func(stats PressureStats) { reports = append(reports, stats) })
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for range op.GetOutput() {
+	}
+
+	if len(reports) != 5 || reports[4].Items != 5 {
+		t.Fatal("unexpected reports", reports)
+	}
+	for _, stats := range reports {
+		if stats.Delay != 0 {
+			t.Fatal("expecting no delay without max delay, got", stats.Delay)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package window
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// SessionOperator is an executor node that groups incoming items, per key,
+// into sessions.  A session accumulates items for its key until no new item
+// is seen for the specified gap duration; the session items are then sent
+// downstream as []interface{} and the session is evicted. Open sessions are
+// flushed when the upstream closes.  Sessions of positioned items carry the
+// latest position not preceded by an item of another open session.
+type SessionOperator struct {
+	keyFn  func(interface{}) interface{}
+	gap    time.Duration
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+type session struct {
+	items []interface{}
+	first int64 // sequence of first item in session (see heldPositions)
+	gen   int
+	timer *time.Timer
+}
+
+type sessionExpiry struct {
+	key interface{}
+	gen int
+}
+
+// NewSession creates a *SessionOperator
+func NewSession(keyFn func(interface{}) interface{}, gap time.Duration) *SessionOperator {
+	op := new(SessionOperator)
+	op.keyFn = keyFn
+	op.gap = gap
+	op.output = make(chan interface{}, 1024)
+	return op
+}
+
+// SetInput sets the input channel for the executor node
+func (op *SessionOperator) SetInput(in <-chan interface{}) {
+	op.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (op *SessionOperator) GetOutput() <-chan interface{} {
+	return op.output
+}
+
+// Exec is the execution starting point for the operator node.
+func (op *SessionOperator) Exec(ctx context.Context) (err error) {
+	op.logf = autoctx.GetLogFunc(ctx)
+	op.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(op.logf, "Session window operator starting")
+
+	if op.input == nil {
+		err = fmt.Errorf("No input channel found")
+		return
+	}
+	if op.keyFn == nil || op.gap <= 0 {
+		err = fmt.Errorf("Session window requires a key function and a gap greater than zero")
+		return
+	}
+
+	go func() {
+		exeCtx, cancel := context.WithCancel(ctx)
+		sessions := make(map[interface{}]*session)
+		var order []interface{} // session keys, oldest first
+		var held heldPositions
+		expired := make(chan sessionExpiry)
+
+		emit := func(key interface{}) bool {
+			sess := sessions[key]
+			sess.timer.Stop()
+			delete(sessions, key)
+			for i, k := range order {
+				if k == key {
+					order = append(order[:i], order[i+1:]...)
+					break
+				}
+			}
+			oldest := noOpenGroup
+			if len(order) > 0 {
+				oldest = sessions[order[0]].first
+			}
+			select {
+			case op.output <- api.WithPosition(sess.items, held.release(oldest)):
+				return true
+			case <-exeCtx.Done():
+				return false
+			}
+		}
+
+		defer func() {
+			util.Logfn(op.logf, "Closing session window operator")
+			for len(order) > 0 {
+				if !emit(order[0]) {
+					break
+				}
+			}
+			cancel()
+			close(op.output)
+		}()
+
+		for {
+			select {
+			case item, opened := <-op.input:
+				if !opened {
+					return
+				}
+				item, pos := api.UnwrapPosition(item)
+				key := op.keyFn(item)
+				if key != nil && !reflect.TypeOf(key).Comparable() {
+					msg := fmt.Sprintf("session key of type %T is not comparable", key)
+					util.Logfn(op.logf, msg)
+					autoctx.Err(op.errf, api.Error(msg))
+					continue
+				}
+				seq := held.add(pos)
+				sess, ok := sessions[key]
+				if !ok {
+					sess = &session{first: seq}
+					sessions[key] = sess
+					order = append(order, key)
+				} else {
+					sess.timer.Stop()
+				}
+				sess.items = append(sess.items, item)
+				sess.gen++
+				expiry := sessionExpiry{key: key, gen: sess.gen}
+				sess.timer = time.AfterFunc(op.gap, func() {
+					select {
+					case expired <- expiry:
+					case <-exeCtx.Done():
+					}
+				})
+
+			case expiry := <-expired:
+				// ignore stale timers for sessions that were extended
+				if sess, ok := sessions[expiry.key]; ok && sess.gen == expiry.gen {
+					if !emit(expiry.key) {
+						return
+					}
+				}
+
+			case <-exeCtx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,89 @@
+package window
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+)
+
+func TestSessionOp_Exec(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- "a:1"
+		in <- "b:1"
+		in <- "a:2"
+		time.Sleep(60 * time.Millisecond) // gap elapses
+		in <- "a:3"
+		close(in)
+	}()
+
+	op := NewSession(func(item interface{}) interface{} {
+		return item.(string)[:1]
+	}, 20*time.Millisecond)
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var sessions [][]interface{}
+	for sess := range op.GetOutput() {
+		sessions = append(sessions, sess.([]interface{}))
+	}
+
+	if len(sessions) != 3 {
+		t.Fatal("expecting 3 sessions, got", sessions)
+	}
+	aSessions := 0
+	for _, sess := range sessions {
+		if sess[0].(string)[:1] == "a" {
+			aSessions++
+		}
+	}
+	if aSessions != 2 {
+		t.Fatal("expecting two sessions for key a, got", sessions)
+	}
+	if len(sessions[2]) != 1 || sessions[2][0] != "a:3" {
+		t.Fatal("unexpected trailing session", sessions[2])
+	}
+}
+
+func TestSessionOp_Positions(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- api.StreamItem{Item: "a:1", Position: 1}
+		in <- api.StreamItem{Item: "b:1", Position: 2}
+		in <- api.StreamItem{Item: "a:2", Position: 3}
+		time.Sleep(25 * time.Millisecond)
+		in <- api.StreamItem{Item: "a:3", Position: 4} // extends session a
+		time.Sleep(45 * time.Millisecond)              // session b expires
+		close(in)
+	}()
+
+	op := NewSession(func(item interface{}) interface{} {
+		return item.(string)[:1]
+	}, 50*time.Millisecond)
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var sessions, positions []interface{}
+	for item := range op.GetOutput() {
+		sess, pos := api.UnwrapPosition(item)
+		sessions = append(sessions, sess)
+		positions = append(positions, pos)
+	}
+
+	if len(sessions) != 2 || len(sessions[0].([]interface{})) != 1 {
+		t.Fatal("expecting session b then session a, got", sessions)
+	}
+	// session a is still open, session b cannot carry a position past a:1
+	if positions[0] != nil {
+		t.Fatal("expecting no position for session b, got", positions[0])
+	}
+	if positions[1] != 4 {
+		t.Fatal("expecting position 4 for session a, got", positions[1])
+	}
+}
@@ -0,0 +1,145 @@
+package window
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// ShuffleOperator is an executor node that approximates a shuffle of the
+// incoming items with bounded memory: items fill a reservoir of fixed
+// size, once it is full each new item replaces a randomly selected item
+// of the reservoir which is sent downstream.  The remaining items are sent
+// in random order when the upstream closes.  Positioned items are sent
+// with the latest position not preceded by an item still in the reservoir,
+// later positions are held back, so that positions stay in order downstream.
+type ShuffleOperator struct {
+	size   int
+	rng    *rand.Rand
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+// shuffled is an item of the reservoir along with its sequence (see
+// heldPositions)
+type shuffled struct {
+	data interface{}
+	seq  int64
+}
+
+// NewShuffle creates a *ShuffleOperator with a reservoir of size items
+// selected with rng.  If rng is nil, the operator uses the stream seed (see
+// autoctx.GetRand) or, without a seed, the current time.
+func NewShuffle(size int, rng *rand.Rand) *ShuffleOperator {
+	return &ShuffleOperator{
+		size:   size,
+		rng:    rng,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (op *ShuffleOperator) SetInput(in <-chan interface{}) {
+	op.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (op *ShuffleOperator) GetOutput() <-chan interface{} {
+	return op.output
+}
+
+// Exec is the execution starting point for the operator node.
+func (op *ShuffleOperator) Exec(ctx context.Context) (err error) {
+	op.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(op.logf, "Shuffle operator starting")
+
+	if op.input == nil {
+		err = fmt.Errorf("No input channel found")
+		return
+	}
+	if op.size <= 0 {
+		err = fmt.Errorf("Shuffle buffer size must be greater than zero")
+		return
+	}
+	if op.rng == nil {
+		op.rng = autoctx.GetRand(ctx)
+	}
+	if op.rng == nil {
+		op.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	go func() {
+		reservoir := make([]shuffled, 0, op.size)
+		var held heldPositions
+		var waiting []int64 // sequences of items in the reservoir, in order
+		sent := make(map[int64]bool)
+		exeCtx, cancel := context.WithCancel(ctx)
+
+		// send sends item, the position released is the last one before
+		// the oldest item still in the reservoir
+		send := func(item shuffled) bool {
+			sent[item.seq] = true
+			for len(waiting) > 0 && sent[waiting[0]] {
+				delete(sent, waiting[0])
+				waiting = waiting[1:]
+			}
+			oldest := noOpenGroup
+			if len(waiting) > 0 {
+				oldest = waiting[0]
+			}
+			select {
+			case op.output <- api.WithPosition(item.data, held.release(oldest)):
+				return true
+			case <-exeCtx.Done():
+				return false
+			}
+		}
+
+		defer func() {
+			util.Logfn(op.logf, "Closing shuffle operator")
+			// push remaining items in random order
+			op.rng.Shuffle(len(reservoir), func(i, j int) {
+				reservoir[i], reservoir[j] = reservoir[j], reservoir[i]
+			})
+			for _, item := range reservoir {
+				if !send(item) {
+					break
+				}
+			}
+			cancel()
+			close(op.output)
+		}()
+
+		for {
+			select {
+			case item, opened := <-op.input:
+				if !opened {
+					return
+				}
+				data, pos := api.UnwrapPosition(item)
+				entry := shuffled{data: data, seq: held.add(pos)}
+				waiting = append(waiting, entry.seq)
+				if len(reservoir) < op.size {
+					reservoir = append(reservoir, entry)
+					continue
+				}
+				i := op.rng.Intn(op.size)
+				selected := reservoir[i]
+				reservoir[i] = entry
+				if !send(selected) {
+					return
+				}
+
+			case <-exeCtx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
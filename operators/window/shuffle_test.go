@@ -0,0 +1,77 @@
+package window
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestShuffleOp_Exec(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			in <- i
+		}
+		close(in)
+	}()
+
+	op := NewShuffle(10, rand.New(rand.NewSource(42)))
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var result []int
+	for item := range op.GetOutput() {
+		result = append(result, item.(int))
+	}
+	if len(result) != 100 {
+		t.Fatal("expecting all items, got", len(result))
+	}
+
+	inOrder := true
+	for i, item := range result {
+		if item != i {
+			inOrder = false
+		}
+	}
+	if inOrder {
+		t.Fatal("expecting items to be shuffled")
+	}
+	sort.Ints(result)
+	for i, item := range result {
+		if item != i {
+			t.Fatal("expecting a permutation of the input, got", result)
+		}
+	}
+}
+
+func TestShuffleOp_Deterministic(t *testing.T) {
+	run := func() []interface{} {
+		in := make(chan interface{})
+		go func() {
+			for i := 0; i < 20; i++ {
+				in <- i
+			}
+			close(in)
+		}()
+		op := NewShuffle(5, rand.New(rand.NewSource(7)))
+		op.SetInput(in)
+		if err := op.Exec(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		var result []interface{}
+		for item := range op.GetOutput() {
+			result = append(result, item)
+		}
+		return result
+	}
+
+	first, second := run(), run()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatal("expecting same order with same seed", first, second)
+		}
+	}
+}
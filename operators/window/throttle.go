@@ -0,0 +1,101 @@
+package window
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// ThrottleLatestOperator is an executor node that samples incoming items:
+// once per interval, the most recent item received during the interval is
+// sent downstream and intermediate items are dropped.  Intervals with no
+// new items send nothing.  A pending item is sent when the upstream closes.
+type ThrottleLatestOperator struct {
+	interval time.Duration
+	input    <-chan interface{}
+	output   chan interface{}
+	logf     api.LogFunc
+}
+
+// NewThrottleLatest creates a *ThrottleLatestOperator for the interval
+func NewThrottleLatest(interval time.Duration) *ThrottleLatestOperator {
+	return &ThrottleLatestOperator{
+		interval: interval,
+		output:   make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (op *ThrottleLatestOperator) SetInput(in <-chan interface{}) {
+	op.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (op *ThrottleLatestOperator) GetOutput() <-chan interface{} {
+	return op.output
+}
+
+// Exec is the execution starting point for the operator node.
+func (op *ThrottleLatestOperator) Exec(ctx context.Context) (err error) {
+	op.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(op.logf, "ThrottleLatest operator starting")
+
+	if op.input == nil {
+		err = fmt.Errorf("No input channel found")
+		return
+	}
+	if op.interval <= 0 {
+		err = fmt.Errorf("Throttle interval must be greater than zero")
+		return
+	}
+
+	go func() {
+		var latest interface{}
+		pending := false
+		exeCtx, cancel := context.WithCancel(ctx)
+		ticker := time.NewTicker(op.interval)
+
+		defer func() {
+			util.Logfn(op.logf, "Closing ThrottleLatest operator")
+			ticker.Stop()
+			// push pending item
+			if pending {
+				select {
+				case op.output <- latest:
+				case <-exeCtx.Done():
+				}
+			}
+			cancel()
+			close(op.output)
+		}()
+
+		for {
+			select {
+			case item, opened := <-op.input:
+				if !opened {
+					return
+				}
+				latest, pending = item, true
+
+			case <-ticker.C:
+				if !pending {
+					continue
+				}
+				select {
+				case op.output <- latest:
+					latest, pending = nil, false
+				case <-exeCtx.Done():
+					return
+				}
+
+			case <-exeCtx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
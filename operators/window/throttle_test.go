@@ -0,0 +1,57 @@
+package window
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestThrottleLatestOp_Exec(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		for burst := 0; burst < 2; burst++ {
+			for i := 0; i < 100; i++ {
+				in <- burst*100 + i
+			}
+			time.Sleep(60 * time.Millisecond)
+		}
+		close(in)
+	}()
+
+	op := NewThrottleLatest(40 * time.Millisecond)
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var items []interface{}
+	for item := range op.GetOutput() {
+		items = append(items, item)
+	}
+	if len(items) != 2 || items[0] != 99 || items[1] != 199 {
+		t.Fatal("expecting the latest item of each burst, got", items)
+	}
+}
+
+func TestThrottleLatestOp_FlushOnClose(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- 1
+		in <- 2
+		close(in)
+	}()
+
+	op := NewThrottleLatest(time.Hour)
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var items []interface{}
+	for item := range op.GetOutput() {
+		items = append(items, item)
+	}
+	if len(items) != 1 || items[0] != 2 {
+		t.Fatal("expecting pending item flushed on close, got", items)
+	}
+}
@@ -0,0 +1,130 @@
+package window
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// TriggerFunc decides when a window closes.  It receives the incoming item
+// and the items accumulated so far (which include the incoming item); the
+// window is closed and sent downstream when it returns true.
+type TriggerFunc func(item interface{}, accumulated []interface{}) bool
+
+// TriggerOperator is an executor node that groups incoming items into
+// windows (as []interface{}) closed by a trigger function, i.e. windows
+// punctuated by a sentinel item.  A trailing window is sent downstream
+// when the upstream closes.
+type TriggerOperator struct {
+	trigger TriggerFunc
+	split   bool // boundary items are excluded from windows
+	input   <-chan interface{}
+	output  chan interface{}
+	logf    api.LogFunc
+}
+
+// NewTrigger creates a *TriggerOperator closing windows with trigger
+func NewTrigger(trigger TriggerFunc) *TriggerOperator {
+	return &TriggerOperator{
+		trigger: trigger,
+		output:  make(chan interface{}, 1024),
+	}
+}
+
+// NewSplitOn creates a *TriggerOperator which closes the current window
+// when isBoundary returns true for an item.  Boundary items are dropped,
+// they are not part of any window, and empty windows (i.e. consecutive
+// boundaries) are not sent downstream.
+func NewSplitOn(isBoundary func(interface{}) bool) *TriggerOperator {
+	op := NewTrigger(func(item interface{}, _ []interface{}) bool { return isBoundary(item) })
+	if isBoundary == nil {
+		op.trigger = nil
+	}
+	op.split = true
+	return op
+}
+
+// SetInput sets the input channel for the executor node
+func (op *TriggerOperator) SetInput(in <-chan interface{}) {
+	op.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (op *TriggerOperator) GetOutput() <-chan interface{} {
+	return op.output
+}
+
+// Exec is the execution starting point for the operator node.
+func (op *TriggerOperator) Exec(ctx context.Context) (err error) {
+	op.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(op.logf, "Trigger window operator starting")
+
+	if op.input == nil {
+		err = fmt.Errorf("No input channel found")
+		return
+	}
+	if op.trigger == nil {
+		err = fmt.Errorf("Window trigger function not provided")
+		return
+	}
+
+	go func() {
+		var items []interface{}
+		var winPos interface{} // position of last item in window
+		exeCtx, cancel := context.WithCancel(ctx)
+
+		defer func() {
+			util.Logfn(op.logf, "Closing trigger window operator")
+			// push trailing window
+			if len(items) > 0 {
+				select {
+				case op.output <- api.WithPosition(items, winPos):
+				case <-exeCtx.Done():
+				}
+			}
+			cancel()
+			close(op.output)
+		}()
+
+		for {
+			select {
+			case item, opened := <-op.input:
+				if !opened {
+					return
+				}
+				item, pos := api.UnwrapPosition(item)
+				if pos != nil {
+					winPos = pos
+				}
+				if op.split {
+					if !op.trigger(item, items) {
+						items = append(items, item)
+						continue
+					}
+					if len(items) == 0 {
+						continue
+					}
+				} else {
+					items = append(items, item)
+					if !op.trigger(item, items) {
+						continue
+					}
+				}
+				select {
+				case op.output <- api.WithPosition(items, winPos):
+					items = nil
+					winPos = nil
+				case <-exeCtx.Done():
+					return
+				}
+
+			case <-exeCtx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
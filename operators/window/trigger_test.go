@@ -0,0 +1,84 @@
+package window
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTriggerOp_Exec(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		for _, item := range []string{"a", "b", "END", "END", "c", "d", "END", "e"} {
+			in <- item
+		}
+		close(in)
+	}()
+
+	op := NewTrigger(func(item interface{}, _ []interface{}) bool { return item == "END" })
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var windows [][]interface{}
+	for win := range op.GetOutput() {
+		windows = append(windows, win.([]interface{}))
+	}
+	expected := [][]string{{"a", "b", "END"}, {"END"}, {"c", "d", "END"}, {"e"}}
+	if len(windows) != len(expected) {
+		t.Fatal("unexpected windows", windows)
+	}
+	for i, win := range windows {
+		if len(win) != len(expected[i]) {
+			t.Fatal("unexpected window", win)
+		}
+		for j := range win {
+			if win[j] != expected[i][j] {
+				t.Fatal("unexpected window", win)
+			}
+		}
+	}
+}
+
+func TestTriggerOp_NoTrigger(t *testing.T) {
+	op := NewTrigger(nil)
+	op.SetInput(make(chan interface{}))
+	if err := op.Exec(context.Background()); err == nil {
+		t.Fatal("expecting error for missing trigger")
+	}
+}
+
+func TestTriggerOp_SplitOn(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		for _, item := range []string{"a", "b", "|", "|", "c", "|", "d", "e"} {
+			in <- item
+		}
+		close(in)
+	}()
+
+	op := NewSplitOn(func(item interface{}) bool { return item == "|" })
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var groups [][]interface{}
+	for group := range op.GetOutput() {
+		groups = append(groups, group.([]interface{}))
+	}
+	expected := [][]string{{"a", "b"}, {"c"}, {"d", "e"}}
+	if len(groups) != len(expected) {
+		t.Fatal("unexpected groups", groups)
+	}
+	for i, group := range groups {
+		if len(group) != len(expected[i]) {
+			t.Fatal("unexpected group", group)
+		}
+		for j := range group {
+			if group[j] != expected[i][j] {
+				t.Fatal("unexpected group", group)
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package window
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// Run is a sequence of consecutive items sharing the same key, Value is
+// the first item of the run and Count the number of items in the run.
+type Run struct {
+	Value interface{}
+	Count int64
+}
+
+// UniqOperator is an executor node that collapses consecutive items with
+// the same key into a single Run (like uniq -c). Only the current run is
+// kept in memory, it is sent downstream when an item with a different key
+// arrives or when the upstream closes.
+type UniqOperator struct {
+	keyFn  func(interface{}) interface{}
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+	errf   api.ErrorFunc
+}
+
+// NewUniq creates a *UniqOperator, runs are keyed by keyFn (the item
+// itself if keyFn is nil).  Keys are compared with ==, items with a key
+// that is not comparable are dropped and reported on the error path.
+func NewUniq(keyFn func(interface{}) interface{}) *UniqOperator {
+	return &UniqOperator{
+		keyFn:  keyFn,
+		output: make(chan interface{}, 1024),
+	}
+}
+
+// SetInput sets the input channel for the executor node
+func (op *UniqOperator) SetInput(in <-chan interface{}) {
+	op.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (op *UniqOperator) GetOutput() <-chan interface{} {
+	return op.output
+}
+
+// Exec is the execution starting point for the operator node.
+func (op *UniqOperator) Exec(ctx context.Context) (err error) {
+	op.logf = autoctx.GetLogFunc(ctx)
+	op.errf = autoctx.GetErrFunc(ctx)
+	util.Logfn(op.logf, "Uniq operator starting")
+
+	if op.input == nil {
+		err = fmt.Errorf("No input channel found")
+		return
+	}
+
+	go func() {
+		var run *Run
+		var runKey interface{}
+		var runPos interface{} // position of last item in run
+		exeCtx, cancel := context.WithCancel(ctx)
+
+		defer func() {
+			util.Logfn(op.logf, "Closing uniq operator")
+			// push trailing run
+			if run != nil {
+				select {
+				case op.output <- api.WithPosition(*run, runPos):
+				case <-exeCtx.Done():
+				}
+			}
+			cancel()
+			close(op.output)
+		}()
+
+		for {
+			select {
+			case item, opened := <-op.input:
+				if !opened {
+					return
+				}
+				item, pos := api.UnwrapPosition(item)
+				key := item
+				if op.keyFn != nil {
+					key = op.keyFn(item)
+				}
+				if key != nil && !reflect.TypeOf(key).Comparable() {
+					msg := fmt.Sprintf("uniq key of type %T is not comparable", key)
+					util.Logfn(op.logf, msg)
+					autoctx.Err(op.errf, api.Error(msg))
+					continue
+				}
+
+				if run != nil && runKey == key {
+					run.Count++
+					if pos != nil {
+						runPos = pos
+					}
+					continue
+				}
+				if run != nil {
+					select {
+					case op.output <- api.WithPosition(*run, runPos):
+					case <-exeCtx.Done():
+						return
+					}
+				}
+				run, runKey, runPos = &Run{Value: item, Count: 1}, key, pos
+
+			case <-exeCtx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
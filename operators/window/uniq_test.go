@@ -0,0 +1,59 @@
+package window
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestUniqOp_Exec(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		for _, item := range []string{"a", "A", "b", "b", "b", "a", "c", "C"} {
+			in <- item
+		}
+		close(in)
+	}()
+
+	op := NewUniq(func(item interface{}) interface{} { return strings.ToLower(item.(string)) })
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var runs []Run
+	for item := range op.GetOutput() {
+		runs = append(runs, item.(Run))
+	}
+	expected := []Run{{"a", 2}, {"b", 3}, {"a", 1}, {"c", 2}}
+	if len(runs) != len(expected) {
+		t.Fatal("unexpected runs", runs)
+	}
+	for i := range expected {
+		if runs[i] != expected[i] {
+			t.Fatalf("expecting run %v, got %v", expected[i], runs[i])
+		}
+	}
+}
+
+func TestUniqOp_NonComparable(t *testing.T) {
+	in := make(chan interface{})
+	go func() {
+		in <- []int{1}
+		in <- 2
+		close(in)
+	}()
+
+	op := NewUniq(nil)
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var runs []Run
+	for item := range op.GetOutput() {
+		runs = append(runs, item.(Run))
+	}
+	if len(runs) != 1 || runs[0] != (Run{2, 1}) {
+		t.Fatal("unexpected runs", runs)
+	}
+}
@@ -0,0 +1,130 @@
+package window
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// WindowOperator is an executor node that groups incoming items into
+// tumbling (fixed-size, non-overlapping) event-time windows.  Windows are
+// aligned to their size and are emitted, as []interface{}, when an item
+// belonging to a later window arrives or when the upstream closes.
+type WindowOperator struct {
+	size   time.Duration
+	timeFn func(interface{}) time.Time
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+// New creates a *WindowOperator for windows of the specified size
+func New(size time.Duration) *WindowOperator {
+	op := new(WindowOperator)
+	op.size = size
+	op.output = make(chan interface{}, 1024)
+	return op
+}
+
+// SetTimeFunc sets the function used to extract the event time of items.
+// By default, the time of api.TimedItem values is used (and the item is
+// unwrapped), other items are stamped with their arrival time.
+func (op *WindowOperator) SetTimeFunc(f func(interface{}) time.Time) {
+	op.timeFn = f
+}
+
+// SetInput sets the input channel for the executor node
+func (op *WindowOperator) SetInput(in <-chan interface{}) {
+	op.input = in
+}
+
+// GetOutput returns the output channel of the executer node
+func (op *WindowOperator) GetOutput() <-chan interface{} {
+	return op.output
+}
+
+// eventTime returns the event time and data of item
+func (op *WindowOperator) eventTime(item interface{}) (time.Time, interface{}) {
+	if op.timeFn != nil {
+		return op.timeFn(item), item
+	}
+	if timed, ok := item.(api.TimedItem); ok {
+		return timed.Time, timed.Item
+	}
+	return time.Now(), item
+}
+
+// Exec is the execution starting point for the operator node.
+func (op *WindowOperator) Exec(ctx context.Context) (err error) {
+	op.logf = autoctx.GetLogFunc(ctx)
+	util.Logfn(op.logf, "Window operator starting")
+
+	if op.input == nil {
+		err = fmt.Errorf("No input channel found")
+		return
+	}
+	if op.size <= 0 {
+		err = fmt.Errorf("Window size must be greater than zero")
+		return
+	}
+
+	go func() {
+		var items []interface{}
+		var winPos interface{} // position of last item in window
+		var winStart time.Time
+		exeCtx, cancel := context.WithCancel(ctx)
+
+		defer func() {
+			util.Logfn(op.logf, "Closing window operator")
+			// push trailing window
+			if len(items) > 0 {
+				select {
+				case op.output <- api.WithPosition(items, winPos):
+				case <-exeCtx.Done():
+				}
+			}
+			cancel()
+			close(op.output)
+		}()
+
+		for {
+			select {
+			case item, opened := <-op.input:
+				if !opened {
+					return
+				}
+				// positioned items are windowed unwrapped, the window
+				// carries the position of its last item.
+				item, pos := api.UnwrapPosition(item)
+				stamp, data := op.eventTime(item)
+				start := stamp.Truncate(op.size)
+
+				// item belongs to a later window, emit current one
+				if len(items) > 0 && start.After(winStart) {
+					select {
+					case op.output <- api.WithPosition(items, winPos):
+						items = nil
+						winPos = nil
+					case <-exeCtx.Done():
+						return
+					}
+				}
+				if len(items) == 0 {
+					winStart = start
+				}
+				items = append(items, data)
+				if pos != nil {
+					winPos = pos
+				}
+
+			case <-exeCtx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,48 @@
+package window
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+)
+
+func TestWindowOp_Exec(t *testing.T) {
+	start := time.Unix(0, 0)
+	in := make(chan interface{})
+	go func() {
+		for i, sec := range []int{0, 1, 2, 5, 9, 10} {
+			in <- api.TimedItem{Time: start.Add(time.Duration(sec) * time.Second), Item: i}
+		}
+		close(in)
+	}()
+
+	op := New(5 * time.Second)
+	op.SetInput(in)
+	if err := op.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var sizes []int
+	for win := range op.GetOutput() {
+		sizes = append(sizes, len(win.([]interface{})))
+	}
+	if len(sizes) != 3 || sizes[0] != 3 || sizes[1] != 2 || sizes[2] != 1 {
+		t.Fatal("unexpected window sizes", sizes)
+	}
+}
+
+func TestWindowFunc_AssignTimestamps(t *testing.T) {
+	start := time.Unix(100, 0)
+	f := AssignTimestampsFunc(start, time.Second)
+	for i := 0; i < 3; i++ {
+		item := f(context.Background(), i).(api.TimedItem)
+		if !item.Time.Equal(start.Add(time.Duration(i) * time.Second)) {
+			t.Fatal("unexpected timestamp", item.Time)
+		}
+		if item.Item != i {
+			t.Fatal("unexpected item", item.Item)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package sketch
+
+import (
+	"math"
+)
+
+// BloomFilter tests whether keys were probably added to it, with bounded
+// memory.  A key that was added is always reported as present, a key that
+// was not added is reported as present with a small probability (a false
+// positive).
+//
+// For n expected keys and a false positive rate p, the filter uses
+//   m = ceil(-n * ln(p) / ln(2)^2)  bits
+//   k = round(m / n * ln(2))        hash functions
+// (i.e. about 9.6 bits per key for p = 1%).  The false positive rate grows
+// beyond p when more than n keys are added.
+type BloomFilter struct {
+	bits []uint64
+	m    uint64
+	k    int
+}
+
+// NewBloomFilter creates a *BloomFilter sized for expectedN keys with the
+// false positive rate fpRate (0 < fpRate < 1, defaults to 1%).
+func NewBloomFilter(expectedN int, fpRate float64) *BloomFilter {
+	if expectedN < 1 {
+		expectedN = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	n := float64(expectedN)
+	m := uint64(math.Ceil(-n * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := int(math.Round(float64(m) / n * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &BloomFilter{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// Size returns the number of bits and hash functions of the filter
+func (f *BloomFilter) Size() (m uint64, k int) {
+	return f.m, f.k
+}
+
+// Add adds the key to the filter and returns true if the key was probably
+// added before.  The key must be a comparable value.
+func (f *BloomFilter) Add(key interface{}) bool {
+	h1, h2 := f.hashes(key)
+	present := true
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % f.m
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if f.bits[word]&mask == 0 {
+			present = false
+			f.bits[word] |= mask
+		}
+	}
+	return present
+}
+
+// Test returns true if the key was probably added to the filter
+func (f *BloomFilter) Test(key interface{}) bool {
+	h1, h2 := f.hashes(key)
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % f.m
+		if f.bits[bit/64]&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hashes derives the two hashes combined (double hashing)
+// to obtain the k bit positions of key
+func (f *BloomFilter) hashes(key interface{}) (uint64, uint64) {
+	h1 := Hash(key)
+	h2 := mix64(h1^0x9e3779b97f4a7c15) | 1 // odd, so positions differ
+	return h1, h2
+}
@@ -0,0 +1,41 @@
+package sketch
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBloomFilter_Size(t *testing.T) {
+	m, k := NewBloomFilter(1000, 0.01).Size()
+	if m != 9586 || k != 7 {
+		t.Fatal("unexpected filter size", m, k)
+	}
+}
+
+func TestBloomFilter_FalsePositiveRate(t *testing.T) {
+	n, rate := 10000, 0.01
+	f := NewBloomFilter(n, rate)
+	for i := 0; i < n; i++ {
+		if f.Add(fmt.Sprintf("key-%d", i)) && i < 10 {
+			t.Fatal("unexpected duplicate for key", i)
+		}
+	}
+	for i := 0; i < n; i++ {
+		if !f.Test(fmt.Sprintf("key-%d", i)) {
+			t.Fatal("added key reported missing", i)
+		}
+		if !f.Add(fmt.Sprintf("key-%d", i)) {
+			t.Fatal("added key not reported as duplicate", i)
+		}
+	}
+
+	falsePositives := 0
+	for i := n; i < 2*n; i++ {
+		if f.Test(fmt.Sprintf("key-%d", i)) {
+			falsePositives++
+		}
+	}
+	if measured := float64(falsePositives) / float64(n); measured > 1.5*rate {
+		t.Fatalf("false positive rate %.4f exceeds %.4f", measured, rate)
+	}
+}
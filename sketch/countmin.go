@@ -0,0 +1,183 @@
+package sketch
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// CountMin estimates the number of times keys were added to it, with
+// bounded memory.  Estimates are never lower than the true counts, and for
+// a total count N, exceed them by at most epsilon*N with probability
+// 1-delta.
+//
+// The sketch uses
+//   w = ceil(e / epsilon)     counters per row
+//   d = ceil(ln(1 / delta))   rows (hash functions)
+// (i.e. 2719 x 5 counters, about 106KB, for epsilon = 0.001, delta = 1%).
+type CountMin struct {
+	rows  [][]uint64
+	w     uint64
+	total uint64
+}
+
+// NewCountMin creates a *CountMin with the error bound epsilon and the
+// failure probability delta (0 < epsilon, delta < 1, default to 0.001 and 1%)
+func NewCountMin(epsilon, delta float64) *CountMin {
+	if epsilon <= 0 || epsilon >= 1 {
+		epsilon = 0.001
+	}
+	if delta <= 0 || delta >= 1 {
+		delta = 0.01
+	}
+	w := uint64(math.Ceil(math.E / epsilon))
+	d := int(math.Ceil(math.Log(1 / delta)))
+	rows := make([][]uint64, d)
+	for i := range rows {
+		rows[i] = make([]uint64, w)
+	}
+	return &CountMin{rows: rows, w: w}
+}
+
+// Size returns the number of counters per row and the number of rows
+func (c *CountMin) Size() (w uint64, d int) {
+	return c.w, len(c.rows)
+}
+
+// Total returns the sum of the counts added to the sketch
+func (c *CountMin) Total() uint64 {
+	return c.total
+}
+
+// Add adds count occurrences of key and returns the new estimate for key.
+// The key must be a comparable value.
+func (c *CountMin) Add(key interface{}, count uint64) uint64 {
+	h1, h2 := c.hashes(key)
+	estimate := uint64(math.MaxUint64)
+	for i, row := range c.rows {
+		idx := (h1 + uint64(i)*h2) % c.w
+		row[idx] += count
+		if row[idx] < estimate {
+			estimate = row[idx]
+		}
+	}
+	c.total += count
+	return estimate
+}
+
+// Estimate returns the estimated count of key
+func (c *CountMin) Estimate(key interface{}) uint64 {
+	h1, h2 := c.hashes(key)
+	estimate := uint64(math.MaxUint64)
+	for i, row := range c.rows {
+		if val := row[(h1+uint64(i)*h2)%c.w]; val < estimate {
+			estimate = val
+		}
+	}
+	return estimate
+}
+
+// hashes derives the two hashes combined (double hashing)
+// to obtain the counter of key in each row
+func (c *CountMin) hashes(key interface{}) (uint64, uint64) {
+	h1 := Hash(key)
+	h2 := mix64(h1^0x9e3779b97f4a7c15) | 1
+	return h1, h2
+}
+
+// Hitter is a key along with its estimated count
+type Hitter struct {
+	Key   interface{}
+	Count uint64
+}
+
+// HeavyHitters tracks the capacity most frequent keys added to it, using a
+// CountMin sketch for the counts and a heap of the top keys, so that memory
+// is bounded regardless of the number of distinct keys.  The reported
+// counts are CountMin estimates (see CountMin for their error bounds).  A
+// key occurring more than N/capacity + epsilon*N times, out of N keys
+// added, is reported with probability 1-delta, less frequent keys may be
+// reported in place of keys with close counts.
+type HeavyHitters struct {
+	counts   *CountMin
+	capacity int
+	top      hitterHeap
+	index    map[interface{}]*hitterEntry
+}
+
+type hitterEntry struct {
+	Hitter
+	pos int // position in heap
+}
+
+// NewHeavyHitters creates a *HeavyHitters tracking the capacity most
+// frequent keys, with count estimates bounded by epsilon and delta
+// (see NewCountMin)
+func NewHeavyHitters(capacity int, epsilon, delta float64) *HeavyHitters {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &HeavyHitters{
+		counts:   NewCountMin(epsilon, delta),
+		capacity: capacity,
+		index:    make(map[interface{}]*hitterEntry),
+	}
+}
+
+// Add adds an occurrence of key.  The key must be a comparable value.
+func (h *HeavyHitters) Add(key interface{}) {
+	count := h.counts.Add(key, 1)
+	if entry, ok := h.index[key]; ok {
+		entry.Count = count
+		heap.Fix(&h.top, entry.pos)
+		return
+	}
+	if len(h.top) < h.capacity {
+		entry := &hitterEntry{Hitter: Hitter{Key: key, Count: count}}
+		h.index[key] = entry
+		heap.Push(&h.top, entry)
+		return
+	}
+	// replace the least frequent of the top keys
+	if min := h.top[0]; count > min.Count {
+		delete(h.index, min.Key)
+		min.Key, min.Count = key, count
+		h.index[key] = min
+		heap.Fix(&h.top, 0)
+	}
+}
+
+// Top returns the tracked keys, most frequent first
+func (h *HeavyHitters) Top() []Hitter {
+	result := make([]Hitter, len(h.top))
+	for i, entry := range h.top {
+		result[i] = entry.Hitter
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Count > result[j].Count
+	})
+	return result
+}
+
+// hitterHeap is a min-heap of entries by count
+type hitterHeap []*hitterEntry
+
+func (h hitterHeap) Len() int           { return len(h) }
+func (h hitterHeap) Less(i, j int) bool { return h[i].Count < h[j].Count }
+func (h hitterHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos, h[j].pos = i, j
+}
+
+func (h *hitterHeap) Push(x interface{}) {
+	entry := x.(*hitterEntry)
+	entry.pos = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *hitterHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
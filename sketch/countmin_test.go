@@ -0,0 +1,70 @@
+package sketch
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCountMin_Size(t *testing.T) {
+	w, d := NewCountMin(0.001, 0.01).Size()
+	if w != 2719 || d != 5 {
+		t.Fatal("unexpected sketch size", w, d)
+	}
+}
+
+func TestCountMin_Estimate(t *testing.T) {
+	epsilon := 0.001
+	c := NewCountMin(epsilon, 0.01)
+	counts := make(map[string]uint64)
+	for i := 0; i < 100000; i++ {
+		key := fmt.Sprintf("key-%d", i%5000)
+		if i%7 == 0 {
+			key = "hot"
+		}
+		counts[key]++
+		c.Add(key, 1)
+	}
+
+	bound := uint64(epsilon * float64(c.Total()))
+	overBound := 0
+	for key, count := range counts {
+		estimate := c.Estimate(key)
+		if estimate < count {
+			t.Fatalf("estimate %d of %s lower than count %d", estimate, key, count)
+		}
+		if estimate-count > bound {
+			overBound++
+		}
+	}
+	if rate := float64(overBound) / float64(len(counts)); rate > 0.01 {
+		t.Fatalf("%.4f of estimates exceed the error bound", rate)
+	}
+}
+
+func TestHeavyHitters_Top(t *testing.T) {
+	h := NewHeavyHitters(3, 0, 0)
+	for i := 0; i < 30000; i++ {
+		switch {
+		case i%4 == 0:
+			h.Add("a") // 25%
+		case i%5 == 0:
+			h.Add("b") // 15%
+		case i%10 == 1:
+			h.Add("c") // 10%
+		default:
+			h.Add(i) // unique keys
+		}
+	}
+
+	top := h.Top()
+	if len(top) != 3 {
+		t.Fatal("expecting 3 heavy hitters, got", top)
+	}
+	bound := uint64(0.001 * 30000)
+	expected := []Hitter{{"a", 7500}, {"b", 4500}, {"c", 3000}}
+	for i, hitter := range expected {
+		if top[i].Key != hitter.Key || top[i].Count < hitter.Count || top[i].Count > hitter.Count+bound {
+			t.Fatalf("expecting heavy hitter %v, got %v", hitter, top[i])
+		}
+	}
+}
@@ -0,0 +1,3 @@
+// Package sketch provides probabilistic data structures used to
+// summarize very large streams with bounded memory.
+package sketch
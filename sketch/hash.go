@@ -0,0 +1,107 @@
+package sketch
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"math"
+	"reflect"
+)
+
+// Hash returns a 64-bit hash of key.  Strings and byte slices are hashed
+// by content, other keys by their type and a canonical encoding of their
+// value: pointers (and channels) by identity, structs and arrays field by
+// field, so distinct keys with the same printed form (i.e. two pointers to
+// equal structs) hash apart.
+func Hash(key interface{}) uint64 {
+	h := fnv.New64a()
+	switch k := key.(type) {
+	case string:
+		h.Write([]byte(k))
+	case []byte:
+		h.Write(k)
+	case nil:
+	default:
+		val := reflect.ValueOf(key)
+		io.WriteString(h, val.Type().String())
+		writeValue(h, val)
+	}
+	return mix64(h.Sum64())
+}
+
+// writeValue writes the canonical encoding of val to h
+func writeValue(h hash.Hash64, val reflect.Value) {
+	var buf [8]byte
+	writeUint := func(u uint64) {
+		binary.LittleEndian.PutUint64(buf[:], u)
+		h.Write(buf[:])
+	}
+	writeFloat := func(f float64) {
+		if f == 0 {
+			f = 0 // -0 == +0
+		}
+		writeUint(math.Float64bits(f))
+	}
+
+	switch val.Kind() {
+	case reflect.Bool:
+		if val.Bool() {
+			writeUint(1)
+		} else {
+			writeUint(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint(uint64(val.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint(val.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeFloat(val.Float())
+	case reflect.Complex64, reflect.Complex128:
+		writeFloat(real(val.Complex()))
+		writeFloat(imag(val.Complex()))
+	case reflect.String:
+		writeUint(uint64(val.Len()))
+		io.WriteString(h, val.String())
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		writeUint(uint64(val.Pointer()))
+	case reflect.Interface:
+		if val.IsNil() {
+			writeUint(0)
+			return
+		}
+		io.WriteString(h, val.Elem().Type().String())
+		writeValue(h, val.Elem())
+	case reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			writeValue(h, val.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < val.NumField(); i++ {
+			writeValue(h, val.Field(i))
+		}
+	default:
+		// not comparable (i.e. slices, maps), see Hashable
+		fmt.Fprint(h, val)
+	}
+}
+
+// mix64 improves bit dispersion of fnv hashes (murmur3 finalizer)
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// Hashable returns true if key can be used as a sketch key (a
+// comparable value such as a string, a number, or a struct of those).
+func Hashable(key interface{}) bool {
+	if key == nil {
+		return true
+	}
+	return reflect.TypeOf(key).Comparable()
+}
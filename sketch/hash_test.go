@@ -0,0 +1,35 @@
+package sketch
+
+import (
+	"math"
+	"testing"
+)
+
+type hashPoint struct {
+	X, Y int
+	tag  string
+}
+
+func TestHash(t *testing.T) {
+	p0, p1 := &hashPoint{X: 1, Y: 2}, &hashPoint{X: 1, Y: 2}
+	tests := []struct {
+		name   string
+		k0, k1 interface{}
+		same   bool
+	}{
+		{name: "equal strings", k0: "a", k1: "a", same: true},
+		{name: "equal structs", k0: hashPoint{1, 2, "a"}, k1: hashPoint{1, 2, "a"}, same: true},
+		{name: "unexported field", k0: hashPoint{1, 2, "a"}, k1: hashPoint{1, 2, "b"}},
+		{name: "same pointer", k0: p0, k1: p0, same: true},
+		{name: "distinct pointers", k0: p0, k1: p1},
+		{name: "types", k0: 1, k1: int64(1)},
+		{name: "printed form", k0: [2]string{"a b", "c"}, k1: [2]string{"a", "b c"}},
+		{name: "zero floats", k0: 0.0, k1: math.Copysign(0, -1), same: true},
+		{name: "interfaces", k0: [1]interface{}{1}, k1: [1]interface{}{"1"}},
+	}
+	for _, test := range tests {
+		if same := Hash(test.k0) == Hash(test.k1); same != test.same {
+			t.Errorf("%s: expecting same hash %t for %v and %v", test.name, test.same, test.k0, test.k1)
+		}
+	}
+}
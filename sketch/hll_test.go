@@ -0,0 +1,35 @@
+package sketch
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestCardinality(t *testing.T) {
+	tests := []struct {
+		name     string
+		distinct int
+		maxErr   float64
+	}{
+		{name: "exact", distinct: 500, maxErr: 0},
+		{name: "estimate", distinct: 50000, maxErr: 0.03},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewCardinality()
+			for i := 0; i < test.distinct; i++ {
+				c.Add(fmt.Sprintf("key-%d", i))
+				c.Add(fmt.Sprintf("key-%d", i)) // duplicates are not counted
+			}
+			relErr := math.Abs(float64(c.Count())-float64(test.distinct)) / float64(test.distinct)
+			if relErr > test.maxErr {
+				t.Fatalf("count %d too far from %d (error %.4f)", c.Count(), test.distinct, relErr)
+			}
+			if (test.maxErr == 0) != c.Exact() {
+				t.Fatal("unexpected exact mode", c.Exact())
+			}
+		})
+	}
+}
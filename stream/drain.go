@@ -44,7 +44,11 @@ func (s *Drain) Open(ctx context.Context) <-chan error {
 			close(result)
 		}()
 		for data := range s.input {
-			s.output <- data
+			select {
+			case s.output <- data:
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 	return result
@@ -2,9 +2,12 @@ package stream
 
 import (
 	"context"
+	"runtime"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/vladimirvivien/automi/testutil"
 )
 
 func TestDrain_Open(t *testing.T) {
@@ -50,3 +53,22 @@ func TestDrain_Open(t *testing.T) {
 	}
 	m.RUnlock()
 }
+
+func TestDrain_Cancel(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	in := make(chan interface{})
+	d := NewDrain()
+	d.SetInput(in)
+	d.Open(ctx)
+	// fill the drain output without reading it
+	for i := 0; i < cap(d.output)+1; i++ {
+		in <- i
+	}
+
+	cancel()
+	if !testutil.WaitGoroutines(baseline, time.Second) {
+		t.Fatal("drain goroutine leaked after cancel")
+	}
+}
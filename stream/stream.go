@@ -12,6 +12,7 @@ import (
 	"github.com/vladimirvivien/automi/collectors"
 	"github.com/vladimirvivien/automi/emitters"
 	streamop "github.com/vladimirvivien/automi/operators/stream"
+	"github.com/vladimirvivien/automi/operators/unary"
 	"github.com/vladimirvivien/automi/util"
 )
 
@@ -25,8 +26,22 @@ type Stream struct {
 	drain    chan error
 	ops      []api.Operator
 	ctx      context.Context
+	userCtx  context.Context
 	logf     api.LogFunc
 	errf     api.ErrorFunc
+	recovery *recovery
+	inflight *inflight
+	stages   *stageMonitor
+	delayed  *delayedErrors
+	shutdown *shutdownMonitor
+	marshal  api.MarshalErrorPolicy
+	errMaps  []func(api.StreamError) api.StreamError
+	seed     *int64
+	values   map[interface{}]interface{}
+	coalesce *coalescedErrors
+	name     string
+	rejects  *deadLetters
+	profiler *profiler
 }
 
 // New creates a new *Stream value
@@ -40,9 +55,37 @@ func New(src interface{}) *Stream {
 	return s
 }
 
+// ConcatFallback creates a new *Stream sourced from the primary emitter
+// which, if the primary fails before completing, continues with the emitter
+// returned by fallback (i.e. a cache or a backup file).  Items emitted by
+// the primary before it failed are kept.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/emitters"#ConcatFallback
+func ConcatFallback(primary api.Emitter, fallback func(err error) api.Emitter) *Stream {
+	return New(emitters.ConcatFallback(primary, fallback))
+}
+
 // WithContext sets a context.Context to use.
 func (s *Stream) WithContext(ctx context.Context) *Stream {
 	s.ctx = ctx
+	s.userCtx = ctx
+	return s
+}
+
+// WithContextValues sets several values on the stream context at once
+// (i.e. shared services or configuration), operators receive them in the
+// context passed to their functions.  The values are added to the context
+// set with WithContext, if any, when the stream is opened.  Successive
+// calls add to the values already set.
+func (s *Stream) WithContextValues(values map[interface{}]interface{}) *Stream {
+	if s.values == nil {
+		s.values = make(map[interface{}]interface{}, len(values))
+	}
+	for key, val := range values {
+		s.values[key] = val
+	}
 	return s
 }
 
@@ -53,6 +96,15 @@ func (s *Stream) WithLogFunc(fn api.LogFunc) *Stream {
 	return s
 }
 
+// WithName names the stream, to attribute its log events and errors when
+// several streams run in the same application.  Log messages are prefixed
+// with "[name] " and errors reported to the error path carry the name in
+// the MetaData["stream"] of their item.
+func (s *Stream) WithName(name string) *Stream {
+	s.name = name
+	return s
+}
+
 // WithErrorFunc sets a function of type func(StreamError) that will be
 // invoked when an operator indicates it wants to signal an error by
 // defining an operator function of the form func(data)error.
@@ -61,6 +113,25 @@ func (s *Stream) WithErrorFunc(fn api.ErrorFunc) *Stream {
 	return s
 }
 
+// WithMarshalErrorPolicy sets how the built-in emitters and collectors of
+// the stream handle items they fail to decode or encode (i.e. a malformed
+// CSV record): the failure is reported on the error path and the item
+// dropped (api.MarshalError, the default), the item is dropped silently
+// (api.MarshalSkip) or the failure is reported along with the malformed
+// item (api.MarshalDeadLetter) so the error function can route it aside.
+func (s *Stream) WithMarshalErrorPolicy(policy api.MarshalErrorPolicy) *Stream {
+	s.marshal = policy
+	return s
+}
+
+// WithSeed sets the seed of the randomized operators of the stream (i.e.
+// Shuffle) so that, for a given seed, they produce the same output on each
+// run.  Each operator draws from its own source seeded with seed.
+func (s *Stream) WithSeed(seed int64) *Stream {
+	s.seed = &seed
+	return s
+}
+
 // From sets the stream source to use
 //func (s *Stream) From(src api.StreamSource) *Stream {
 //	s.source = src
@@ -73,6 +144,22 @@ func (s *Stream) Into(snk interface{}) *Stream {
 	return s
 }
 
+// Sink returns the terminal sink of the stream so that its results can be
+// read once the stream completes, i.e.
+//   strm.Sink().(*collectors.SliceCollector).Get()
+// This includes sinks created by the stream for an Into parameter that is
+// not an api.Sink (such as a slice, a file name or an io.Writer), which are
+// available once the stream is opened.  It returns nil if no sink is set yet.
+func (s *Stream) Sink() api.Sink {
+	if s.sink != nil {
+		return s.sink
+	}
+	if snk, ok := s.snkParam.(api.Sink); ok {
+		return snk
+	}
+	return nil
+}
+
 // ReStream takes upstream items of types []slice []array, map[T]
 // and emmits their elements as individual channel items to downstream
 // operations.  Items of other types are ignored.
@@ -82,6 +169,32 @@ func (s *Stream) ReStream() *Stream {
 	return s
 }
 
+// AddOperator splices a user-provided operator into the stream at the
+// current position.  The operator must implement api.Operator:
+//   SetInput(<-chan interface{}) - receives the upstream channel
+//   GetOutput() <-chan interface{} - returns the channel read downstream
+//   Exec(context.Context) error - starts processing (without blocking)
+// Exec is expected to process items in a goroutine, to stop when the
+// context is done, and to close its output once its input is closed.
+// The stream log and error functions can be retrieved from the context
+// passed to Exec (see package "github.com/vladimirvivien/automi/api/context").
+//
+// When the source sets positions (checkpointing) or the stream uses
+// WithMaxInFlight, items arrive as api.StreamItem values carrying a
+// Position.  The operator should unwrap them with api.UnwrapPosition and
+// carry the position onto its results with api.WithPosition, otherwise
+// positions are not committed and in-flight slots are never released:
+//   data, pos := api.UnwrapPosition(item)
+//   out <- api.WithPosition(process(data), pos)
+func (s *Stream) AddOperator(op api.Operator) *Stream {
+	if op == nil {
+		s.drainErr(errors.New("stream operator is nil"))
+		return s
+	}
+	s.ops = append(s.ops, op)
+	return s
+}
+
 // Open opens the Stream which executes all operators nodes.
 // If there's an issue prior to execution, an error is returned
 // in the error channel.
@@ -93,27 +206,91 @@ func (s *Stream) Open() <-chan error {
 		return s.drain
 	}
 
+	if err := s.setupRecovery(); err != nil {
+		s.drainErr(err)
+		return s.drain
+	}
+
 	util.Logfn(s.logf, "Opening stream")
 
+	if s.stages != nil {
+		var cancel context.CancelFunc
+		s.ctx, cancel = context.WithCancel(s.ctx)
+		s.stages.start(s.ctx, cancel, s.logf)
+	}
+
+	// operators can stop the source once they need no more items
+	srcCtx, stopSource := context.WithCancel(s.ctx)
+	s.ctx = autoctx.WithSourceStop(s.ctx, stopSource)
+
+	// stages report errors to the dead letter sink, if any
+	stageCtx := func(ctx context.Context, stage int, node interface{}) context.Context {
+		if s.rejects == nil {
+			return ctx
+		}
+		return s.rejects.stageContext(ctx, stageName(stage, node), s.errf)
+	}
+	if s.rejects != nil {
+		s.rejects.open(s.ctx)
+	}
+
 	// open stream
 	go func() {
-		// open source, if err bail
-		if err := s.source.Open(s.ctx); err != nil {
+		defer stopSource()
+		// bail with err, closing the dead letter sink
+		bail := func(err error) {
+			if s.rejects != nil {
+				s.rejects.close()
+			}
 			s.drainErr(err)
+		}
+		// open source, if err bail
+		if err := s.source.Open(stageCtx(srcCtx, 0, s.source)); err != nil {
+			bail(err)
 			return
 		}
 		//apply operators, if err bail
+		stage := 0
 		for _, op := range s.ops {
-			if err := op.Exec(s.ctx); err != nil {
-				s.drainErr(err)
+			ctx := s.ctx
+			if !isProbe(op) {
+				stage++
+				ctx = stageCtx(s.ctx, stage, op)
+			}
+			if err := op.Exec(ctx); err != nil {
+				bail(err)
 				return
 			}
 		}
 
 		// open stream sink, after log sink is ready.
+		sinkDone := s.sink.Open(stageCtx(s.ctx, stage+1, s.sink))
+		if s.shutdown != nil {
+			sinkDone = s.shutdownResult(sinkDone)
+		}
 		select {
-		case err := <-s.sink.Open(s.ctx):
+		case err := <-sinkDone:
 			util.Logfn(s.logf, "Closing stream")
+			if s.profiler != nil {
+				s.profiler.done(len(s.profiler.stages) - 1)
+			}
+			if s.stages != nil && err == nil {
+				err = s.stages.failure()
+			}
+			if s.recovery != nil && err == nil {
+				s.recovery.flush()
+			}
+			if s.coalesce != nil {
+				s.coalesce.flush()
+			}
+			if s.delayed != nil && err == nil {
+				err = s.delayed.result()
+			}
+			if s.rejects != nil {
+				if rejectErr := s.rejects.close(); err == nil {
+					err = rejectErr
+				}
+			}
 			s.drain <- err
 		}
 	}()
@@ -127,8 +304,28 @@ func (s *Stream) prepareContext() {
 	if s.ctx == nil {
 		s.ctx = context.TODO()
 	}
+	for key, val := range s.values {
+		s.ctx = context.WithValue(s.ctx, key, val)
+	}
+	if s.delayed != nil {
+		s.errf = s.delayed.errorFunc(s.errf)
+	}
+	if s.coalesce != nil {
+		s.errf = s.coalesce.errorFunc(s.errf)
+	}
+	if len(s.errMaps) > 0 {
+		s.errf = mapErrorFunc(s.errMaps, s.errf)
+	}
+	if s.name != "" {
+		s.logf = namedLogFunc(s.name, s.logf)
+		s.errf = namedErrorFunc(s.name, s.errf)
+	}
 	s.ctx = autoctx.WithLogFunc(s.ctx, s.logf)
 	s.ctx = autoctx.WithErrorFunc(s.ctx, s.errf)
+	s.ctx = autoctx.WithMarshalErrorPolicy(s.ctx, s.marshal)
+	if s.seed != nil {
+		s.ctx = autoctx.WithSeed(s.ctx, *s.seed)
+	}
 }
 
 // bindOps binds operator channels
@@ -160,6 +357,38 @@ func (s *Stream) initGraph() error {
 		return err
 	}
 
+	// unbatch items of emitters sending batches, unary operators
+	// process batched items on their own unless probes feed them
+	probed := s.profiler != nil || s.inflight != nil || s.stages != nil || s.shutdown != nil
+	if emitter, ok := s.source.(api.BatchEmitter); ok && emitter.EmitsBatches() {
+		if len(s.ops) == 0 || probed || !isUnaryOp(s.ops[0]) {
+			s.ops = append([]api.Operator{streamop.Unbatch()}, s.ops...)
+		}
+	}
+
+	// the operators inserted below are probes, they are not numbered as
+	// stages (see isProbe)
+
+	// profile each operator and the sink
+	if s.profiler != nil {
+		s.ops = s.profiler.watch(s.ops, s.sink)
+	}
+
+	// bound in-flight items between source and sink
+	if s.inflight != nil {
+		s.ops = s.inflight.bracket(s.ops)
+	}
+
+	// watch each stage for stalls
+	if s.stages != nil {
+		s.ops = s.stages.watch(s.source, s.ops)
+	}
+
+	// watch each stage for draining on shutdown
+	if s.shutdown != nil {
+		s.ops = s.shutdown.watch(s.source, s.ops)
+	}
+
 	// if there are no ops, link source to sink
 	if len(s.ops) == 0 && s.sink != nil {
 		util.Logfn(s.logf, "No operators in stream, binding source to sink directly")
@@ -178,6 +407,24 @@ func (s *Stream) initGraph() error {
 	return nil
 }
 
+func isUnaryOp(op api.Operator) bool {
+	_, ok := op.(*unary.UnaryOperator)
+	return ok
+}
+
+// probe is implemented by the operators inserted by the stream to observe
+// or pace its stages (profiling, in-flight bound, stage monitors)
+type probe interface {
+	probe()
+}
+
+// isProbe returns true if op was inserted by the stream, probes are not
+// stages: they are skipped when stages are numbered and named
+func isProbe(op api.Operator) bool {
+	_, ok := op.(probe)
+	return ok
+}
+
 // setupSource checks the source, setup the proper type or return err if problem
 func (s *Stream) setupSource() error {
 	if s.srcParam == nil {
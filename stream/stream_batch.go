@@ -1,6 +1,8 @@
 package stream
 
 import (
+	"errors"
+
 	"github.com/vladimirvivien/automi/api"
 	"github.com/vladimirvivien/automi/operators/batch"
 	"github.com/vladimirvivien/automi/operators/unary"
@@ -144,6 +146,30 @@ func (s *Stream) Sum() *Stream {
 	return s.appendOp(operator)
 }
 
+// SumBigInt is similar to Sum for batched integers, the sum is computed
+// with a big.Int so it cannot overflow.  The operator returns an int64 if
+// the sum fits, otherwise a *big.Int.
+//
+// See Also
+//
+// See also the operator function BigIntSumFunc in
+//   "github.com/vladimirvivien/automi/operators/batch"
+func (s *Stream) SumBigInt() *Stream {
+	return s.Transform(batch.BigIntSumFunc())
+}
+
+// SumBigFloat is similar to Sum, the sum is computed with a big.Float so
+// large totals stay exact.  The operator returns a float64 if the sum
+// converts exactly, otherwise a *big.Float.
+//
+// See Also
+//
+// See also the operator function BigFloatSumFunc in
+//   "github.com/vladimirvivien/automi/operators/batch"
+func (s *Stream) SumBigFloat() *Stream {
+	return s.Transform(batch.BigFloatSumFunc())
+}
+
 // SumByKey sums up numeric items that are batched as []map[K]V or
 // []map[K][]V where key specifies a K value that returns a V or a []V that
 // is a numeric (or a slice of) value of type integer or floating
@@ -204,3 +230,64 @@ func (s *Stream) appendOp(operator api.Operator) *Stream {
 	s.ops = append(s.ops, operator)
 	return s
 }
+
+// DistinctCountPerBatch counts the distinct keys, returned by keyFn, of
+// items batched (or windowed) as []T and sends the count, as uint64,
+// downstream for each batch.  If keyFn is nil, items are used as their
+// own keys.
+//
+// See Also
+//
+// See the batch operator function DistinctCountFunc in
+//   "github.com/vladimirvivien/automi/operators/batch"
+func (s *Stream) DistinctCountPerBatch(keyFn func(interface{}) interface{}) *Stream {
+	return s.Transform(batch.DistinctCountFunc(keyFn))
+}
+
+// DistinctByKey deduplicates the items of each batch (i.e. from Batch,
+// BatchBySize or WindowByTime) by the key returned by keyFn: only the first
+// item of each key within the batch is kept and the batch is sent
+// downstream as []interface{}.  Unlike a global distinct, keys seen in a
+// batch (or window) are forgotten at the next one.  If keyFn is nil, items
+// are used as their own keys.
+//
+// See Also
+//
+// See the batch operator function DistinctByKeyFunc in
+//   "github.com/vladimirvivien/automi/operators/batch"
+func (s *Stream) DistinctByKey(keyFn func(interface{}) interface{}) *Stream {
+	return s.Transform(batch.DistinctByKeyFunc(keyFn))
+}
+
+// MapPartition applies the user-provided function f to each batch (i.e. from
+// Batch, BatchBySize, or WindowByTime), as []interface{}, in a single call to
+// amortize per-item overhead (i.e. a batch inference API).  The batch
+// returned by f is sent downstream as is, and may be of a different length
+// than the input batch.  Use ReStream afterward to unbatch the result.
+//
+// See Also
+//
+// See the batch operator function MapPartitionFunc in
+//   "github.com/vladimirvivien/automi/operators/batch"
+func (s *Stream) MapPartition(f func([]interface{}) []interface{}) *Stream {
+	if f == nil {
+		s.drainErr(errors.New("map partition function is nil"))
+		return s
+	}
+	return s.Transform(batch.MapPartitionFunc(f))
+}
+
+// Debatch sends each element of batched items (i.e. from Batch, BatchBySize,
+// or WindowByTime) downstream individually as a batch.Item, which records the
+// index of the element's batch and its index within the batch.  Unlike
+// ReStream, the provenance of elements is retained (i.e. for error reporting
+// after batch processing).
+//
+// See Also
+//
+// See the batch operator function DebatchFunc in
+//   "github.com/vladimirvivien/automi/operators/batch"
+func (s *Stream) Debatch() *Stream {
+	s.Transform(batch.DebatchFunc())
+	return s.ReStream()
+}
@@ -1,11 +1,14 @@
 package stream
 
 import (
+	"math"
+	"math/big"
 	"testing"
 	"time"
 
 	"github.com/vladimirvivien/automi/collectors"
 	"github.com/vladimirvivien/automi/emitters"
+	"github.com/vladimirvivien/automi/operators/batch"
 )
 
 func TestStream_GroupByKey(t *testing.T) {
@@ -330,3 +333,115 @@ func TestStream_SumByPos(t *testing.T) {
 		t.Fatal("Took too long")
 	}
 }
+
+func TestStream_DistinctCountPerBatch(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"a", "b", "a", "c", "c", "c"})).
+		BatchBySize(3).
+		DistinctCountPerBatch(nil).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	result := snk.Get()
+	if len(result) != 2 || result[0].(uint64) != 2 || result[1].(uint64) != 1 {
+		t.Fatal("unexpected per batch counts", result)
+	}
+}
+
+func TestStream_MapPartition(t *testing.T) {
+	snk := collectors.Slice()
+	calls := 0
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5})).
+		BatchBySize(2).
+		MapPartition(func(batch []interface{}) []interface{} {
+			calls++
+			result := make([]interface{}, len(batch))
+			for i, item := range batch {
+				result[i] = item.(int) * 2
+			}
+			return result
+		}).
+		ReStream().
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	result := snk.Get()
+	if len(result) != 5 || result[0] != 2 || result[4] != 10 {
+		t.Fatal("unexpected result", result)
+	}
+	if calls != 3 {
+		t.Fatal("expecting one call per batch, got", calls)
+	}
+}
+
+func TestStream_Debatch(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"a", "b", "c", "d", "e"})).
+		BatchBySize(3).
+		Debatch().
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	expected := []batch.Item{
+		{BatchIndex: 0, ItemIndex: 0, Value: "a"},
+		{BatchIndex: 0, ItemIndex: 1, Value: "b"},
+		{BatchIndex: 0, ItemIndex: 2, Value: "c"},
+		{BatchIndex: 1, ItemIndex: 0, Value: "d"},
+		{BatchIndex: 1, ItemIndex: 1, Value: "e"},
+	}
+	result := snk.Get()
+	if len(result) != len(expected) {
+		t.Fatal("unexpected result", result)
+	}
+	for i := range expected {
+		if result[i].(batch.Item) != expected[i] {
+			t.Fatalf("item %d: expecting %v, got %v", i, expected[i], result[i])
+		}
+	}
+}
+
+func TestStream_SumBigInt(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int64{math.MaxInt64, math.MaxInt64, 10})).
+		Batch().
+		SumBigInt().
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	result := snk.Get()
+	if len(result) != 1 || result[0].(*big.Int).String() != "18446744073709551624" {
+		t.Fatal("unexpected sum", result)
+	}
+}
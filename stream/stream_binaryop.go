@@ -1,6 +1,16 @@
 package stream
 
-import "github.com/vladimirvivien/automi/operators/binary"
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vladimirvivien/automi/api"
+	"github.com/vladimirvivien/automi/api/tuple"
+	"github.com/vladimirvivien/automi/operators/batch"
+	"github.com/vladimirvivien/automi/operators/binary"
+	"github.com/vladimirvivien/automi/sketch"
+)
 
 // Reduce accumulates and reduces items from upstream into a
 // single value using the initial seed value and the reduction
@@ -22,3 +32,121 @@ func (s *Stream) Reduce(seed, f interface{}) *Stream {
 	s.ops = append(s.ops, operator)
 	return s
 }
+
+// DistinctCount counts the distinct keys, returned by keyFn, of items
+// streamed from upstream and sends the count (as uint64) downstream when the
+// upstream closes.  If keyFn is nil, items are used as their own keys.
+// Small cardinalities are counted exactly, beyond that the count is an
+// estimate (about 1% error) computed with a bounded-memory HyperLogLog
+// sketch.  Items with keys that are not hashable are sent to the error path.
+// To count distinct keys per window, see DistinctCountPerBatch.
+//
+// See Also
+//
+// See the binary operator function DistinctCountFunc in
+//   "github.com/vladimirvivien/automi/operators/binary"
+func (s *Stream) DistinctCount(keyFn func(interface{}) interface{}) *Stream {
+	operator := binary.New()
+	operator.SetOperation(binary.DistinctCountFunc(keyFn))
+	operator.SetInitialState(sketch.NewCardinality())
+	s.ops = append(s.ops, operator)
+	return s.Transform(api.UnFunc(func(ctx context.Context, item interface{}) interface{} {
+		return item.(*sketch.Cardinality).Count()
+	}))
+}
+
+// HeavyHitters tracks the capacity most frequent keys, returned by keyFn,
+// of items streamed from upstream and, when the upstream closes, sends
+// them downstream as tuple.KV{key, count} (count as uint64), most frequent
+// first.  If keyFn is nil, items are used as their own keys.  Memory is
+// bounded regardless of the number of distinct keys: counts are estimated
+// with a count-min sketch, they are never lower than the true counts and,
+// for N items, exceed them by at most 0.1% of N with a 99% probability.
+// Keys occurring more than N/capacity (+ 0.1% of N) times are reported, with
+// the same probability.  Items with keys that are not hashable are sent to
+// the error path.
+//
+// See Also
+//
+// See the binary operator function HeavyHittersFunc in
+//   "github.com/vladimirvivien/automi/operators/binary"
+func (s *Stream) HeavyHitters(keyFn func(interface{}) interface{}, capacity int) *Stream {
+	if capacity < 1 {
+		s.drainErr(fmt.Errorf("heavy hitters capacity must be greater than zero, got %d", capacity))
+		return s
+	}
+	operator := binary.New()
+	operator.SetOperation(binary.HeavyHittersFunc(keyFn, capacity))
+	operator.SetInitialState(sketch.NewHeavyHitters(capacity, 0, 0))
+	s.ops = append(s.ops, operator)
+	return s.Transform(api.UnFunc(func(ctx context.Context, item interface{}) interface{} {
+		top := item.(*sketch.HeavyHitters).Top()
+		result := make([]tuple.KV, len(top))
+		for i, hitter := range top {
+			result[i] = tuple.KV{hitter.Key, hitter.Count}
+		}
+		return result
+	})).ReStream()
+}
+
+// GroupByKeyReduce groups items from upstream by the key returned by keyFn
+// and folds the items of each group with reduceFn (the accumulator is nil
+// for the first item of a group).  When the upstream closes, the result of
+// each group is sent downstream as tuple.KV{key, accumulator} ordered by
+// key.  The combineFn merges the accumulators of a key and is used when
+// groups are spilled to disk, to do so add an operator configured with a
+// spill threshold instead:
+//   s.AddOperator(binary.GroupReduce(keyFn, reduceFn, combineFn).SpillThreshold(n))
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/binary"#GroupReduce
+func (s *Stream) GroupByKeyReduce(
+	keyFn func(interface{}) interface{},
+	reduceFn func(acc, item interface{}) interface{},
+	combineFn func(acc0, acc1 interface{}) interface{},
+) *Stream {
+	return s.appendOp(binary.GroupReduce(keyFn, reduceFn, combineFn))
+}
+
+// GroupCountMode selects what GroupByCountEmitting sends downstream
+type GroupCountMode int
+
+const (
+	// CountPerKey sends each distinct key with its count, as tuple.KV{key, int64}
+	CountPerKey GroupCountMode = iota
+	// CountPerItem sends each item with the count of its key, as
+	// tuple.KV{item, int64}. All items are held in memory until the
+	// upstream closes.
+	CountPerItem
+)
+
+// GroupByCount counts items from upstream per key returned by keyFn (the
+// streaming GROUP BY key COUNT(*)).  When the upstream closes, each distinct
+// key is sent downstream with its count as tuple.KV{key, int64} ordered by
+// key.  For large key spaces, counts can be spilled to disk by adding an
+// operator configured with a spill threshold instead:
+//   s.AddOperator(binary.GroupCount(keyFn).SpillThreshold(n))
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/binary"#GroupCount
+func (s *Stream) GroupByCount(keyFn func(interface{}) interface{}) *Stream {
+	return s.GroupByCountEmitting(keyFn, CountPerKey)
+}
+
+// GroupByCountEmitting is similar to GroupByCount with the specified mode:
+// CountPerKey sends each key with its count, while CountPerItem annotates
+// each item with the count of its key once the upstream closes (use
+// Batch().Transform(batch.CountAnnotateFunc(keyFn)) to count per batch).
+func (s *Stream) GroupByCountEmitting(keyFn func(interface{}) interface{}, mode GroupCountMode) *Stream {
+	if keyFn == nil {
+		s.drainErr(errors.New("group by count key function is nil"))
+		return s
+	}
+	if mode == CountPerItem {
+		s.Batch().Transform(batch.CountAnnotateFunc(keyFn))
+		return s.ReStream()
+	}
+	return s.appendOp(binary.GroupCount(keyFn))
+}
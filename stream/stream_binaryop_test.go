@@ -1,9 +1,12 @@
 package stream
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
+	"github.com/vladimirvivien/automi/api"
+	"github.com/vladimirvivien/automi/api/tuple"
 	"github.com/vladimirvivien/automi/collectors"
 	"github.com/vladimirvivien/automi/emitters"
 )
@@ -29,3 +32,177 @@ func TestStream_Reduce(t *testing.T) {
 		t.Fatal("Took too long")
 	}
 }
+
+func TestStream_DistinctCount(t *testing.T) {
+	snk := collectors.Slice()
+	var errs []api.StreamError
+	strm := New(emitters.Slice([]interface{}{"a", "b", "a", []int{1}, "c", "b"})).
+		DistinctCount(nil).
+		WithErrorFunc(func(err api.StreamError) {
+			errs = append(errs, err)
+		}).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	result := snk.Get()
+	if len(result) != 1 || result[0].(uint64) != 3 {
+		t.Fatal("unexpected distinct count", result)
+	}
+	if len(errs) != 1 {
+		t.Fatal("expecting one error for non-hashable key, got", len(errs))
+	}
+}
+
+func TestStream_DistinctCount_Estimate(t *testing.T) {
+	var data []int
+	for i := 0; i < 20000; i++ {
+		data = append(data, i%10000)
+	}
+	snk := collectors.Slice()
+	strm := New(emitters.Slice(data)).DistinctCount(func(item interface{}) interface{} {
+		return item
+	}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Took too long")
+	}
+
+	count := float64(snk.Get()[0].(uint64))
+	if count < 9700 || count > 10300 {
+		t.Fatal("estimate outside expected error bound:", count)
+	}
+}
+
+func TestStream_HeavyHitters(t *testing.T) {
+	// a few status codes dominate a long tail of request paths
+	var data []string
+	for i := 0; i < 20000; i++ {
+		switch {
+		case i%2 == 0:
+			data = append(data, "200")
+		case i%5 == 1:
+			data = append(data, "404")
+		default:
+			data = append(data, fmt.Sprintf("/path/%d", i))
+		}
+	}
+
+	snk := collectors.Slice()
+	strm := New(emitters.Slice(data)).HeavyHitters(nil, 2).Into(snk)
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Took too long")
+	}
+
+	result := snk.Get()
+	if len(result) != 2 {
+		t.Fatal("expecting 2 heavy hitters, got", result)
+	}
+	bound := uint64(0.001 * float64(len(data)))
+	expected := []tuple.KV{{"200", uint64(10000)}, {"404", uint64(2000)}}
+	for i, kv := range expected {
+		hitter := result[i].(tuple.KV)
+		count := hitter[1].(uint64)
+		if hitter[0] != kv[0] || count < kv[1].(uint64) || count > kv[1].(uint64)+bound {
+			t.Fatalf("expecting heavy hitter %v within %d, got %v", kv, bound, hitter)
+		}
+	}
+}
+
+func TestStream_GroupByKeyReduce(t *testing.T) {
+	snk := collectors.Slice()
+	count := func(acc, item interface{}) interface{} {
+		if acc == nil {
+			return 1
+		}
+		return acc.(int) + 1
+	}
+	strm := New(emitters.Slice([]string{"b", "a", "b", "c", "b"})).
+		GroupByKeyReduce(func(item interface{}) interface{} { return item }, count, func(a, b interface{}) interface{} {
+			return a.(int) + b.(int)
+		}).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	result := snk.Get()
+	if len(result) != 3 || result[0].(tuple.KV)[0] != "a" || result[1].(tuple.KV)[1] != 3 {
+		t.Fatal("unexpected groups", result)
+	}
+}
+
+func TestStream_GroupByCount(t *testing.T) {
+	words := []string{"b", "a", "b", "c", "b", "a"}
+	tests := []struct {
+		name     string
+		mode     GroupCountMode
+		expected []tuple.KV
+	}{
+		{
+			name:     "per key",
+			mode:     CountPerKey,
+			expected: []tuple.KV{{"a", int64(2)}, {"b", int64(3)}, {"c", int64(1)}},
+		},
+		{
+			name: "per item",
+			mode: CountPerItem,
+			expected: []tuple.KV{
+				{"b", int64(3)}, {"a", int64(2)}, {"b", int64(3)},
+				{"c", int64(1)}, {"b", int64(3)}, {"a", int64(2)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			snk := collectors.Slice()
+			strm := New(emitters.Slice(words)).
+				GroupByCountEmitting(func(item interface{}) interface{} { return item }, test.mode).
+				Into(snk)
+
+			select {
+			case err := <-strm.Open():
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(50 * time.Millisecond):
+				t.Fatal("Took too long")
+			}
+
+			result := snk.Get()
+			if len(result) != len(test.expected) {
+				t.Fatal("unexpected counts", result)
+			}
+			for i, kv := range test.expected {
+				got := result[i].(tuple.KV)
+				if got[0] != kv[0] || got[1] != kv[1] {
+					t.Fatal("unexpected counts", result)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/vladimirvivien/automi/api"
+	"github.com/vladimirvivien/automi/collectors"
+)
+
+// GroupByKeyInto routes each streamed item into a sink dedicated to the
+// item's key, as returned by keyFn.  Sinks are created lazily, with newSink,
+// the first time a key is seen and are all closed when the stream completes.
+// This is the streaming counterpart of Batch().GroupByKey(...) and sets the
+// stream sink (there is no need to call Into).  To bound the number of
+// simultaneously open sinks, use collectors.Partition(...).MaxOpen(n) with Into.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/collectors"#Partition
+func (s *Stream) GroupByKeyInto(keyFn func(interface{}) interface{}, newSink func(key interface{}) api.Sink) *Stream {
+	return s.Into(collectors.Partition(keyFn, newSink))
+}
+
+// OnEach opens the stream and calls f, from a single goroutine, with the
+// zero-based index of each item streamed to the end of the stream along
+// with the item itself.  It blocks until the stream completes and sets the
+// stream sink (there is no need to call Into).  If f returns an error, the
+// stream is cancelled and the error is returned, otherwise OnEach returns
+// the error from the stream or from ctx, if any.
+func (s *Stream) OnEach(ctx context.Context, f func(i int64, item interface{}) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	exeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var index int64
+	var failed error
+	s.WithContext(exeCtx).Into(collectors.Func(func(item interface{}) error {
+		if failed != nil {
+			return nil // draining after cancellation
+		}
+		if err := f(index, item); err != nil {
+			failed = err
+			cancel()
+			return nil
+		}
+		index++
+		return nil
+	}))
+
+	err := <-s.Open()
+	switch {
+	case failed != nil:
+		return failed
+	case err != nil:
+		return err
+	}
+	return ctx.Err()
+}
+
+// ToChannel opens the stream and returns a channel of the items streamed
+// to the end of the stream, for the caller to range over, along with a
+// channel which receives the error from the stream, if any.  Both channels
+// are closed once the stream completes or ctx is done, the caller must
+// keep receiving items (or cancel ctx) for the stream to make progress.
+// It sets the stream sink (there is no need to call Into).
+func (s *Stream) ToChannel(ctx context.Context) (<-chan interface{}, <-chan error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	items := make(chan interface{})
+	errs := make(chan error, 1)
+
+	s.WithContext(ctx).Into(collectors.Func(func(item interface{}) error {
+		select {
+		case items <- item:
+		case <-ctx.Done():
+		}
+		return nil
+	}))
+
+	result := s.Open()
+	go func() {
+		err := <-result
+		close(items)
+		if err != nil {
+			errs <- err
+		}
+		close(errs)
+	}()
+	return items, errs
+}
+
+// ReduceToValue reduces the items of the stream, with the seed value and
+// the binary function f (see Reduce), then opens the stream and returns the
+// final value.  It blocks until the stream completes and sets the stream
+// sink (there is no need to call Into).  An empty stream returns the seed,
+// which means nil when the seed is nil.  The error from the stream, or
+// from ctx, is returned if any.
+func (s *Stream) ReduceToValue(ctx context.Context, seed, f interface{}) (interface{}, error) {
+	result := seed
+	err := s.Reduce(seed, f).OnEach(ctx, func(i int64, item interface{}) error {
+		result = item
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CollectMap opens the stream and returns a map of the items streamed to
+// the end of the stream, keyed by keyFn, which is useful to build lookup
+// tables.  When several items share a key, the last one wins (see
+// CollectMapUnique).  It blocks until the stream completes and sets the
+// stream sink (there is no need to call Into).  A key that cannot be used
+// as a map key cancels the stream and is returned as an error.
+func (s *Stream) CollectMap(ctx context.Context, keyFn func(interface{}) interface{}) (map[interface{}]interface{}, error) {
+	return s.collectMap(ctx, keyFn, false)
+}
+
+// CollectMapUnique is similar to CollectMap, however a duplicate key
+// cancels the stream and is returned as an error.
+func (s *Stream) CollectMapUnique(ctx context.Context, keyFn func(interface{}) interface{}) (map[interface{}]interface{}, error) {
+	return s.collectMap(ctx, keyFn, true)
+}
+
+func (s *Stream) collectMap(ctx context.Context, keyFn func(interface{}) interface{}, unique bool) (map[interface{}]interface{}, error) {
+	if keyFn == nil {
+		return nil, errors.New("collect map: key function is nil")
+	}
+	result := make(map[interface{}]interface{})
+	err := s.OnEach(ctx, func(i int64, item interface{}) error {
+		key := keyFn(item)
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			return fmt.Errorf("collect map: item %d: key of type %T is not comparable", i, key)
+		}
+		if _, ok := result[key]; ok && unique {
+			return fmt.Errorf("collect map: item %d: duplicate key %v", i, key)
+		}
+		result[key] = item
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CollectGroups opens the stream and returns the items streamed to the end
+// of the stream grouped by key, as returned by keyFn, with the items of
+// each group in stream order.  It blocks until the stream completes and
+// sets the stream sink (there is no need to call Into).  All items are held
+// in memory, it is intended for bounded streams (see CollectGroupsMax).  A
+// key that cannot be used as a map key cancels the stream and is returned
+// as an error.
+func (s *Stream) CollectGroups(ctx context.Context, keyFn func(interface{}) interface{}) (map[interface{}][]interface{}, error) {
+	return s.CollectGroupsMax(ctx, keyFn, 0)
+}
+
+// CollectGroupsMax is similar to CollectGroups, however the stream is
+// cancelled and an error returned when an item would create more than
+// maxGroups groups (no limit if maxGroups <= 0).
+func (s *Stream) CollectGroupsMax(ctx context.Context, keyFn func(interface{}) interface{}, maxGroups int) (map[interface{}][]interface{}, error) {
+	if keyFn == nil {
+		return nil, errors.New("collect groups: key function is nil")
+	}
+	result := make(map[interface{}][]interface{})
+	err := s.OnEach(ctx, func(i int64, item interface{}) error {
+		key := keyFn(item)
+		if key != nil && !reflect.TypeOf(key).Comparable() {
+			return fmt.Errorf("collect groups: item %d: key of type %T is not comparable", i, key)
+		}
+		group, ok := result[key]
+		if !ok && maxGroups > 0 && len(result) >= maxGroups {
+			return fmt.Errorf("collect groups: item %d: key %v exceeds %d groups", i, key, maxGroups)
+		}
+		result[key] = append(group, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// RoundRobinInto distributes streamed items in turn across the specified
+// sinks, for parallel writing, and sets the stream sink (there is no need
+// to call Into).  A sink whose buffer is full is skipped so that a slow sink
+// does not hold back the others.  All sinks are closed when the stream
+// completes.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/collectors"#RoundRobin
+func (s *Stream) RoundRobinInto(sinks ...api.Sink) *Stream {
+	return s.Into(collectors.RoundRobin(sinks...))
+}
+
+// ReplayInto writes streamed items to the unreliable destination writer,
+// keeping the last size items written in a buffer, and sets the stream sink
+// (there is no need to call Into).  When a write fails, the writer is
+// reconnected and the buffered items are replayed, in order, so no items
+// within the buffer window are lost (at-least-once delivery).  Items may be
+// delivered more than once: the destination is responsible for
+// de-duplicating them.  Use Into with collectors.Replay to set the number
+// of reconnect attempts.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/collectors"#Replay
+func (s *Stream) ReplayInto(writer collectors.ReplayWriter, size int) *Stream {
+	return s.Into(collectors.Replay(writer, size))
+}
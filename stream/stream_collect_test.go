@@ -0,0 +1,261 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	"github.com/vladimirvivien/automi/collectors"
+	"github.com/vladimirvivien/automi/emitters"
+)
+
+func TestStream_GroupByKeyInto(t *testing.T) {
+	sinks := map[interface{}]*collectors.SliceCollector{}
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5})).GroupByKeyInto(
+		func(item interface{}) interface{} {
+			if item.(int)%2 == 0 {
+				return "even"
+			}
+			return "odd"
+		},
+		func(key interface{}) api.Sink {
+			snk := collectors.Slice()
+			sinks[key] = snk
+			return snk
+		},
+	)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(sinks) != 2 {
+		t.Fatal("expecting 2 sinks, got", len(sinks))
+	}
+	if len(sinks["odd"].Get()) != 3 || len(sinks["even"].Get()) != 2 {
+		t.Fatal("unexpected partitioning", sinks["odd"].Get(), sinks["even"].Get())
+	}
+}
+
+func TestStream_OnEach(t *testing.T) {
+	var indices []int64
+	var items []interface{}
+	err := New(emitters.Slice([]string{"a", "b", "c"})).OnEach(context.Background(), func(i int64, item interface{}) error {
+		indices = append(indices, i)
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indices) != 3 || indices[0] != 0 || indices[2] != 2 || items[2] != "c" {
+		t.Fatal("unexpected indices or items", indices, items)
+	}
+}
+
+func TestStream_OnEach_Error(t *testing.T) {
+	var data []int
+	for i := 0; i < 10000; i++ {
+		data = append(data, i)
+	}
+	stop := errors.New("stop")
+	var last int64
+	err := New(emitters.Slice(data)).OnEach(context.Background(), func(i int64, item interface{}) error {
+		last = i
+		if i == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop {
+		t.Fatal("expecting callback error, got", err)
+	}
+	if last != 2 {
+		t.Fatal("callback invoked after error, last index", last)
+	}
+}
+
+func TestStream_ReduceToValue(t *testing.T) {
+	sum := func(acc, val int) int { return acc + val }
+	total, err := New([]int{1, 2, 3, 4}).ReduceToValue(context.Background(), 0, sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 10 {
+		t.Fatal("expecting total 10, got", total)
+	}
+
+	total, err = New([]int{}).ReduceToValue(context.Background(), 5, sum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 5 {
+		t.Fatal("expecting seed for an empty stream, got", total)
+	}
+}
+
+func TestStream_RoundRobinInto(t *testing.T) {
+	var data []int
+	for i := 0; i < 100; i++ {
+		data = append(data, i)
+	}
+	sinks := []*collectors.SliceCollector{collectors.Slice(), collectors.Slice()}
+	strm := New(emitters.Slice(data)).RoundRobinInto(sinks[0], sinks[1])
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if len(sinks[0].Get())+len(sinks[1].Get()) != 100 || len(sinks[0].Get()) < 40 {
+		t.Fatal("unexpected distribution", len(sinks[0].Get()), len(sinks[1].Get()))
+	}
+}
+
+func TestStream_CollectMap(t *testing.T) {
+	type user struct {
+		ID   int
+		Name string
+	}
+	users := []user{{1, "ann"}, {2, "bob"}, {1, "cal"}}
+	byID := func(item interface{}) interface{} { return item.(user).ID }
+
+	index, err := New(emitters.Slice(users)).CollectMap(context.Background(), byID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index) != 2 || index[1].(user).Name != "cal" || index[2].(user).Name != "bob" {
+		t.Fatal("unexpected lookup table", index)
+	}
+
+	if _, err := New(emitters.Slice(users)).CollectMapUnique(context.Background(), byID); err == nil {
+		t.Fatal("expecting duplicate key error")
+	}
+
+	_, err = New(emitters.Slice(users)).CollectMap(context.Background(), func(item interface{}) interface{} {
+		return []int{item.(user).ID}
+	})
+	if err == nil {
+		t.Fatal("expecting non-comparable key error")
+	}
+}
+
+func TestStream_CollectGroups(t *testing.T) {
+	words := []string{"apple", "bean", "avocado", "corn", "beet", "artichoke"}
+	byInitial := func(item interface{}) interface{} { return item.(string)[0] }
+
+	groups, err := New(emitters.Slice(words)).CollectGroups(context.Background(), byInitial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[byte][]string{
+		'a': {"apple", "avocado", "artichoke"},
+		'b': {"bean", "beet"},
+		'c': {"corn"},
+	}
+	if len(groups) != len(expected) {
+		t.Fatal("unexpected groups", groups)
+	}
+	for key, items := range expected {
+		group := groups[key]
+		if len(group) != len(items) {
+			t.Fatalf("group %c: unexpected items %v", key, group)
+		}
+		for i := range items {
+			if group[i] != items[i] {
+				t.Fatalf("group %c: unexpected items %v", key, group)
+			}
+		}
+	}
+
+	if _, err := New(emitters.Slice(words)).CollectGroupsMax(context.Background(), byInitial, 2); err == nil {
+		t.Fatal("expecting error beyond max groups")
+	}
+}
+
+func TestStream_ToChannel(t *testing.T) {
+	items, errs := New(emitters.Slice([]int{1, 2, 3, 4})).
+		Map(func(i int) int { return i * i }).
+		ToChannel(context.Background())
+
+	var result []interface{}
+	for item := range items {
+		result = append(result, item)
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 4 || result[0] != 1 || result[3] != 16 {
+		t.Fatal("unexpected items", result)
+	}
+}
+
+func TestStream_ToChannel_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	items, errs := New(emitters.Slice([]int{1, 2, 3, 4})).ToChannel(ctx)
+	<-items
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		for range items {
+		}
+		for range errs {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("expecting channels to close on cancellation")
+	}
+}
+
+// dropOnceWriter loses its connection once, after a number of writes
+type dropOnceWriter struct {
+	failAfter int
+	writes    int
+	failed    bool
+	delivered map[interface{}]int
+}
+
+func (w *dropOnceWriter) Write(item interface{}) error {
+	w.writes++
+	if !w.failed && w.writes > w.failAfter {
+		w.failed = true
+		return errors.New("connection reset")
+	}
+	w.delivered[item]++
+	return nil
+}
+
+func (w *dropOnceWriter) Reconnect() error { return nil }
+
+func TestStream_ReplayInto(t *testing.T) {
+	writer := &dropOnceWriter{failAfter: 3, delivered: map[interface{}]int{}}
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5, 6})).ReplayInto(writer, 4)
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	for i := 1; i <= 6; i++ {
+		if writer.delivered[i] == 0 {
+			t.Fatal("item lost:", i, writer.delivered)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vladimirvivien/automi/emitters"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// CSVTo reads CSV records from source (a file name or an io.Reader, see
+// emitters.CSV) and decodes each record into a new struct appended to the
+// slice pointed to by target (a *[]T or *[]*T where T is a struct type).
+// The first record holds the column headers which are matched to the fields
+// of T by their `csv:"colname"` tag or, for untagged fields, by their name
+// (case insensitive).  Fields tagged `csv:"-"` and columns without a
+// matching field are ignored.  Values are converted to the type of their
+// field (see util.Coerce) and empty values leave the field to its zero
+// value.  A value that cannot be converted stops the reading and is
+// returned as an error.
+func CSVTo(ctx context.Context, source interface{}, target interface{}) error {
+	ptr := reflect.ValueOf(target)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("CSVTo target must be a pointer to a slice, got %T", target)
+	}
+	slice := ptr.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("CSVTo target must be a slice of structs, got %T", target)
+	}
+
+	var columns [][]int // field index per column, nil for ignored columns
+	var headers []string
+	err := New(emitters.CSV(source)).OnEach(ctx, func(i int64, item interface{}) error {
+		row, ok := item.([]string)
+		if !ok {
+			return fmt.Errorf("CSVTo unexpected record type %T", item)
+		}
+		if i == 0 {
+			headers = row
+			columns = csvColumns(structType, row)
+			return nil
+		}
+
+		elem := reflect.New(structType)
+		for col, value := range row {
+			if col >= len(columns) || columns[col] == nil || value == "" {
+				continue
+			}
+			field := elem.Elem().FieldByIndex(columns[col])
+			converted, err := util.Coerce(value, field.Type())
+			if err != nil {
+				return fmt.Errorf("CSVTo record %d, column %s: %s", i, headers[col], err)
+			}
+			field.Set(reflect.ValueOf(converted))
+		}
+		if elemType.Kind() == reflect.Ptr {
+			slice = reflect.Append(slice, elem)
+		} else {
+			slice = reflect.Append(slice, elem.Elem())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if columns == nil {
+		return errors.New("CSVTo no header record found")
+	}
+	ptr.Elem().Set(slice)
+	return nil
+}
+
+// csvColumns returns, for each header, the index of its matching field in
+// structType or nil if no exported field matches.
+func csvColumns(structType reflect.Type, headers []string) [][]int {
+	fields := make(map[string][]int)
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("csv"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag = strings.Split(tag, ",")[0]; tag != "" {
+				name = tag
+			}
+		}
+		fields[strings.ToLower(name)] = field.Index
+	}
+
+	columns := make([][]int, len(headers))
+	for i, header := range headers {
+		columns[i] = fields[strings.ToLower(strings.TrimSpace(header))]
+	}
+	return columns
+}
@@ -0,0 +1,87 @@
+package stream
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	"github.com/vladimirvivien/automi/collectors"
+	"github.com/vladimirvivien/automi/emitters"
+)
+
+type csvPerson struct {
+	Name   string  `csv:"full_name"`
+	Age    int     `csv:"age"`
+	Score  float64 // matched by name
+	Secret string  `csv:"-"`
+}
+
+func TestStream_CSVTo(t *testing.T) {
+	data := "full_name,age,score,secret,extra\nAlice,30,9.5,x,y\nBob,,7,x,y\n"
+	var people []csvPerson
+	if err := CSVTo(context.Background(), strings.NewReader(data), &people); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []csvPerson{{Name: "Alice", Age: 30, Score: 9.5}, {Name: "Bob", Score: 7}}
+	if len(people) != len(expected) {
+		t.Fatal("unexpected records", people)
+	}
+	for i := range expected {
+		if people[i] != expected[i] {
+			t.Fatalf("expecting %+v, got %+v", expected[i], people[i])
+		}
+	}
+}
+
+func TestStream_CSVTo_Pointers(t *testing.T) {
+	var people []*csvPerson
+	if err := CSVTo(context.Background(), strings.NewReader("full_name,age\nAlice,30\n"), &people); err != nil {
+		t.Fatal(err)
+	}
+	if len(people) != 1 || people[0].Name != "Alice" || people[0].Age != 30 {
+		t.Fatal("unexpected records", people)
+	}
+}
+
+func TestStream_CSVTo_Errors(t *testing.T) {
+	var people []csvPerson
+	if err := CSVTo(context.Background(), strings.NewReader("full_name,age\nAlice,old\n"), &people); err == nil {
+		t.Fatal("expecting conversion error")
+	}
+	if err := CSVTo(context.Background(), strings.NewReader(""), people); err == nil {
+		t.Fatal("expecting error for non-pointer target")
+	}
+	var ints []int
+	if err := CSVTo(context.Background(), strings.NewReader(""), &ints); err == nil {
+		t.Fatal("expecting error for non-struct target")
+	}
+}
+
+func TestStream_WithMarshalErrorPolicy(t *testing.T) {
+	var dead []interface{}
+	snk := collectors.Slice()
+	strm := New(emitters.CSV(strings.NewReader("a,b\nc,d,e\nf,g\n"))).
+		WithMarshalErrorPolicy(api.MarshalDeadLetter).
+		WithErrorFunc(func(err api.StreamError) {
+			if item := err.Item(); item != nil {
+				dead = append(dead, item.Item)
+			}
+		}).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 2 || len(dead) != 1 {
+		t.Fatal("unexpected records", snk.Get(), "dead letters", dead)
+	}
+}
@@ -0,0 +1,83 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+)
+
+// WithDeadLetter routes every error reported by a stage of the stream (its
+// source, operators or sink) into the dead letter sink, in addition to the
+// error function, to capture everything the stream could not process.  The
+// errors are sent to the sink as api.StreamItem values where Item is the
+// rejected item carried by the error (nil for errors without an item, see
+// api.ErrorWithItem), MetaData["reason"] is the error message and
+// MetaData["stage"] identifies the stage (i.e. "stage 2 (*unary.UnaryOperator)").
+// The metadata of the rejected item is kept.  The sink is opened with the
+// stream and closed once the stream completes, its error, if any, is
+// returned by the stream.
+func (s *Stream) WithDeadLetter(snk api.Sink) *Stream {
+	if snk == nil {
+		s.drainErr(errors.New("dead letter sink is nil"))
+		return s
+	}
+	s.rejects = &deadLetters{sink: snk, items: make(chan interface{}, 1024)}
+	return s
+}
+
+// deadLetters sends the errors reported by stages to a sink
+type deadLetters struct {
+	sync.Mutex
+	sink   api.Sink
+	items  chan interface{}
+	closed bool
+	done   <-chan error
+}
+
+// open opens the dead letter sink
+func (d *deadLetters) open(ctx context.Context) {
+	d.sink.SetInput(d.items)
+	d.done = d.sink.Open(ctx)
+}
+
+// stageContext returns a context whose error function sends the errors,
+// of the named stage, to the dead letter sink before forwarding them to errf
+func (d *deadLetters) stageContext(ctx context.Context, stage string, errf api.ErrorFunc) context.Context {
+	return autoctx.WithErrorFunc(ctx, func(err api.StreamError) {
+		d.send(ctx, stage, err)
+		autoctx.Err(errf, err)
+	})
+}
+
+func (d *deadLetters) send(ctx context.Context, stage string, err api.StreamError) {
+	item := itemWithMetaData(err.Item(), "reason", err.Error())
+	item.MetaData["stage"] = stage
+	item.Position = nil // dead letters are not checkpointed
+
+	d.Lock()
+	defer d.Unlock()
+	if d.closed {
+		return
+	}
+	select {
+	case d.items <- *item:
+	case <-ctx.Done():
+	}
+}
+
+// close closes the dead letter sink input and returns its result
+func (d *deadLetters) close() error {
+	d.Lock()
+	d.closed = true
+	close(d.items)
+	d.Unlock()
+	return <-d.done
+}
+
+func stageName(stage int, node interface{}) string {
+	return fmt.Sprintf("stage %d (%T)", stage, node)
+}
@@ -0,0 +1,128 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	"github.com/vladimirvivien/automi/collectors"
+	"github.com/vladimirvivien/automi/emitters"
+	"github.com/vladimirvivien/automi/operators/unary"
+)
+
+func TestStream_WithDeadLetter(t *testing.T) {
+	var mutex sync.Mutex
+	var errCount int
+	dead := collectors.Slice()
+	snk := collectors.Slice()
+	strm := New([]int{1, -2, 3, 4, -5}).
+		Assert(func(item interface{}) bool { return item.(int) > 0 }, "negative item").
+		Map(func(i int) interface{} {
+			if i == 4 {
+				return errors.New("unsupported item")
+			}
+			return i
+		}).
+		WithErrorFunc(func(api.StreamError) {
+			mutex.Lock()
+			errCount++
+			mutex.Unlock()
+		}).
+		WithDeadLetter(dead).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if errCount != 3 {
+		t.Fatal("expecting errors still sent to the error function, got", errCount)
+	}
+	rejects := dead.Get()
+	if len(rejects) != 3 {
+		t.Fatal("expecting 3 dead letters, got", rejects)
+	}
+	for _, reject := range rejects {
+		item := reject.(api.StreamItem)
+		switch reason := item.MetaData["reason"]; {
+		case strings.Contains(reason, "negative item"):
+			if item.Item != -2 && item.Item != -5 {
+				t.Fatal("expecting rejected item, got", item)
+			}
+			if !strings.HasPrefix(item.MetaData["stage"], "stage 1 ") {
+				t.Fatal("expecting assert stage, got", item.MetaData["stage"])
+			}
+		case reason == "unsupported item":
+			if !strings.HasPrefix(item.MetaData["stage"], "stage 2 ") {
+				t.Fatal("expecting map stage, got", item.MetaData["stage"])
+			}
+		default:
+			t.Fatal("unexpected dead letter", item)
+		}
+	}
+	if result := snk.Get(); len(result) != 4 {
+		t.Fatal("unexpected items", result)
+	}
+}
+
+// closeSink signals when its input is closed
+type closeSink struct {
+	input  <-chan interface{}
+	closed chan struct{}
+}
+
+func (c *closeSink) SetInput(in <-chan interface{}) {
+	c.input = in
+}
+
+func (c *closeSink) Open(ctx context.Context) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		for range c.input {
+		}
+		close(c.closed)
+		result <- nil
+	}()
+	return result
+}
+
+func TestStream_WithDeadLetter_OpenError(t *testing.T) {
+	streams := map[string]func(dead api.Sink) *Stream{
+		"source": func(dead api.Sink) *Stream {
+			return New(emitters.Slice(42)).WithDeadLetter(dead).Into(collectors.Null())
+		},
+		"operator": func(dead api.Sink) *Stream {
+			return New([]int{1, 2}).
+				AddOperator(unary.NewLimitBytes(10, nil, false)).
+				WithDeadLetter(dead).
+				Into(collectors.Null())
+		},
+	}
+	for name, newStream := range streams {
+		dead := &closeSink{closed: make(chan struct{})}
+		select {
+		case err := <-newStream(dead).Open():
+			if err == nil {
+				t.Fatalf("%s: expecting open error", name)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatalf("%s: took too long", name)
+		}
+		select {
+		case <-dead.closed:
+		case <-time.After(50 * time.Millisecond):
+			t.Fatalf("%s: expecting dead letter sink closed", name)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+)
+
+// DelayedError is returned by a stream in DelayError mode, when it
+// completes after errors were reported to its error path.
+type DelayedError struct {
+	Errors []api.StreamError
+}
+
+// Error returns the messages of the collected errors
+func (e *DelayedError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("stream completed with %d error(s): %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// delayedErrors collects errors reported to the stream error path
+type delayedErrors struct {
+	sync.Mutex
+	errs []api.StreamError
+}
+
+// DelayError sets the stream to collect the errors reported to its error
+// path (items that failed to process) while the stream keeps processing the
+// remaining items.  When the stream completes, the collected errors are
+// returned, from Open, as a *DelayedError.  Errors are still forwarded to the
+// function set with WithErrorFunc, if any.  Errors that cancel the stream
+// still terminate it immediately.
+func (s *Stream) DelayError() *Stream {
+	s.delayed = &delayedErrors{}
+	return s
+}
+
+// errorFunc returns an error function which collects errors before
+// forwarding them to errf
+func (d *delayedErrors) errorFunc(errf api.ErrorFunc) api.ErrorFunc {
+	return func(err api.StreamError) {
+		d.Lock()
+		d.errs = append(d.errs, err)
+		d.Unlock()
+		if errf != nil {
+			errf(err)
+		}
+	}
+}
+
+// result returns the collected errors, as a *DelayedError, or nil
+func (d *delayedErrors) result() error {
+	d.Lock()
+	defer d.Unlock()
+	if len(d.errs) == 0 {
+		return nil
+	}
+	errs := make([]api.StreamError, len(d.errs))
+	copy(errs, d.errs)
+	return &DelayedError{Errors: errs}
+}
+
+// MapError transforms the errors reported to the stream error path, with
+// f, before they reach the function set with WithErrorFunc and the errors
+// collected by DelayError (i.e. to add the stage, a timestamp or a retry
+// count as metadata of the error item, see api.ErrorWithItem).  Streamed
+// items are left untouched.  MapError applies to the errors of the whole
+// stream, regardless of where it is called in the chain, successive
+// MapError functions are applied in the order they are set.
+func (s *Stream) MapError(f func(api.StreamError) api.StreamError) *Stream {
+	if f == nil {
+		s.drainErr(errors.New("error map function is nil"))
+		return s
+	}
+	s.errMaps = append(s.errMaps, f)
+	return s
+}
+
+// mapErrorFunc returns an error function which applies maps to errors
+// before forwarding them to errf
+func mapErrorFunc(maps []func(api.StreamError) api.StreamError, errf api.ErrorFunc) api.ErrorFunc {
+	return func(err api.StreamError) {
+		for _, f := range maps {
+			err = f(err)
+		}
+		if errf != nil {
+			errf(err)
+		}
+	}
+}
+
+// coalescedErrors collapses runs of identical errors reported to the
+// stream error path
+type coalescedErrors struct {
+	sync.Mutex
+	window  time.Duration
+	errf    api.ErrorFunc
+	pending *api.StreamError // first error of the current run
+	count   int
+	run     int // identifies the current run for its timer
+}
+
+// CoalesceErrors collapses consecutive identical errors (with the same
+// message) reported to the stream error path within window into a single
+// error, sent once the run ends (a different error is reported, the window
+// elapses or the stream completes).  An error repeated n times is sent as
+// an error with the message suffixed with the count and an item whose
+// MetaData["occurrences"] holds n (the item of the first error is kept).
+// Single errors are sent unchanged.  This reduces noise during failure
+// storms, errors collected by DelayError are coalesced as well.
+func (s *Stream) CoalesceErrors(window time.Duration) *Stream {
+	if window <= 0 {
+		s.drainErr(errors.New("error coalescing window must be greater than zero"))
+		return s
+	}
+	s.coalesce = &coalescedErrors{window: window}
+	return s
+}
+
+// errorFunc returns an error function which coalesces errors before
+// forwarding them to errf
+func (c *coalescedErrors) errorFunc(errf api.ErrorFunc) api.ErrorFunc {
+	c.errf = errf
+	return func(err api.StreamError) {
+		c.Lock()
+		defer c.Unlock()
+		if c.pending != nil && c.pending.Error() == err.Error() {
+			c.count++
+			return
+		}
+		c.flushLocked()
+		c.pending, c.count = &err, 1
+		c.run++
+		run := c.run
+		time.AfterFunc(c.window, func() {
+			c.Lock()
+			defer c.Unlock()
+			if c.run == run {
+				c.flushLocked()
+			}
+		})
+	}
+}
+
+// flush sends the pending run, if any
+func (c *coalescedErrors) flush() {
+	c.Lock()
+	defer c.Unlock()
+	c.flushLocked()
+}
+
+func (c *coalescedErrors) flushLocked() {
+	if c.pending == nil {
+		return
+	}
+	err := *c.pending
+	if c.count > 1 {
+		item := itemWithMetaData(c.pending.Item(), "occurrences", strconv.Itoa(c.count))
+		err = api.ErrorWithItem(fmt.Sprintf("%s (%d occurrences)", err.Error(), c.count), item)
+	}
+	c.pending, c.count = nil, 0
+	c.run++
+	if c.errf != nil {
+		c.errf(err)
+	}
+}
+
+// namedErrorFunc returns an error function which sets the stream name in
+// the MetaData["stream"] of errors before forwarding them to errf
+func namedErrorFunc(name string, errf api.ErrorFunc) api.ErrorFunc {
+	if errf == nil {
+		return nil
+	}
+	return func(err api.StreamError) {
+		errf(api.ErrorWithItem(err.Error(), itemWithMetaData(err.Item(), "stream", name)))
+	}
+}
+
+// namedLogFunc returns a log function which prefixes messages with the
+// stream name before forwarding them to logf
+func namedLogFunc(name string, logf api.LogFunc) api.LogFunc {
+	if logf == nil {
+		return nil
+	}
+	return func(msg interface{}) {
+		logf(fmt.Sprintf("[%s] %v", name, msg))
+	}
+}
+
+// itemWithMetaData returns a copy of item (or a new item if nil) with
+// MetaData[key] set to value, the MetaData of item is left unchanged
+func itemWithMetaData(item *api.StreamItem, key, value string) *api.StreamItem {
+	result := &api.StreamItem{}
+	if item != nil {
+		*result = *item
+	}
+	meta := make(map[string]string, len(result.MetaData)+1)
+	for k, v := range result.MetaData {
+		meta[k] = v
+	}
+	meta[key] = value
+	result.MetaData = meta
+	return result
+}
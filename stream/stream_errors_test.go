@@ -0,0 +1,170 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	"github.com/vladimirvivien/automi/collectors"
+	"github.com/vladimirvivien/automi/emitters"
+)
+
+func TestStream_DelayError(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5, 6})).
+		DelayError().
+		Map(func(i int) interface{} {
+			if i%3 == 0 {
+				return fmt.Errorf("bad item %d", i)
+			}
+			return i
+		}).
+		Into(snk)
+
+	var err error
+	select {
+	case err = <-strm.Open():
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 4 {
+		t.Fatal("expecting all 4 good items in sink, got", snk.Get())
+	}
+	delayed, ok := err.(*DelayedError)
+	if !ok {
+		t.Fatal("expecting DelayedError, got", err)
+	}
+	if len(delayed.Errors) != 2 || delayed.Errors[1].Error() != "bad item 6" {
+		t.Fatal("unexpected collected errors", delayed.Errors)
+	}
+}
+
+func TestStream_DelayError_NoErrors(t *testing.T) {
+	strm := New(emitters.Slice([]int{1, 2, 3})).DelayError().Into(collectors.Null())
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
+
+func TestStream_MapError(t *testing.T) {
+	var errs []api.StreamError
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4})).
+		Map(func(i int) interface{} {
+			if i%2 == 0 {
+				return fmt.Errorf("even item %d", i)
+			}
+			return i
+		}).
+		MapError(func(err api.StreamError) api.StreamError {
+			item := &api.StreamItem{MetaData: map[string]string{"stage": "odd-filter"}}
+			return api.ErrorWithItem("odd-filter: "+err.Error(), item)
+		}).
+		DelayError().
+		WithErrorFunc(func(err api.StreamError) { errs = append(errs, err) }).
+		Into(snk)
+
+	var err error
+	select {
+	case err = <-strm.Open():
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	delayed, ok := err.(*DelayedError)
+	if !ok || len(delayed.Errors) != 2 || delayed.Errors[0].Error() != "odd-filter: even item 2" {
+		t.Fatal("expecting delayed errors to be mapped, got", err)
+	}
+	if len(errs) != 2 {
+		t.Fatal("expecting 2 errors, got", errs)
+	}
+	for _, e := range errs {
+		if e.Item() == nil || e.Item().MetaData["stage"] != "odd-filter" {
+			t.Fatal("expecting enriched error, got", e)
+		}
+	}
+
+	result := snk.Get()
+	if len(result) != 2 || result[0] != 1 || result[1] != 3 {
+		t.Fatal("unexpected items", result)
+	}
+}
+
+func TestStream_CoalesceErrors(t *testing.T) {
+	var errs []api.StreamError
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5, 6, 7})).
+		Map(func(i int) interface{} {
+			switch {
+			case i <= 4:
+				return errors.New("connection refused")
+			case i == 5:
+				return errors.New("timeout")
+			}
+			return i
+		}).
+		CoalesceErrors(time.Second).
+		WithErrorFunc(func(err api.StreamError) { errs = append(errs, err) }).
+		Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(errs) != 2 {
+		t.Fatal("expecting 2 coalesced errors, got", errs)
+	}
+	if errs[0].Error() != "connection refused (4 occurrences)" || errs[0].Item().MetaData["occurrences"] != "4" {
+		t.Fatal("unexpected coalesced error", errs[0])
+	}
+	if errs[1].Error() != "timeout" || errs[1].Item() != nil {
+		t.Fatal("expecting single error unchanged, got", errs[1])
+	}
+}
+
+func TestStream_CoalesceErrors_Window(t *testing.T) {
+	var mutex sync.Mutex
+	var errs []api.StreamError
+	strm := New(emitters.Slice([]int{1, 2, 3})).
+		Map(func(i int) interface{} {
+			if i == 3 {
+				time.Sleep(30 * time.Millisecond) // past the window
+			}
+			return errors.New("unavailable")
+		}).
+		CoalesceErrors(10 * time.Millisecond).
+		WithErrorFunc(func(err api.StreamError) {
+			mutex.Lock()
+			errs = append(errs, err)
+			mutex.Unlock()
+		}).
+		Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(errs) != 2 || errs[0].Error() != "unavailable (2 occurrences)" || errs[1].Error() != "unavailable" {
+		t.Fatal("expecting run to reset after the window, got", errs)
+	}
+}
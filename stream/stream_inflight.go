@@ -0,0 +1,176 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/group"
+	streamop "github.com/vladimirvivien/automi/operators/stream"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// inflightPos is the position carried by items while they are in-flight,
+// seq is the sequence of the item at the source, pos its original position.
+type inflightPos struct {
+	seq int64
+	pos interface{}
+}
+
+// inflight bounds the number of items between the source and the sink
+type inflight struct {
+	sync.Mutex
+	sem      *group.SemaphoreLimiter
+	acquired int64 // sequence of the last item admitted
+	released int64 // sequence of the last item released
+}
+
+// WithMaxInFlight bounds the total number of items in-flight in the stream,
+// across all operators and their buffers, to n.  A slot is acquired as each
+// item leaves the source and is released when the item (or a result derived
+// from it) reaches the sink.  Items dropped or combined by operators (i.e.
+// Filter, Batch) are released when a later item reaches the sink, so n must
+// be greater than the number of items any operator holds at once (such as
+// the size of a batch or window) for the stream to make progress.  Items
+// are tracked as api.StreamItem values with a Position, positions set by
+// the source are preserved.  With Repartition, items are released when they
+// are handed to the lanes, which are streams of their own.
+func (s *Stream) WithMaxInFlight(n int) *Stream {
+	s.inflight = &inflight{sem: group.Semaphore(n)}
+	return s
+}
+
+// operators returns the operators used to admit and release items, the
+// output of the latter is unbuffered so items are released once the sink
+// receives them
+func (f *inflight) operators() (api.Operator, api.Operator) {
+	return &inflightOp{f: f, admit: true, output: make(chan interface{}, 1024)},
+		&inflightOp{f: f, output: make(chan interface{})}
+}
+
+// bracket returns ops with the admit operator first and the release
+// operator last or, if items leave the stream (i.e. on Repartition lanes),
+// before the operator handing them over
+func (f *inflight) bracket(ops []api.Operator) []api.Operator {
+	admit, release := f.operators()
+	at := len(ops)
+	for i, op := range ops {
+		if _, ok := op.(*streamop.RepartitionOperator); ok {
+			at = i
+			break
+		}
+	}
+	result := make([]api.Operator, 0, len(ops)+2)
+	result = append(result, admit)
+	result = append(result, ops[:at]...)
+	result = append(result, release)
+	return append(result, ops[at:]...)
+}
+
+// admit waits for a slot and returns item tagged with its sequence
+func (f *inflight) admit(ctx context.Context, item interface{}) (interface{}, bool) {
+	if err := f.sem.Acquire(ctx); err != nil {
+		return nil, false
+	}
+	f.Lock()
+	f.acquired++
+	seq := f.acquired
+	f.Unlock()
+
+	if posItem, ok := item.(api.StreamItem); ok && posItem.Position != nil {
+		posItem.Position = inflightPos{seq: seq, pos: posItem.Position}
+		return posItem, true
+	}
+	return api.StreamItem{Item: item, Position: inflightPos{seq: seq}}, true
+}
+
+// release returns the item with its original position along with its
+// sequence, to be released once the item is delivered (0 if not tracked).
+func (f *inflight) release(item interface{}) (interface{}, int64) {
+	data, pos := api.UnwrapPosition(item)
+	ipos, ok := pos.(inflightPos)
+	if !ok {
+		return item, 0
+	}
+	return api.WithPosition(data, ipos.pos), ipos.seq
+}
+
+// releaseTo releases the slots of items up to seq (all admitted items if seq < 0)
+func (f *inflight) releaseTo(seq int64) {
+	f.Lock()
+	defer f.Unlock()
+	if seq < 0 || seq > f.acquired {
+		seq = f.acquired
+	}
+	for ; f.released < seq; f.released++ {
+		f.sem.Release()
+	}
+}
+
+// inflightOp is the operator admitting items after the
+// source (admit is true) or releasing them before the sink
+type inflightOp struct {
+	f      *inflight
+	admit  bool
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+func (o *inflightOp) probe() {}
+
+func (o *inflightOp) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+func (o *inflightOp) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+func (o *inflightOp) Exec(ctx context.Context) error {
+	o.logf = autoctx.GetLogFunc(ctx)
+	if o.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		defer func() {
+			// once the stream is done, no item is in-flight
+			if !o.admit {
+				o.f.releaseTo(-1)
+			}
+			close(o.output)
+			util.Logfn(o.logf, "In-flight operator done")
+		}()
+		for {
+			select {
+			case item, opened := <-o.input:
+				if !opened {
+					return
+				}
+				var seq int64
+				if o.admit {
+					var ok bool
+					if item, ok = o.f.admit(ctx, item); !ok {
+						return
+					}
+				} else {
+					item, seq = o.f.release(item)
+				}
+				select {
+				case o.output <- item:
+				case <-ctx.Done():
+					return
+				}
+				if seq > 0 {
+					o.f.releaseTo(seq)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
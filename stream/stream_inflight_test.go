@@ -0,0 +1,60 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/collectors"
+	"github.com/vladimirvivien/automi/emitters"
+)
+
+func TestStream_WithMaxInFlight(t *testing.T) {
+	var data []int
+	for i := 0; i < 200; i++ {
+		data = append(data, i)
+	}
+
+	var strm *Stream
+	maxSeen := 0
+	count := 0
+	strm = New(emitters.Slice(data).Positioned()).
+		WithMaxInFlight(8).
+		Filter(func(i int) bool { return i%3 != 0 }).
+		Map(func(i int) int { return i * 2 }).
+		Into(collectors.Func(func(item interface{}) error {
+			if count == 0 {
+				// hold the first item until the source fills the window
+				for deadline := time.Now().Add(time.Second); strm.inflight.sem.InFlight() < 8 && time.Now().Before(deadline); {
+					time.Sleep(time.Millisecond)
+				}
+			}
+			if n := strm.inflight.sem.InFlight(); n > maxSeen {
+				maxSeen = n
+			}
+			if _, ok := item.(int); !ok {
+				t.Errorf("expecting unwrapped item, got %T", item)
+			}
+			count++
+			time.Sleep(100 * time.Microsecond) // slow sink
+			return nil
+		}))
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Took too long")
+	}
+
+	if maxSeen > 8 {
+		t.Fatal("in-flight items exceeded limit:", maxSeen)
+	}
+	if maxSeen < 2 {
+		t.Fatal("expecting items to be in-flight, max seen", maxSeen)
+	}
+	if count != 133 {
+		t.Fatal("unexpected item count", count)
+	}
+}
@@ -0,0 +1,94 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// latencyPos is the position carried by items between MarkStart and
+// MeasureLatency, start is the time the item was marked, pos its
+// original position.
+type latencyPos struct {
+	start time.Time
+	pos   interface{}
+}
+
+// MarkStart stamps each item with the current time, to be measured by a
+// later MeasureLatency.  Items are stamped as api.StreamItem values with a
+// Position (positions set upstream are preserved), so operators in between
+// receive items unchanged.
+func (s *Stream) MarkStart() *Stream {
+	return s.appendOp(&latencyOp{mark: true, output: make(chan interface{}, 1024)})
+}
+
+// MeasureLatency calls record with the time elapsed since each item was
+// stamped by the preceding MarkStart.  Operators combining items (i.e.
+// Batch, Reduce) carry the stamp of the last item they combined.  Items
+// that were not stamped are passed through without being measured.
+func (s *Stream) MeasureLatency(record func(d time.Duration)) *Stream {
+	if record == nil {
+		s.drainErr(errors.New("latency record function is nil"))
+		return s
+	}
+	return s.appendOp(&latencyOp{record: record, output: make(chan interface{}, 1024)})
+}
+
+// latencyOp stamps items (mark is true) or measures their latency
+type latencyOp struct {
+	mark   bool
+	record func(time.Duration)
+	input  <-chan interface{}
+	output chan interface{}
+	logf   api.LogFunc
+}
+
+func (o *latencyOp) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+func (o *latencyOp) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+func (o *latencyOp) Exec(ctx context.Context) error {
+	o.logf = autoctx.GetLogFunc(ctx)
+	if o.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+
+	go func() {
+		defer func() {
+			close(o.output)
+			util.Logfn(o.logf, "Latency operator done")
+		}()
+		for {
+			select {
+			case item, opened := <-o.input:
+				if !opened {
+					return
+				}
+				data, pos := api.UnwrapPosition(item)
+				if o.mark {
+					item = api.StreamItem{Item: data, Position: latencyPos{start: time.Now(), pos: pos}}
+				} else if lpos, ok := pos.(latencyPos); ok {
+					o.record(time.Since(lpos.start))
+					item = api.WithPosition(data, lpos.pos)
+				}
+				select {
+				case o.output <- item:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,45 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/collectors"
+	"github.com/vladimirvivien/automi/emitters"
+)
+
+func TestStream_MeasureLatency(t *testing.T) {
+	delay := 5 * time.Millisecond
+	var latencies []time.Duration
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3})).
+		MarkStart().
+		Map(func(i int) int {
+			time.Sleep(delay)
+			return i * 10
+		}).
+		MeasureLatency(func(d time.Duration) { latencies = append(latencies, d) }).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	result := snk.Get()
+	if len(result) != 3 || result[0] != 10 || result[2] != 30 {
+		t.Fatal("expecting unwrapped items in sink, got", result)
+	}
+	if len(latencies) != 3 {
+		t.Fatal("expecting 3 measures, got", latencies)
+	}
+	for _, d := range latencies {
+		if d < delay {
+			t.Fatal("expecting latency of at least", delay, "got", d)
+		}
+	}
+}
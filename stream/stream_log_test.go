@@ -2,12 +2,14 @@ package stream
 
 import (
 	"context"
+	"errors"
 	"log"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/vladimirvivien/automi/api"
 	autoctx "github.com/vladimirvivien/automi/api/context"
 	"github.com/vladimirvivien/automi/collectors"
 	"github.com/vladimirvivien/automi/emitters"
@@ -102,3 +104,58 @@ func TestStream_Log_With_Logger(t *testing.T) {
 		t.Fatal("logger func not logging properly")
 	}
 }
+
+func TestStream_WithName(t *testing.T) {
+	var lock sync.Mutex
+	var logs []string
+	var errs []api.StreamError
+
+	strm := New(emitters.Slice([]string{"hello", "world"})).
+		WithName("orders").
+		WithLogFunc(func(val interface{}) {
+			lock.Lock()
+			logs = append(logs, val.(string))
+			lock.Unlock()
+		}).
+		WithErrorFunc(func(err api.StreamError) {
+			lock.Lock()
+			errs = append(errs, err)
+			lock.Unlock()
+		}).
+		Process(func(val string) error {
+			return errors.New("failed " + val)
+		}).
+		Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	var operatorLogged bool
+	for _, line := range logs {
+		if !strings.HasPrefix(line, "[orders] ") {
+			t.Fatal("expecting log prefixed with stream name, got", line)
+		}
+		if strings.Contains(line, "Unary operator") {
+			operatorLogged = true
+		}
+	}
+	if !operatorLogged {
+		t.Fatal("expecting operator logs to carry the stream name", logs)
+	}
+	if len(errs) != 2 {
+		t.Fatal("expecting 2 errors, got", errs)
+	}
+	for _, err := range errs {
+		if err.Item() == nil || err.Item().MetaData["stream"] != "orders" {
+			t.Fatal("expecting error tagged with stream name:", err)
+		}
+	}
+}
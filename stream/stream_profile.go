@@ -0,0 +1,181 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+)
+
+// profileSampleEvery is the rate at which items are sampled for
+// allocations, runtime.ReadMemStats stops the world
+const profileSampleEvery = 64
+
+// StageProfile is the profile of a stage (operator or sink) of a stream
+// opened with WithProfiler
+type StageProfile struct {
+	Stage      string        // i.e. "stage 1 (*unary.UnaryOperator)"
+	Items      int64         // number of items received by the stage
+	Time       time.Duration // cumulative time spent by the stage on its items
+	Samples    int64         // number of items sampled for allocations
+	AllocBytes uint64        // bytes allocated while the sampled items were processed
+	Allocs     uint64        // heap objects allocated while the sampled items were processed
+}
+
+// WithProfiler records, for each operator and the sink of the stream, the
+// cumulative time spent on items and the allocations made while processing
+// them, to identify the bottleneck of a long pipeline (see Profile).  The
+// time of a stage is measured from the moment it receives an item until it
+// is ready to receive the next one, so it includes the time the stage is
+// blocked by a slower downstream once the buffer between them is full.
+// Allocations are measured (with runtime.ReadMemStats) for one item in 64
+// and include the allocations made concurrently by the other stages, they
+// are an approximation.  Each stage is fed by an additional unbuffered
+// channel, profiling is meant for tuning and is not free.
+func (s *Stream) WithProfiler() *Stream {
+	s.profiler = &profiler{}
+	return s
+}
+
+// Profile returns the profile of each operator and of the sink, in stream
+// order, once the stream opened with WithProfiler completes (nil if the
+// stream is not profiled).
+func (s *Stream) Profile() []StageProfile {
+	if s.profiler == nil {
+		return nil
+	}
+	return s.profiler.profile()
+}
+
+// profiler tracks the time and allocations of each stage
+type profiler struct {
+	sync.Mutex
+	stages []StageProfile
+	closed []time.Time // time when the input of each stage was closed
+}
+
+// watch inserts a profiling operator, feeding each operator, and returns
+// the operators, the last profiling operator feeds the sink
+func (p *profiler) watch(ops []api.Operator, snk api.Sink) []api.Operator {
+	var watched []api.Operator
+	for i, op := range ops {
+		p.stages = append(p.stages, StageProfile{Stage: stageName(i+1, op)})
+		watched = append(watched, newProfileOp(p, i), op)
+	}
+	p.stages = append(p.stages, StageProfile{Stage: stageName(len(ops)+1, snk)})
+	p.closed = make([]time.Time, len(p.stages))
+	return append(watched, newProfileOp(p, len(ops)))
+}
+
+// add records item sent to stage, after it was waited on for d
+func (p *profiler) add(stage int, d time.Duration) {
+	p.Lock()
+	p.stages[stage].Items++
+	p.stages[stage].Time += d
+	p.Unlock()
+}
+
+func (p *profiler) sample(stage int, before, after *runtime.MemStats) {
+	p.Lock()
+	p.stages[stage].Samples++
+	p.stages[stage].AllocBytes += after.TotalAlloc - before.TotalAlloc
+	p.stages[stage].Allocs += after.Mallocs - before.Mallocs
+	p.Unlock()
+}
+
+// closeInput marks the input of stage closed
+func (p *profiler) closeInput(stage int) {
+	p.Lock()
+	p.closed[stage] = time.Now()
+	p.Unlock()
+}
+
+// done records the time stage spent on its last item, until it completed
+func (p *profiler) done(stage int) {
+	p.Lock()
+	if !p.closed[stage].IsZero() {
+		p.stages[stage].Time += time.Since(p.closed[stage])
+	}
+	p.Unlock()
+}
+
+func (p *profiler) profile() []StageProfile {
+	p.Lock()
+	defer p.Unlock()
+	result := make([]StageProfile, len(p.stages))
+	copy(result, p.stages)
+	return result
+}
+
+// profileOp forwards items to a stage, measuring how long the stage
+// takes to be ready for the next item
+type profileOp struct {
+	p      *profiler
+	stage  int
+	input  <-chan interface{}
+	output chan interface{}
+}
+
+// newProfileOp creates a profileOp with an unbuffered output, which
+// blocks until the stage is ready to receive
+func newProfileOp(p *profiler, stage int) *profileOp {
+	return &profileOp{p: p, stage: stage, output: make(chan interface{})}
+}
+
+func (o *profileOp) probe() {}
+
+func (o *profileOp) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+func (o *profileOp) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+func (o *profileOp) Exec(ctx context.Context) error {
+	if o.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+	go func() {
+		defer close(o.output)
+		var before, after runtime.MemStats
+		var count int64
+		sampling := false
+		for {
+			select {
+			case item, opened := <-o.input:
+				if !opened {
+					// upstream stage completed
+					if o.stage > 0 {
+						o.p.done(o.stage - 1)
+					}
+					o.p.closeInput(o.stage)
+					return
+				}
+				start := time.Now()
+				select {
+				case o.output <- item:
+				case <-ctx.Done():
+					return
+				}
+				o.p.add(o.stage, time.Since(start))
+				if sampling {
+					runtime.ReadMemStats(&after)
+					o.p.sample(o.stage, &before, &after)
+					sampling = false
+				}
+				if count%profileSampleEvery == 0 {
+					runtime.ReadMemStats(&before)
+					sampling = true
+				}
+				count++
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,124 @@
+package stream
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	"github.com/vladimirvivien/automi/collectors"
+	"github.com/vladimirvivien/automi/emitters"
+)
+
+func TestStream_WithProfiler(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5})).
+		Map(func(i int) int { return i * 2 }).
+		Map(func(i int) int {
+			time.Sleep(10 * time.Millisecond)
+			return i
+		}).
+		Filter(func(i int) bool { return i > 2 }).
+		WithProfiler().
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 4 {
+		t.Fatal("unexpected result", snk.Get())
+	}
+	profile := strm.Profile()
+	if len(profile) != 4 {
+		t.Fatal("expecting a profile for 3 operators and the sink, got", profile)
+	}
+	if !strings.HasPrefix(profile[3].Stage, "stage 4 (*collectors.SliceCollector)") {
+		t.Fatal("unexpected sink stage", profile[3].Stage)
+	}
+	slowest := 0
+	for i, stage := range profile {
+		if stage.Time > profile[slowest].Time {
+			slowest = i
+		}
+	}
+	if slowest != 1 {
+		t.Fatal("expecting the sleeping stage to be the slowest, got", profile)
+	}
+	if profile[1].Time < 50*time.Millisecond {
+		t.Fatal("expecting at least 50ms spent in the sleeping stage, got", profile[1].Time)
+	}
+	if profile[0].Items != 5 || profile[3].Items != 4 {
+		t.Fatal("unexpected item counts", profile)
+	}
+	if profile[0].Samples != 1 {
+		t.Fatal("expecting the first item to be sampled for allocations, got", profile[0])
+	}
+}
+
+func TestStream_Profile_NotProfiled(t *testing.T) {
+	strm := New(emitters.Slice([]int{1})).Map(func(i int) int { return i }).Into(collectors.Null())
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	if strm.Profile() != nil {
+		t.Fatal("expecting no profile")
+	}
+}
+
+func TestStream_WithProfiler_StageNumbers(t *testing.T) {
+	dead := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3})).
+		Map(func(i int) int { return i * 2 }).
+		Map(func(i int) interface{} {
+			if i == 4 {
+				return errors.New("unsupported item")
+			}
+			return i
+		}).
+		WithProfiler().
+		TimeoutPerStage(time.Second).
+		WithMaxInFlight(8).
+		WithDeadLetter(dead).
+		Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	profile := strm.Profile()
+	if len(profile) != 3 || !strings.HasPrefix(profile[1].Stage, "stage 2 ") {
+		t.Fatal("unexpected profile stages", profile)
+	}
+	rejects := dead.Get()
+	if len(rejects) != 1 {
+		t.Fatal("expecting 1 dead letter, got", rejects)
+	}
+	if stage := rejects[0].(api.StreamItem).MetaData["stage"]; stage != profile[1].Stage {
+		t.Fatalf("expecting dead letter from %q, got %q", profile[1].Stage, stage)
+	}
+	if len(strm.stages.names) != 3 {
+		t.Fatal("expecting the source and 2 operators monitored, got", strm.stages.names)
+	}
+	for _, name := range strm.stages.names {
+		if strings.Contains(name, "profileOp") || strings.Contains(name, "inflightOp") {
+			t.Fatal("expecting probes not monitored as stages, got", strm.stages.names)
+		}
+	}
+}
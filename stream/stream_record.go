@@ -0,0 +1,14 @@
+package stream
+
+import (
+	"github.com/vladimirvivien/automi/collectors"
+)
+
+// RecordBuffer returns a collector that records streamed items
+// in memory. It can be used as a stream sink and, once that stream
+// completes, replayed any number of times with
+//   emitters.FromBuffer(rec)
+// This is intended for fast, repeatable test runs without disk.
+func RecordBuffer() *collectors.RecordCollector {
+	return collectors.Record()
+}
@@ -0,0 +1,52 @@
+package stream
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/collectors"
+	"github.com/vladimirvivien/automi/emitters"
+)
+
+func TestStream_RecordBuffer(t *testing.T) {
+	rec := RecordBuffer()
+	strm := New([]string{"hello", "world", "!"}).Map(func(s string) string {
+		return strings.ToUpper(s)
+	}).Into(rec)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	replay := func() string {
+		snk := collectors.Slice()
+		strm := New(emitters.FromBuffer(rec)).Into(snk)
+		select {
+		case err := <-strm.Open():
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("Waited too long ...")
+		}
+		var result strings.Builder
+		for _, item := range snk.Get() {
+			result.WriteString(item.(string))
+		}
+		return result.String()
+	}
+
+	first, second := replay(), replay()
+	if first != "HELLOWORLD!" {
+		t.Fatal("unexpected replay output", first)
+	}
+	if first != second {
+		t.Fatalf("replays differ: %s, %s", first, second)
+	}
+}
@@ -0,0 +1,166 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/vladimirvivien/automi/api"
+	autoctx "github.com/vladimirvivien/automi/api/context"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// recovery tracks committed positions and persists checkpoints
+type recovery struct {
+	sync.Mutex
+	store   api.CheckpointStore
+	every   int
+	count   int
+	ops     []api.Operator
+	errf    api.ErrorFunc
+	logf    api.LogFunc
+	lastPos interface{}
+	next    api.Checkpointer // checkpointer set on the sink by the user
+}
+
+// checkpointerGetter is implemented by checkpoint sinks that expose
+// the checkpointer already set on them
+type checkpointerGetter interface {
+	GetCheckpointer() api.Checkpointer
+}
+
+// WithRecovery enables checkpoint-based recovery of the stream.  Every
+// `every` committed source positions, a checkpoint (the last committed
+// position along with the state of operators implementing api.Snapshotter)
+// is saved to the store.  When the stream is opened and the store holds a
+// checkpoint, operator states are restored and the source is resumed after
+// the checkpointed position.
+//
+// Recovery requires a source that emits positioned items and implements
+// api.Resumable, and a sink that implements api.CheckpointSink. Items
+// written after the last saved checkpoint are reprocessed after a restart
+// (at-least-once), use every == 1 to checkpoint each committed position.
+// A checkpointer already set on the sink still receives the committed
+// positions.
+//
+// Operator states are snapshot when the sink commits a position, while
+// operators keep running: a snapshot may include items located after the
+// checkpointed position, which are processed again after a restart.
+// Snapshot must therefore be safe for concurrent use, and the state must be
+// derived from the positions of the items it includes (i.e. record the last
+// position applied, to skip reprocessed items once restored).
+func (s *Stream) WithRecovery(store api.CheckpointStore, every int) *Stream {
+	if every < 1 {
+		every = 1
+	}
+	s.recovery = &recovery{store: store, every: every}
+	return s
+}
+
+// setupRecovery restores the last checkpoint (if any) and installs
+// the stream checkpointer on the sink.
+func (s *Stream) setupRecovery() error {
+	rec := s.recovery
+	if rec == nil {
+		return nil
+	}
+	if rec.store == nil {
+		return errors.New("stream recovery missing checkpoint store")
+	}
+	snk, ok := s.sink.(api.CheckpointSink)
+	if !ok {
+		return fmt.Errorf("stream recovery requires a checkpoint sink, got %T", s.sink)
+	}
+	rec.ops = s.ops
+	rec.logf = s.logf
+	rec.errf = s.errf
+
+	cp, found, err := rec.store.Load()
+	if err != nil {
+		return err
+	}
+	if found {
+		util.Logfn(s.logf, fmt.Sprintf("Recovering stream from position %v", cp.Position))
+		if err := rec.restore(s.source, cp); err != nil {
+			return err
+		}
+	}
+
+	// chain to the checkpointer set by the user, if any
+	if getter, ok := snk.(checkpointerGetter); ok {
+		if next := getter.GetCheckpointer(); next != rec {
+			rec.next = next
+		}
+	}
+	snk.SetCheckpointer(rec)
+	return nil
+}
+
+// restore restores operator states and resumes the source
+func (r *recovery) restore(src api.Source, cp api.Checkpoint) error {
+	for i, op := range r.ops {
+		state, ok := cp.States[i]
+		if !ok {
+			continue
+		}
+		if snap, ok := op.(api.Snapshotter); ok {
+			if err := snap.Restore(state); err != nil {
+				return err
+			}
+		}
+	}
+	if cp.Position == nil {
+		return nil
+	}
+	resumable, ok := src.(api.Resumable)
+	if !ok {
+		return fmt.Errorf("stream recovery requires a resumable source, got %T", src)
+	}
+	return resumable.Resume(cp.Position)
+}
+
+// Commit implements api.Checkpointer, it saves a checkpoint every
+// r.every committed positions, after committing position to the next
+// checkpointer, if any.
+func (r *recovery) Commit(position interface{}) error {
+	r.Lock()
+	defer r.Unlock()
+	if r.next != nil {
+		if err := r.next.Commit(position); err != nil {
+			return err
+		}
+	}
+	r.lastPos = position
+	r.count++
+	if r.count%r.every != 0 {
+		return nil
+	}
+	return r.save()
+}
+
+// flush saves the last committed position, if not already saved
+func (r *recovery) flush() {
+	r.Lock()
+	defer r.Unlock()
+	if r.lastPos == nil || r.count%r.every == 0 {
+		return
+	}
+	if err := r.save(); err != nil {
+		util.Logfn(r.logf, err)
+		autoctx.Err(r.errf, api.Error(err.Error()))
+	}
+}
+
+func (r *recovery) save() error {
+	cp := api.Checkpoint{Position: r.lastPos, States: make(map[int]interface{})}
+	for i, op := range r.ops {
+		if snap, ok := op.(api.Snapshotter); ok {
+			state, err := snap.Snapshot()
+			if err != nil {
+				return err
+			}
+			cp.States[i] = state
+		}
+	}
+	return r.store.Save(cp)
+}
@@ -0,0 +1,215 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	"github.com/vladimirvivien/automi/collectors"
+	"github.com/vladimirvivien/automi/emitters"
+)
+
+type memCheckpointStore struct {
+	sync.Mutex
+	cp    api.Checkpoint
+	saved bool
+}
+
+func (m *memCheckpointStore) Save(cp api.Checkpoint) error {
+	m.Lock()
+	defer m.Unlock()
+	m.cp, m.saved = cp, true
+	return nil
+}
+
+func (m *memCheckpointStore) Load() (api.Checkpoint, bool, error) {
+	m.Lock()
+	defer m.Unlock()
+	return m.cp, m.saved, nil
+}
+
+func TestStream_WithRecovery(t *testing.T) {
+	data := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	store := new(memCheckpointStore)
+	var written []int
+
+	run := func(crashAfter int) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		runCount := 0
+		snk := collectors.Func(func(item interface{}) error {
+			if crashAfter > 0 && runCount == crashAfter {
+				cancel() // simulate a crash, item is not written
+				return errors.New("crashed")
+			}
+			written = append(written, item.(int))
+			runCount++
+			return nil
+		})
+		strm := New(emitters.Slice(data).Positioned()).
+			WithContext(ctx).
+			Map(func(i int) int { return i }).
+			WithRecovery(store, 1).
+			Into(snk)
+
+		select {
+		case err := <-strm.Open():
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatal("Waited too long ...")
+		}
+	}
+
+	run(5) // first run stops after writing 5 items
+	if len(written) != 5 {
+		t.Fatal("expecting 5 items written before crash, got", written)
+	}
+	if store.cp.Position != 4 {
+		t.Fatal("expecting checkpoint at position 4, got", store.cp.Position)
+	}
+
+	run(0) // restart resumes after the checkpoint
+	if len(written) != len(data) {
+		t.Fatal("expecting all items written once, got", written)
+	}
+	for i, val := range written {
+		if val != i {
+			t.Fatal("items lost or reprocessed:", written)
+		}
+	}
+}
+
+// sumOp sums items, its state records the last position applied to skip
+// items reprocessed after a restore
+type sumOp struct {
+	sync.Mutex
+	sum     int
+	lastPos int
+	input   <-chan interface{}
+	output  chan interface{}
+}
+
+type sumState struct {
+	Sum     int
+	LastPos int
+}
+
+func newSumOp() *sumOp {
+	return &sumOp{lastPos: -1, output: make(chan interface{}, 1024)}
+}
+
+func (o *sumOp) SetInput(in <-chan interface{}) { o.input = in }
+
+func (o *sumOp) GetOutput() <-chan interface{} { return o.output }
+
+func (o *sumOp) Exec(ctx context.Context) error {
+	go func() {
+		defer close(o.output)
+		for item := range o.input {
+			data, pos := api.UnwrapPosition(item)
+			o.Lock()
+			if pos.(int) > o.lastPos {
+				o.sum += data.(int)
+				o.lastPos = pos.(int)
+			}
+			o.Unlock()
+			select {
+			case o.output <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (o *sumOp) Snapshot() (interface{}, error) {
+	o.Lock()
+	defer o.Unlock()
+	return sumState{Sum: o.sum, LastPos: o.lastPos}, nil
+}
+
+func (o *sumOp) Restore(state interface{}) error {
+	o.Lock()
+	defer o.Unlock()
+	s := state.(sumState)
+	o.sum, o.lastPos = s.Sum, s.LastPos
+	return nil
+}
+
+func TestStream_WithRecovery_Snapshot(t *testing.T) {
+	data := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	store := new(memCheckpointStore)
+
+	run := func(crashAfter int) *sumOp {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		written := 0
+		snk := collectors.Func(func(item interface{}) error {
+			if crashAfter > 0 && written == crashAfter {
+				cancel()
+				return errors.New("crashed")
+			}
+			written++
+			return nil
+		})
+		op := newSumOp()
+		strm := New(emitters.Slice(data).Positioned()).
+			WithContext(ctx).
+			appendOp(op).
+			WithRecovery(store, 1).
+			Into(snk)
+
+		select {
+		case err := <-strm.Open():
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(100 * time.Millisecond):
+			t.Fatal("Waited too long ...")
+		}
+		return op
+	}
+
+	run(5)
+	if _, ok := store.cp.States[0].(sumState); !ok {
+		t.Fatal("expecting operator state in checkpoint, got", store.cp.States)
+	}
+	op := run(0)
+	if op.sum != 45 {
+		t.Fatal("expecting restored sum of all items counted once, got", op.sum)
+	}
+}
+
+func TestStream_WithRecovery_ChainedCheckpointer(t *testing.T) {
+	store := new(memCheckpointStore)
+	var committed []interface{}
+	snk := collectors.Func(func(interface{}) error { return nil }).
+		Checkpointer(api.CheckpointFunc(func(pos interface{}) error {
+			committed = append(committed, pos)
+			return nil
+		}))
+	strm := New(emitters.Slice([]int{1, 2, 3}).Positioned()).
+		WithRecovery(store, 1).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	if len(committed) != 3 || committed[2] != 2 {
+		t.Fatal("expecting positions committed to the sink checkpointer, got", committed)
+	}
+	if store.cp.Position != 2 {
+		t.Fatal("expecting checkpoint at position 2, got", store.cp.Position)
+	}
+}
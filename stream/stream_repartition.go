@@ -0,0 +1,50 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+
+	streamop "github.com/vladimirvivien/automi/operators/stream"
+)
+
+// Repartition redistributes streamed items across n lanes by the key
+// returned by keyFn, so that all items with the same key are streamed, in
+// order, on the same lane.  Each lane is consumed by the sub-stream returned
+// by Lane(i), to which per-lane (keyed) operations can be applied.
+//
+// Items are only streamed on the lanes: this stream emits no further items,
+// however it must still be opened (it feeds the lanes), as must each lane
+// sub-stream.  All lanes are closed when the upstream closes.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/stream"#RepartitionOperator
+func (s *Stream) Repartition(keyFn func(interface{}) interface{}, n int) *Stream {
+	if keyFn == nil {
+		s.drainErr(errors.New("repartition key function is nil"))
+		return s
+	}
+	if n < 1 {
+		s.drainErr(fmt.Errorf("repartition requires at least 1 lane, got %d", n))
+		return s
+	}
+	return s.appendOp(streamop.NewRepartition(keyFn, n))
+}
+
+// Lane returns a new stream that emits the items of lane i of the most
+// recent Repartition operation of this stream.
+func (s *Stream) Lane(i int) *Stream {
+	for j := len(s.ops) - 1; j >= 0; j-- {
+		op, ok := s.ops[j].(*streamop.RepartitionOperator)
+		if !ok {
+			continue
+		}
+		if src := op.Lane(i); src != nil {
+			return New(src)
+		}
+		break
+	}
+	strm := New(nil)
+	strm.drainErr(fmt.Errorf("stream has no repartition lane %d", i))
+	return strm
+}
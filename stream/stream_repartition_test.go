@@ -0,0 +1,96 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/collectors"
+	"github.com/vladimirvivien/automi/emitters"
+)
+
+func TestStream_Repartition(t *testing.T) {
+	type sale struct {
+		Region string
+		Store  string
+	}
+	sales := []sale{
+		{"east", "s1"}, {"west", "s2"}, {"east", "s3"}, {"north", "s1"},
+		{"west", "s3"}, {"south", "s2"}, {"north", "s4"}, {"east", "s2"},
+	}
+	strm := New(emitters.Slice(sales)).
+		Repartition(func(item interface{}) interface{} { return item.(sale).Store }, 2)
+
+	sinks := []*collectors.SliceCollector{collectors.Slice(), collectors.Slice()}
+	results := []<-chan error{strm.Into(collectors.Null()).Open()}
+	for i, snk := range sinks {
+		results = append(results, strm.Lane(i).Map(func(s sale) string { return s.Store }).Into(snk).Open())
+	}
+	for _, result := range results {
+		select {
+		case err := <-result:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("Waited too long ...")
+		}
+	}
+
+	laneOf := make(map[string]int)
+	total := 0
+	for i, snk := range sinks {
+		for _, store := range snk.Get() {
+			if lane, ok := laneOf[store.(string)]; ok && lane != i {
+				t.Fatalf("store %s streamed on lanes %d and %d", store, lane, i)
+			}
+			laneOf[store.(string)] = i
+			total++
+		}
+	}
+	if total != len(sales) {
+		t.Fatal("expecting all items on lanes, got", total)
+	}
+}
+
+func TestStream_Lane_NoRepartition(t *testing.T) {
+	strm := New(emitters.Slice([]int{1})).Lane(0).Into(collectors.Null())
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error for missing repartition")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
+
+func TestStream_Repartition_MaxInFlight(t *testing.T) {
+	data := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	strm := New(emitters.Slice(data).Positioned()).
+		WithMaxInFlight(2).
+		Repartition(func(item interface{}) interface{} { return item.(int) % 2 }, 2)
+
+	sinks := []*collectors.SliceCollector{collectors.Slice(), collectors.Slice()}
+	results := []<-chan error{strm.Into(collectors.Null()).Open()}
+	for i, snk := range sinks {
+		results = append(results, strm.Lane(i).Into(snk).Open())
+	}
+	for _, result := range results {
+		select {
+		case err := <-result:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(500 * time.Millisecond):
+			t.Fatal("Waited too long ...")
+		}
+	}
+
+	total := 0
+	for _, snk := range sinks {
+		total += len(snk.Get())
+	}
+	if total != len(data) {
+		t.Fatal("expecting all items on lanes, got", total)
+	}
+}
@@ -0,0 +1,65 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryStream opens the stream returned by build and, if it terminates with
+// an error, builds and opens it again, up to attempts times, waiting for
+// backoff after the first failure and doubling the wait after each
+// subsequent one.  It returns a channel which receives the result of the
+// last attempt.  This restarts the whole pipeline, which is suitable for
+// idempotent jobs facing transient failures.  Retries stop when the
+// context set with WithContext is cancelled, including during the backoff.
+//
+// A stream can only be opened once, build is called for each attempt (with
+// the attempt number, starting at 1) and must return a new *Stream whose
+// source starts over (i.e. reopens the file or the query) along with a new
+// sink, the items collected by failed attempts are not reused.
+func RetryStream(attempts int, backoff time.Duration, build func(attempt int) *Stream) <-chan error {
+	result := make(chan error)
+	if build == nil || attempts <= 0 {
+		go func() { result <- errors.New("stream retry requires a build function and at least one attempt") }()
+		return result
+	}
+
+	go func() {
+		wait := backoff
+		var err error
+		for attempt := 1; attempt <= attempts; attempt++ {
+			strm := build(attempt)
+			if strm == nil {
+				err = errors.New("stream retry build function returned a nil stream")
+				break
+			}
+			if err = <-strm.Open(); err == nil {
+				break
+			}
+			if attempt == attempts {
+				break
+			}
+			// only the context set with WithContext cancels the retries,
+			// the stream context is also cancelled when a stage stalls
+			ctx := strm.userCtx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if ctx.Err() != nil {
+				break // stream cancelled, do not retry
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				result <- err
+				return
+			}
+			wait *= 2
+		}
+		result <- err
+	}()
+	return result
+}
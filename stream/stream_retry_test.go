@@ -0,0 +1,117 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/collectors"
+	"github.com/vladimirvivien/automi/emitters"
+)
+
+// failingSource is a source that fails to open
+type failingSource struct{ ch chan interface{} }
+
+func (f failingSource) Open(context.Context) error    { return errors.New("source unavailable") }
+func (f failingSource) GetOutput() <-chan interface{} { return f.ch }
+
+func TestStream_RetryStream(t *testing.T) {
+	var snk *collectors.SliceCollector
+	runs := 0
+	result := RetryStream(3, time.Millisecond, func(attempt int) *Stream {
+		runs++
+		snk = collectors.Slice()
+		if attempt == 1 {
+			return New(failingSource{make(chan interface{})}).Into(snk)
+		}
+		return New(emitters.Slice([]int{1, 2, 3})).Map(func(i int) int { return i * 2 }).Into(snk)
+	})
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	items := snk.Get()
+	if runs != 2 || len(items) != 3 || items[0] != 2 || items[2] != 6 {
+		t.Fatal("expecting output of the second run, got", runs, items)
+	}
+}
+
+func TestStream_RetryStream_Exhausted(t *testing.T) {
+	runs := 0
+	result := RetryStream(3, time.Millisecond, func(int) *Stream {
+		runs++
+		return New(failingSource{make(chan interface{})}).Into(collectors.Null())
+	})
+
+	select {
+	case err := <-result:
+		if err == nil {
+			t.Fatal("expecting error after last attempt")
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	if runs != 3 {
+		t.Fatal("expecting 3 attempts, got", runs)
+	}
+}
+
+func TestStream_RetryStream_StalledStage(t *testing.T) {
+	var snk *collectors.SliceCollector
+	runs := 0
+	result := RetryStream(2, time.Millisecond, func(attempt int) *Stream {
+		runs++
+		snk = collectors.Slice()
+		return New(emitters.Slice([]int{1, 2, 3})).
+			WithContext(context.Background()).
+			TimeoutPerStage(30 * time.Millisecond).
+			Process(func(ctx context.Context, i int) int {
+				if attempt == 1 && i == 2 {
+					<-ctx.Done() // stage hangs on the first attempt
+				}
+				return i
+			}).
+			Into(snk)
+	})
+
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Waited too long ...")
+	}
+	if runs != 2 || len(snk.Get()) != 3 {
+		t.Fatal("expecting stalled attempt to be retried, got", runs, snk.Get())
+	}
+}
+
+func TestStream_RetryStream_CancelledBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := 0
+	result := RetryStream(3, time.Hour, func(int) *Stream {
+		runs++
+		return New(failingSource{make(chan interface{})}).WithContext(ctx).Into(collectors.Null())
+	})
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	select {
+	case err := <-result:
+		if err == nil {
+			t.Fatal("expecting error of the cancelled attempt")
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("backoff not cancelled")
+	}
+	if runs != 1 {
+		t.Fatal("expecting 1 attempt, got", runs)
+	}
+}
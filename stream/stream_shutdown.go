@@ -0,0 +1,177 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// WithShutdownTimeout bounds the time the stream takes to shut down once
+// its context is done: if the source, an operator, or the sink has not
+// finished draining within d after cancellation, the stream stops waiting
+// and Open returns an error naming the stages that did not drain in time.
+// Goroutines of stages that are stuck are abandoned (they exit whenever the
+// stuck operation returns).
+func (s *Stream) WithShutdownTimeout(d time.Duration) *Stream {
+	s.shutdown = &shutdownMonitor{timeout: d}
+	return s
+}
+
+// shutdownMonitor tracks which stages have drained
+type shutdownMonitor struct {
+	sync.Mutex
+	timeout time.Duration
+	names   []string
+	closed  []bool
+	drained chan struct{}
+	pending int
+}
+
+// watch inserts a drain watcher after the source and each operator
+// (probes, such as stage watchdogs, are not watched).
+func (m *shutdownMonitor) watch(src api.Source, ops []api.Operator) []api.Operator {
+	m.names = []string{fmt.Sprintf("stage 0 (source %T)", src)}
+	watched := []api.Operator{&shutdownWatchOp{m: m, stage: 0, output: make(chan interface{}, 1024)}}
+	for _, op := range ops {
+		watched = append(watched, op)
+		if isProbe(op) {
+			continue
+		}
+		stage := len(m.names)
+		m.names = append(m.names, fmt.Sprintf("stage %d (%T)", stage, op))
+		watched = append(watched, &shutdownWatchOp{m: m, stage: stage, output: make(chan interface{}, 1024)})
+	}
+	m.closed = make([]bool, len(m.names))
+	m.pending = len(m.names)
+	m.drained = make(chan struct{})
+	return watched
+}
+
+func (m *shutdownMonitor) markClosed(stage int) {
+	m.Lock()
+	defer m.Unlock()
+	if m.closed[stage] {
+		return
+	}
+	m.closed[stage] = true
+	m.pending--
+	if m.pending == 0 {
+		close(m.drained)
+	}
+}
+
+// wait returns the sink result.  Once ctx is done, it waits at most for the
+// shutdown timeout for the sink and all stages to drain.
+func (m *shutdownMonitor) wait(ctx context.Context, sink api.Sink, sinkDone <-chan error, logf api.LogFunc) error {
+	var sinkErr error
+	select {
+	case sinkErr = <-sinkDone:
+		// the sink may return as soon as the context is done,
+		// the stages must still drain within the timeout
+		if ctx.Err() == nil {
+			return sinkErr
+		}
+		sinkDone = nil
+	case <-ctx.Done():
+	}
+
+	timer := time.NewTimer(m.timeout)
+	defer timer.Stop()
+	drained := m.drained
+	for sinkDone != nil || drained != nil {
+		select {
+		case sinkErr = <-sinkDone:
+			sinkDone = nil
+		case <-drained:
+			drained = nil
+		case <-timer.C:
+			laggards := m.laggards()
+			if sinkDone != nil {
+				laggards = append(laggards, fmt.Sprintf("sink (%T)", sink))
+				go func(done <-chan error) { <-done }(sinkDone)
+			}
+			err := fmt.Errorf("stream shutdown timed out after %v, not drained: %s", m.timeout, strings.Join(laggards, ", "))
+			util.Logfn(logf, err)
+			return err
+		}
+	}
+	return sinkErr
+}
+
+// laggards returns the names of the stages that have not drained
+func (m *shutdownMonitor) laggards() []string {
+	m.Lock()
+	defer m.Unlock()
+	var names []string
+	for i, closed := range m.closed {
+		if !closed {
+			names = append(names, m.names[i])
+		}
+	}
+	return names
+}
+
+// shutdownWatchOp forwards the output of a stage.  Once the context is
+// done, it closes its output and discards the remaining output of the
+// stage to record when the stage has drained.
+type shutdownWatchOp struct {
+	m      *shutdownMonitor
+	stage  int
+	input  <-chan interface{}
+	output chan interface{}
+}
+
+func (o *shutdownWatchOp) probe() {}
+
+func (o *shutdownWatchOp) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+func (o *shutdownWatchOp) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+func (o *shutdownWatchOp) Exec(ctx context.Context) error {
+	if o.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+	go func() {
+		defer o.m.markClosed(o.stage)
+		defer func() {
+			for range o.input {
+			}
+		}()
+		defer close(o.output)
+		for {
+			select {
+			case item, opened := <-o.input:
+				if !opened {
+					return
+				}
+				select {
+				case o.output <- item:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// shutdownResult returns a channel receiving the sink result, or the
+// shutdown timeout error
+func (s *Stream) shutdownResult(sinkDone <-chan error) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		result <- s.shutdown.wait(s.ctx, s.sink, sinkDone, s.logf)
+	}()
+	return result
+}
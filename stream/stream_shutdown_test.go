@@ -0,0 +1,66 @@
+package stream
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/collectors"
+	"github.com/vladimirvivien/automi/emitters"
+)
+
+func TestStream_WithShutdownTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stuck := make(chan struct{})
+	defer close(stuck)
+	called := make(chan struct{})
+
+	strm := New(emitters.Slice([]int{1, 2, 3})).
+		WithContext(ctx).
+		WithShutdownTimeout(20 * time.Millisecond).
+		Map(func(i int) int {
+			if i == 2 {
+				close(called)
+				<-stuck
+			}
+			return i
+		}).
+		Into(collectors.Null())
+
+	result := strm.Open()
+	<-called
+	cancel()
+
+	select {
+	case err := <-result:
+		if err == nil || !strings.Contains(err.Error(), "stage 1 (*unary.UnaryOperator)") {
+			t.Fatal("expecting shutdown timeout naming the stuck stage, got", err)
+		}
+		if strings.Contains(err.Error(), "stage 0") {
+			t.Fatal("source should have drained", err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("shutdown did not complete within timeout")
+	}
+}
+
+func TestStream_WithShutdownTimeout_Completes(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3})).
+		WithShutdownTimeout(20 * time.Millisecond).
+		Map(func(i int) int { return i * 2 }).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	if len(snk.Get()) != 3 {
+		t.Fatal("unexpected result", snk.Get())
+	}
+}
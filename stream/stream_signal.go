@@ -0,0 +1,72 @@
+package stream
+
+import (
+	"github.com/vladimirvivien/automi/api"
+	"github.com/vladimirvivien/automi/operators/signal"
+)
+
+// SkipUntil drops items from upstream until the signal emitter produces
+// its first item, the remaining items are then passed downstream.  If
+// the signal is an api.Source, it is opened along with the stream.
+//
+// See Also
+//
+// See the signal operator SkipUntil in
+//   "github.com/vladimirvivien/automi/operators/signal"
+func (s *Stream) SkipUntil(sig api.Emitter) *Stream {
+	return s.appendOp(signal.SkipUntil(sig))
+}
+
+// TakeUntil passes items from upstream until the signal emitter produces
+// its first item, the stream then stops sending items downstream.
+//
+// See Also
+//
+// See the signal operator TakeUntil in
+//   "github.com/vladimirvivien/automi/operators/signal"
+func (s *Stream) TakeUntil(sig api.Emitter) *Stream {
+	return s.appendOp(signal.TakeUntil(sig))
+}
+
+// CombineLatest sends downstream, whenever the stream or the other emitter
+// produces an item, the result of combine applied to the latest item of
+// the stream (a) and the latest item of the other emitter (b).  Nothing is
+// sent until both have produced an item.  If the other emitter is an
+// api.Source, it is opened along with the stream.
+//
+// See Also
+//
+// See the signal operator CombineLatest in
+//   "github.com/vladimirvivien/automi/operators/signal"
+func (s *Stream) CombineLatest(other api.Emitter, combine func(a, b interface{}) interface{}) *Stream {
+	return s.appendOp(signal.CombineLatest(other, combine))
+}
+
+// Gate passes items downstream only while the gate is open, the gate is
+// opened or closed by sending true or false on the open channel (it starts
+// opened).  While closed, the stream stops reading items from upstream.
+// If the open channel is closed, the remaining items are passed.
+//
+// See Also
+//
+// See the signal operator Gate in
+//   "github.com/vladimirvivien/automi/operators/signal"
+func (s *Stream) Gate(open <-chan bool) *Stream {
+	return s.appendOp(signal.Gate(open))
+}
+
+// CrossJoin sends downstream the cartesian product of the stream and the
+// right emitter, using combine to join each item of the stream (l) with
+// every item of right (r).  All items of right are buffered before the
+// first item of the stream is joined, the cost is O(N*M) in time and O(M)
+// in memory (for M right items), so right should be small or bounded.  If
+// right emits nothing, nothing is sent downstream.  If right is an
+// api.Source, it is opened along with the stream.
+//
+// See Also
+//
+// See the signal operator CrossJoin in
+//   "github.com/vladimirvivien/automi/operators/signal"
+func (s *Stream) CrossJoin(right api.Emitter, combine func(l, r interface{}) interface{}) *Stream {
+	return s.appendOp(signal.CrossJoin(right, combine))
+}
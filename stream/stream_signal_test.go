@@ -0,0 +1,151 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/collectors"
+	"github.com/vladimirvivien/automi/emitters"
+)
+
+// chanSource is an unbuffered source, used to control
+// exactly when items are received by the stream
+type chanSource struct{ ch chan interface{} }
+
+func (c chanSource) GetOutput() <-chan interface{} { return c.ch }
+func (c chanSource) Open(context.Context) error    { return nil }
+
+func TestStream_TakeUntil(t *testing.T) {
+	src := chanSource{make(chan interface{})}
+	sig := chanSource{make(chan interface{})}
+	go func() {
+		for i := 0; i < 6; i++ {
+			if i == 3 {
+				sig.ch <- "stop"
+			}
+			src.ch <- i
+		}
+		close(src.ch)
+	}()
+
+	snk := collectors.Slice()
+	strm := New(src).TakeUntil(sig).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	result := snk.Get()
+	if len(result) != 3 || result[0] != 0 || result[2] != 2 {
+		t.Fatal("expecting exactly the first 3 items, got", result)
+	}
+}
+
+func TestStream_CombineLatest(t *testing.T) {
+	prices := chanSource{make(chan interface{})}
+	rates := chanSource{make(chan interface{})}
+	go func() {
+		prices.ch <- 10.0
+		rates.ch <- 2.0
+		prices.ch <- 20.0
+		rates.ch <- 3.0
+		close(prices.ch)
+		close(rates.ch)
+	}()
+
+	snk := collectors.Slice()
+	strm := New(prices).
+		CombineLatest(rates, func(a, b interface{}) interface{} { return a.(float64) * b.(float64) }).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	result := snk.Get()
+	if len(result) != 3 || result[0] != 20.0 || result[1] != 40.0 || result[2] != 60.0 {
+		t.Fatal("expecting combinations of the latest items, got", result)
+	}
+}
+
+func TestStream_Gate(t *testing.T) {
+	src := chanSource{make(chan interface{})}
+	open := make(chan bool)
+	heldBack := make(chan bool, 1)
+	go func() {
+		src.ch <- 0
+		src.ch <- 1
+		open <- false
+		select {
+		case src.ch <- 2:
+			heldBack <- false
+		case <-time.After(10 * time.Millisecond):
+			heldBack <- true
+		}
+		open <- true
+		src.ch <- 2
+		src.ch <- 3
+		close(src.ch)
+	}()
+
+	snk := collectors.Slice()
+	strm := New(src).Gate(open).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	if !<-heldBack {
+		t.Fatal("expecting items to stop flowing while the gate is closed")
+	}
+	result := snk.Get()
+	if len(result) != 4 || result[0] != 0 || result[3] != 3 {
+		t.Fatal("expecting all items once the gate reopens, got", result)
+	}
+}
+
+func TestStream_CrossJoin(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New([]int{1, 2}).
+		CrossJoin(emitters.Slice([]string{"a", "b"}), func(l, r interface{}) interface{} {
+			return fmt.Sprintf("%v%v", l, r)
+		}).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	result := snk.Get()
+	expected := []interface{}{"1a", "1b", "2a", "2b"}
+	if len(result) != len(expected) {
+		t.Fatal("expecting 4 combined items, got", result)
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Fatal("unexpected combinations", result)
+		}
+	}
+}
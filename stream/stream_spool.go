@@ -0,0 +1,29 @@
+package stream
+
+import (
+	"fmt"
+
+	streamop "github.com/vladimirvivien/automi/operators/stream"
+)
+
+// Spool buffers streamed items, without blocking the upstream, while
+// downstream is slower (i.e. during bursts).  Up to memLimit items are
+// kept in memory and the excess is spooled to temporary files in dir
+// (os.TempDir() if empty), which are read back in order as downstream
+// catches up, so that no item is dropped and memory stays bounded.  Items
+// are encoded with encoding/gob, custom types must be registered with
+// gob.Register, items that cannot be encoded are kept in memory.  If
+// spooling fails (i.e. the disk is full), the error is routed to the error
+// path and the following items are kept in memory.  Temporary files are
+// removed when the stream completes or is cancelled.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/stream"#SpoolOperator
+func (s *Stream) Spool(memLimit int, dir string) *Stream {
+	if memLimit < 1 {
+		s.drainErr(fmt.Errorf("spool requires a memory limit greater than zero, got %d", memLimit))
+		return s
+	}
+	return s.appendOp(streamop.NewSpool(memLimit, dir))
+}
@@ -0,0 +1,53 @@
+package stream
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/collectors"
+)
+
+func TestStream_Spool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var data []string
+	for i := 0; i < 200; i++ {
+		data = append(data, string(rune('a'+i%26)))
+	}
+
+	var result []interface{}
+	strm := New(data).Spool(3, dir).Into(collectors.Func(func(item interface{}) error {
+		if len(result) == 0 {
+			time.Sleep(20 * time.Millisecond) // slow consumer, items are spooled
+		}
+		result = append(result, item)
+		return nil
+	}))
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Took too long")
+	}
+
+	if len(result) != len(data) {
+		t.Fatal("expecting all items, got", len(result))
+	}
+	for i := range data {
+		if result[i] != data[i] {
+			t.Fatal("items out of order at", i)
+		}
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Fatal("expecting spooled files removed, got", len(files))
+	}
+}
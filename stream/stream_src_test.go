@@ -1,10 +1,13 @@
 package stream
 
 import (
+	"fmt"
 	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
+	"github.com/vladimirvivien/automi/api"
 	"github.com/vladimirvivien/automi/collectors"
 	"github.com/vladimirvivien/automi/emitters"
 )
@@ -36,6 +39,61 @@ func TestStream_SliceSource(t *testing.T) {
 	}
 }
 
+func TestStream_SliceSourceEmitBatchSize(t *testing.T) {
+	var data []int
+	for i := 0; i < 1000; i++ {
+		data = append(data, i)
+	}
+	pipelines := map[string]func(*Stream) *Stream{
+		"no operator": func(s *Stream) *Stream { return s },
+		"unary":       func(s *Stream) *Stream { return s.Map(func(i int) int { return i * 2 }) },
+		"non unary":   func(s *Stream) *Stream { return s.Batch().ReStream() },
+	}
+
+	for name, pipeline := range pipelines {
+		for _, size := range []int{0, 1, 7, 64, 5000} {
+			snk := collectors.Slice()
+			strm := pipeline(New(emitters.Slice(data).EmitBatchSize(size))).Into(snk)
+			select {
+			case err := <-strm.Open():
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(500 * time.Millisecond):
+				t.Fatal("Took too long")
+			}
+
+			result := snk.Get()
+			if len(result) != len(data) {
+				t.Fatalf("%s, batch size %d: expecting %d items, got %d", name, size, len(data), len(result))
+			}
+			for i, item := range result {
+				if _, batched := item.(api.ItemBatch); batched || (name == "no operator" && item != i) {
+					t.Fatalf("%s, batch size %d: unexpected item %v at %d", name, size, item, i)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkStream_EmitBatchSize(b *testing.B) {
+	data := make([]int, 100000)
+	for _, size := range []int{1, 64} {
+		b.Run(fmt.Sprintf("size-%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				strm := New(emitters.Slice(data).EmitBatchSize(size)).
+					Map(func(i int) int { return i + 1 }).
+					Into(collectors.Null())
+				if err := <-strm.Open(); err != nil {
+					b.Fatal(err)
+				}
+			}
+			// channel sends from the emitter for each stream run
+			b.ReportMetric(float64((len(data)+size-1)/size), "sends/op")
+		})
+	}
+}
+
 func TestStream_ChannelSource(t *testing.T) {
 	data := [][]string{
 		{"request", "/i/a", "00:11:51:AA", "accepted"},
@@ -98,3 +156,25 @@ func TestStream_ReaderSource(t *testing.T) {
 		t.Fatal("Took too long")
 	}
 }
+
+func TestStream_ConcatFallback(t *testing.T) {
+	rdr := iotest.TimeoutReader(strings.NewReader("one\ntwo\n"))
+	snk := collectors.Slice()
+	strm := ConcatFallback(emitters.Reader(rdr), func(err error) api.Emitter {
+		return emitters.Slice([]string{"backup"})
+	}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	result := snk.Get()
+	if len(result) != 2 || string(result[0].([]byte)) != "one\ntwo\n" || result[1] != "backup" {
+		t.Fatal("expecting primary items followed by fallback items, got", result)
+	}
+}
@@ -2,6 +2,7 @@ package stream
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -116,3 +117,243 @@ func TestStream_Open_WithOp(t *testing.T) {
 	}
 	m.RUnlock()
 }
+
+func TestStream_Checkpoint(t *testing.T) {
+	src := make(chan interface{})
+	go func() {
+		for i, word := range []string{"hello", "world", "how", "are", "you"} {
+			src <- api.StreamItem{Item: word, Position: int64(i)}
+		}
+		close(src)
+	}()
+
+	var written []string
+	var committed []int64
+	snk := collectors.Func(func(item interface{}) error {
+		written = append(written, item.(string))
+		return nil
+	}).Checkpointer(api.CheckpointFunc(func(pos interface{}) error {
+		if len(written) != len(committed)+1 {
+			t.Errorf("position %v committed before its item was written", pos)
+		}
+		committed = append(committed, pos.(int64))
+		return nil
+	}))
+
+	strm := New(src).Map(func(s string) string {
+		return strings.ToUpper(s)
+	}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(committed) != 5 {
+		t.Fatal("expecting 5 committed positions, got", len(committed))
+	}
+	for i, pos := range committed {
+		if pos != int64(i) {
+			t.Fatalf("positions committed out of order: %v", committed)
+		}
+	}
+	if written[0] != "HELLO" {
+		t.Fatal("unexpected item written", written[0])
+	}
+}
+
+func TestStream_CheckpointBatch(t *testing.T) {
+	src := make(chan interface{})
+	go func() {
+		for i := 1; i <= 4; i++ {
+			src <- api.StreamItem{Item: i, Position: i}
+		}
+		close(src)
+	}()
+
+	var committed []interface{}
+	var sums []interface{}
+	snk := collectors.Func(func(item interface{}) error {
+		sums = append(sums, item)
+		return nil
+	}).Checkpointer(api.CheckpointFunc(func(pos interface{}) error {
+		committed = append(committed, pos)
+		return nil
+	}))
+
+	strm := New(src).BatchBySize(2).Sum().Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(sums) != 2 || sums[0].(float64) != 3 || sums[1].(float64) != 7 {
+		t.Fatal("unexpected batch sums", sums)
+	}
+	if len(committed) != 2 || committed[0] != 2 || committed[1] != 4 {
+		t.Fatal("expecting positions of last batched items committed, got", committed)
+	}
+}
+
+func TestStream_PositionsWithoutCheckpointer(t *testing.T) {
+	src := make(chan interface{})
+	go func() {
+		src <- api.StreamItem{Item: []string{"a", "b"}, Position: 1}
+		src <- api.StreamItem{Item: "c", Position: 2}
+		close(src)
+	}()
+
+	snk := collectors.Slice()
+	strm := New(src).Process(func(s interface{}) interface{} {
+		if s == "c" {
+			return api.ErrorWithItem("bad item", &api.StreamItem{Item: []string{"c"}})
+		}
+		return s
+	}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	for _, item := range snk.Get() {
+		if _, ok := item.([]string); !ok {
+			t.Fatalf("expecting unwrapped []string items, got %T", item)
+		}
+	}
+}
+
+type upperOperator struct {
+	input  <-chan interface{}
+	output chan interface{}
+}
+
+func (o *upperOperator) SetInput(in <-chan interface{}) { o.input = in }
+func (o *upperOperator) GetOutput() <-chan interface{}  { return o.output }
+func (o *upperOperator) Exec(ctx context.Context) error {
+	go func() {
+		defer close(o.output)
+		for item := range o.input {
+			data, pos := api.UnwrapPosition(item)
+			select {
+			case o.output <- api.WithPosition(strings.ToUpper(data.(string)), pos):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func TestStream_AddOperator(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"hello", "world"})).
+		AddOperator(&upperOperator{output: make(chan interface{})}).
+		Map(func(s string) string { return s + "!" }).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	result := snk.Get()
+	if len(result) != 2 || result[0] != "HELLO!" || result[1] != "WORLD!" {
+		t.Fatal("unexpected result", result)
+	}
+}
+
+func TestStream_AddOperator_MaxInFlight(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"a", "b", "c", "d"})).
+		WithMaxInFlight(2).
+		AddOperator(&upperOperator{output: make(chan interface{})}).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	result := snk.Get()
+	if len(result) != 4 || result[0] != "A" || result[3] != "D" {
+		t.Fatal("unexpected result", result)
+	}
+}
+
+func TestStream_Sink(t *testing.T) {
+	strm := New(emitters.Slice([]string{"a", "b"})).
+		Map(strings.ToUpper).
+		Into([]string{}) // slice collector created by the stream
+	if strm.Sink() != nil {
+		t.Fatal("expecting no sink before the stream is opened")
+	}
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	snk, ok := strm.Sink().(*collectors.SliceCollector)
+	if !ok {
+		t.Fatalf("expecting slice collector, got %T", strm.Sink())
+	}
+	if result := snk.Get(); len(result) != 2 || result[0] != "A" || result[1] != "B" {
+		t.Fatal("unexpected results", result)
+	}
+
+	topN := collectors.TopN(1, func(item interface{}) float64 { return 0 })
+	if New(emitters.Slice([]int{1})).Into(topN).Sink() != topN {
+		t.Fatal("expecting sink set with Into")
+	}
+}
+
+func TestStream_WithContextValues(t *testing.T) {
+	type ctxKey string
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"a", "b"})).
+		WithContextValues(map[interface{}]interface{}{ctxKey("prefix"): ">", ctxKey("suffix"): "<"}).
+		Transform(api.UnFunc(func(ctx context.Context, item interface{}) interface{} {
+			return ctx.Value(ctxKey("prefix")).(string) + item.(string) + ctx.Value(ctxKey("suffix")).(string)
+		})).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	result := snk.Get()
+	if len(result) != 2 || result[0] != ">a<" || result[1] != ">b<" {
+		t.Fatal("expecting context values in operation, got", result)
+	}
+}
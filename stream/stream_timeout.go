@@ -0,0 +1,211 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	"github.com/vladimirvivien/automi/util"
+)
+
+// TimeoutPerStage detects stalled stages: if the source, or any operator,
+// makes no progress for the duration d before it completes, the stream is
+// cancelled and fails with an error naming the stalled stage.  A stage
+// makes progress when it accepts an item from upstream or produces an item.
+// A stage blocked sending downstream (backpressure from a slower stage or
+// sink) or idle waiting for input (starved by its upstream) is not stalled,
+// so only the stage that holds the stream back is reported.  The duration
+// must be greater than the longest time any stage takes to process an item
+// (or, for the source, between two items).
+func (s *Stream) TimeoutPerStage(d time.Duration) *Stream {
+	s.stages = &stageMonitor{timeout: d}
+	return s
+}
+
+// stageMonitor tracks the progress of each stage, through the watchdog
+// operator inserted after it (watcher i reads the output of stage i and
+// feeds stage i+1, the sink for the last one).
+type stageMonitor struct {
+	sync.Mutex
+	timeout  time.Duration
+	names    []string
+	received []time.Time // last output received by watcher i, from stage i
+	sent     []time.Time // last item sent by watcher i, accepted by stage i+1
+	sending  []bool      // watcher i waits for stage i+1 to accept an item
+	closed   []bool      // stage i completed
+	err      error
+}
+
+// watch inserts a watchdog operator after the source and each operator
+// (probes are not watched).
+func (m *stageMonitor) watch(src api.Source, ops []api.Operator) []api.Operator {
+	m.names = append(m.names, fmt.Sprintf("stage 0 (source %T)", src))
+	watched := []api.Operator{newStageWatchOp(m, 0)}
+	for _, op := range ops {
+		watched = append(watched, op)
+		if isProbe(op) {
+			continue
+		}
+		stage := len(m.names)
+		m.names = append(m.names, fmt.Sprintf("stage %d (%T)", stage, op))
+		watched = append(watched, newStageWatchOp(m, stage))
+	}
+	m.received = make([]time.Time, len(m.names))
+	m.sent = make([]time.Time, len(m.names))
+	m.sending = make([]bool, len(m.names))
+	m.closed = make([]bool, len(m.names))
+	return watched
+}
+
+// start monitors the stages until they all complete, cancelling
+// the stream when one of them stalls.
+func (m *stageMonitor) start(ctx context.Context, cancel context.CancelFunc, logf api.LogFunc) {
+	m.Lock()
+	now := time.Now()
+	for i := range m.received {
+		m.received[i] = now
+		m.sent[i] = now
+	}
+	m.Unlock()
+
+	go func() {
+		interval := m.timeout / 4
+		if interval <= 0 {
+			interval = time.Millisecond
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				done, err := m.check()
+				if err != nil {
+					util.Logfn(logf, err)
+					cancel()
+					return
+				}
+				if done {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// check returns true once all stages are done, or the
+// error of the most upstream stalled stage.
+func (m *stageMonitor) check() (bool, error) {
+	m.Lock()
+	defer m.Unlock()
+	done := true
+	for i := range m.names {
+		if m.closed[i] {
+			continue
+		}
+		done = false
+		// blocked sending downstream
+		if m.sending[i] {
+			continue
+		}
+		progress := m.received[i]
+		if i > 0 {
+			// idle waiting on input
+			if !m.sending[i-1] && !m.closed[i-1] {
+				continue
+			}
+			if m.sent[i-1].After(progress) {
+				progress = m.sent[i-1]
+			}
+		}
+		if time.Since(progress) > m.timeout {
+			m.err = fmt.Errorf("stream %s made no progress for %v", m.names[i], m.timeout)
+			return false, m.err
+		}
+	}
+	return done, nil
+}
+
+// receive records an output of stage, or its completion
+func (m *stageMonitor) receive(stage int, closed bool) {
+	m.Lock()
+	m.received[stage] = time.Now()
+	if closed {
+		m.closed[stage] = true
+	}
+	m.Unlock()
+}
+
+// send records whether the watcher of stage is waiting
+// for the next stage to accept an item
+func (m *stageMonitor) send(stage int, sending bool) {
+	m.Lock()
+	m.sending[stage] = sending
+	if !sending {
+		m.sent[stage] = time.Now()
+	}
+	m.Unlock()
+}
+
+// failure returns the stall error, if any
+func (m *stageMonitor) failure() error {
+	m.Lock()
+	defer m.Unlock()
+	return m.err
+}
+
+// stageWatchOp forwards the output of a stage, recording its progress
+type stageWatchOp struct {
+	m      *stageMonitor
+	stage  int
+	input  <-chan interface{}
+	output chan interface{}
+}
+
+// newStageWatchOp creates a stageWatchOp with an unbuffered output, so
+// the next stage accepting an item is observed
+func newStageWatchOp(m *stageMonitor, stage int) *stageWatchOp {
+	return &stageWatchOp{m: m, stage: stage, output: make(chan interface{})}
+}
+
+func (o *stageWatchOp) probe() {}
+
+func (o *stageWatchOp) SetInput(in <-chan interface{}) {
+	o.input = in
+}
+
+func (o *stageWatchOp) GetOutput() <-chan interface{} {
+	return o.output
+}
+
+func (o *stageWatchOp) Exec(ctx context.Context) error {
+	if o.input == nil {
+		return fmt.Errorf("No input channel found")
+	}
+	go func() {
+		defer close(o.output)
+		for {
+			select {
+			case item, opened := <-o.input:
+				if !opened {
+					o.m.receive(o.stage, true)
+					return
+				}
+				o.m.receive(o.stage, false)
+				o.m.send(o.stage, true)
+				select {
+				case o.output <- item:
+					o.m.send(o.stage, false)
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
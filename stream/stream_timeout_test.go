@@ -0,0 +1,106 @@
+package stream
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/collectors"
+	"github.com/vladimirvivien/automi/emitters"
+)
+
+func TestStream_TimeoutPerStage(t *testing.T) {
+	strm := New(emitters.Slice([]int{1, 2, 3, 4})).
+		TimeoutPerStage(30 * time.Millisecond).
+		Map(func(i int) int { return i * 2 }).
+		Process(func(ctx context.Context, i int) int {
+			if i == 6 {
+				<-ctx.Done() // stage hangs
+			}
+			return i
+		}).
+		Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting stalled stage error")
+		}
+		if !strings.Contains(err.Error(), "stage 2 ") {
+			t.Fatal("expecting stage 2 to be reported, got:", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_TimeoutPerStage_Completes(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3})).
+		TimeoutPerStage(time.Second).
+		Map(func(i int) int { return i * 2 }).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Took too long")
+	}
+	if len(snk.Get()) != 3 {
+		t.Fatal("unexpected result", snk.Get())
+	}
+}
+
+func TestStream_TimeoutPerStage_MiddleStage(t *testing.T) {
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5, 6})).
+		TimeoutPerStage(40 * time.Millisecond).
+		Map(func(i int) int { return i * 2 }).
+		Process(func(ctx context.Context, i int) int {
+			if i == 6 {
+				<-ctx.Done() // stage hangs
+			}
+			return i
+		}).
+		Map(func(i int) int { return i + 1 }).
+		Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting stalled stage error")
+		}
+		if !strings.Contains(err.Error(), "stage 2 ") {
+			t.Fatal("expecting the hanging stage 2 to be reported, got:", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Took too long")
+	}
+}
+
+func TestStream_TimeoutPerStage_SlowSink(t *testing.T) {
+	count := 0
+	strm := New(emitters.Slice([]int{1, 2, 3, 4})).
+		TimeoutPerStage(40 * time.Millisecond).
+		Map(func(i int) int { return i * 2 }).
+		Into(collectors.Func(func(interface{}) error {
+			time.Sleep(60 * time.Millisecond) // slow but working
+			count++
+			return nil
+		}))
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal("expecting backpressure from a slow sink not to fail the stream, got:", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Took too long")
+	}
+	if count != 4 {
+		t.Fatal("expecting all items written, got", count)
+	}
+}
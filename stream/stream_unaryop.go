@@ -1,7 +1,14 @@
 package stream
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
 	"github.com/vladimirvivien/automi/api"
+	"github.com/vladimirvivien/automi/codec"
+	"github.com/vladimirvivien/automi/group"
 	"github.com/vladimirvivien/automi/operators/unary"
 )
 
@@ -31,6 +38,31 @@ func (s *Stream) Process(f interface{}) *Stream {
 	return s.Transform(op)
 }
 
+// ProcessLimited is similar to Process, however, the user-defined function
+// is applied by the specified number of concurrent workers while the
+// semaphore limiter caps how many calls to the function can be in-flight
+// at once.  This is intended for I/O-bound functions (i.e. enriching items
+// with remote calls) where the number of slow calls must be bounded
+// independently of the number of workers. The same limiter can be shared
+// across several operations. Output order is not guaranteed when workers > 1,
+// which means source positions (see api.Checkpointer) may reach the sink out
+// of order; in-order checkpoint commits require workers == 1.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/group"#Semaphore
+func (s *Stream) ProcessLimited(workers int, limiter *group.SemaphoreLimiter, f interface{}) *Stream {
+	op, err := unary.ProcessFunc(f)
+	if err != nil {
+		s.drainErr(err)
+	}
+	operator := unary.New()
+	operator.SetOperation(op)
+	operator.SetConcurrency(workers)
+	operator.SetLimiter(limiter)
+	return s.appendOp(operator)
+}
+
 // Filter takes a predicate user-defined func that filters the stream.
 // The specified function must be of type:
 //   func (T) bool
@@ -55,6 +87,275 @@ func (s *Stream) Map(f interface{}) *Stream {
 	return s.Transform(op)
 }
 
+// MapIndexed is similar to Map, the function f also receives the
+// zero-based index of each streamed item (i.e. to assign row numbers).
+// The function is applied by a single worker so that indices follow the
+// order of the items.
+func (s *Stream) MapIndexed(f func(i int64, item interface{}) interface{}) *Stream {
+	if f == nil {
+		s.drainErr(errors.New("map function is nil"))
+		return s
+	}
+	return s.Transform(unary.MapIndexedFunc(f))
+}
+
+// MapConcurrentOrdered is similar to Map, however, the user-defined function
+// is applied by n concurrent workers while results are emitted in the order of
+// their incoming items.  Results that complete early are buffered until they
+// can be emitted in order. Parameter maxPending bounds the number of items
+// dispatched and awaiting in-order emission: when the bound is reached, no new
+// items are dispatched, so a single slow item cannot cause memory to grow
+// without limit.  If maxPending < n, n is used.
+func (s *Stream) MapConcurrentOrdered(n int, maxPending int, f interface{}) *Stream {
+	op, err := unary.MapFunc(f)
+	if err != nil {
+		s.drainErr(err)
+	}
+	operator := unary.NewOrdered(n, maxPending)
+	operator.SetOperation(op)
+	return s.appendOp(operator)
+}
+
+// Schema enforces spec on each streamed map or struct item: declared fields
+// are checked and coerced to their expected types, and defaults are applied
+// to missing optional fields.  Items violating the schema are routed to the
+// error path with a message listing the offending fields.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/unary"#SchemaFunc
+func (s *Stream) Schema(spec unary.SchemaSpec) *Stream {
+	return s.Transform(unary.SchemaFunc(spec))
+}
+
+// CoerceTo converts each streamed item to the target type using reflection
+// (i.e. float64 to int, string to float64, etc) before it reaches typed
+// downstream operations. Items that cannot be converted (or would lose
+// precision) are routed to the error path.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/unary"#CoerceFunc
+func (s *Stream) CoerceTo(target reflect.Type) *Stream {
+	return s.Transform(unary.CoerceFunc(target))
+}
+
+// MapValuesToType converts the value of each streamed tuple.KV item to the
+// target type, as CoerceTo does for items, leaving the key unchanged.
+// Items whose value cannot be converted (or that are not tuple.KV) are
+// routed to the error path.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/unary"#MapValuesToTypeFunc
+func (s *Stream) MapValuesToType(target reflect.Type) *Stream {
+	return s.Transform(unary.MapValuesToTypeFunc(target))
+}
+
+// Compress compresses each streamed []byte item with the codec registered
+// under algo (codec.Gzip, or snappy and zstd when built with the snappy and
+// zstd build tags), i.e. before sending large payloads to a network sink.
+// Items that are not []byte are routed to the error path.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/unary"#CompressFunc
+func (s *Stream) Compress(algo string) *Stream {
+	c, err := codec.Get(algo)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+	return s.Transform(unary.CompressFunc(c))
+}
+
+// Decompress decompresses each streamed []byte item, compressed with the
+// codec registered under algo (see Compress).  Items that are not []byte,
+// or fail to decompress, are routed to the error path.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/unary"#DecompressFunc
+func (s *Stream) Decompress(algo string) *Stream {
+	c, err := codec.Get(algo)
+	if err != nil {
+		s.drainErr(err)
+		return s
+	}
+	return s.Transform(unary.DecompressFunc(c))
+}
+
+// LookupJoin enriches the stream using the static reference table: each
+// streamed item is joined with table[keyFn(item)] and combine(item, ref) is
+// sent downstream.  Items whose key is missing from table are dropped (see
+// LookupJoinWithPolicy), keys are matched as is (see
+// LookupJoinWithNormalizer).  Unlike a stream-stream join, nothing is
+// buffered, the table is read only and must not be modified while the
+// stream is open.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/unary"#LookupJoinFunc
+func (s *Stream) LookupJoin(
+	table map[interface{}]interface{},
+	keyFn func(interface{}) interface{},
+	combine func(item, ref interface{}) interface{},
+) *Stream {
+	return s.LookupJoinWithPolicy(table, keyFn, combine, unary.LookupDrop)
+}
+
+// LookupJoinWithPolicy is LookupJoin with the policy applied to items whose
+// key is missing from table: unary.LookupDrop drops them, unary.LookupPass
+// sends them downstream unchanged and unary.LookupError routes them to the
+// error path.
+func (s *Stream) LookupJoinWithPolicy(
+	table map[interface{}]interface{},
+	keyFn func(interface{}) interface{},
+	combine func(item, ref interface{}) interface{},
+	policy unary.LookupPolicy,
+) *Stream {
+	if keyFn == nil || combine == nil {
+		s.drainErr(errors.New("LookupJoin key and combine functions are required"))
+		return s
+	}
+	return s.Transform(unary.LookupJoinFunc(table, keyFn, combine, policy))
+}
+
+// LookupJoinWithNormalizer is LookupJoinWithPolicy with the keys of table
+// and the item keys canonicalized with normalize before matching (i.e. so
+// that an int64 item key matches a float64 table key decoded from JSON).
+// A nil normalize uses group.NormalizeKey, which converts numeric keys to a
+// common form.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/unary"#NormalizedLookupJoinFunc
+//   "github.com/vladimirvivien/automi/group"#NormalizeKey
+func (s *Stream) LookupJoinWithNormalizer(
+	table map[interface{}]interface{},
+	keyFn func(interface{}) interface{},
+	combine func(item, ref interface{}) interface{},
+	policy unary.LookupPolicy,
+	normalize func(interface{}) interface{},
+) *Stream {
+	if keyFn == nil || combine == nil {
+		s.drainErr(errors.New("LookupJoin key and combine functions are required"))
+		return s
+	}
+	return s.Transform(unary.NormalizedLookupJoinFunc(table, keyFn, combine, policy, normalize))
+}
+
+// Assert checks each streamed item with the predicate pred and passes it
+// downstream unchanged.  Unlike Filter, items violating the predicate are
+// not dropped: an api.StreamError carrying msg and the item is reported
+// to the error path (see WithErrorFunc).
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/unary"#AssertFunc
+func (s *Stream) Assert(pred func(interface{}) bool, msg string) *Stream {
+	return s.Transform(unary.AssertFunc(pred, msg, false))
+}
+
+// MustAssert is similar to Assert, however, the stream panics when
+// an item violates the predicate.
+func (s *Stream) MustAssert(pred func(interface{}) bool, msg string) *Stream {
+	return s.Transform(unary.AssertFunc(pred, msg, true))
+}
+
+// FlattenMap flattens streamed nested maps (with string keys) into
+// single-level map[string]interface{} values keyed by the dotted path of
+// each value, using sep as separator (i.e. "a.b.c").  Slice elements are
+// keyed by their index (i.e. "a.0").  This is useful to write nested records
+// as flat CSV rows. Nesting is flattened up to unary.MaxFlattenDepth levels,
+// cyclic values are skipped and reported to the error path, as are items
+// that are not maps with string keys.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/unary"#FlattenMapFunc
+func (s *Stream) FlattenMap(sep string) *Stream {
+	return s.Transform(unary.FlattenMapFunc(sep))
+}
+
+// RenameFields renames the keys of each streamed map (with string keys)
+// using mapping, from old to new key.  Other items, and maps where a key is
+// renamed onto a key already present, are routed to the error path, to pass
+// them through use:
+//   s.Transform(unary.RenameFieldsFunc(mapping, true))
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/unary"#RenameFieldsFunc
+func (s *Stream) RenameFields(mapping map[string]string) *Stream {
+	return s.Transform(unary.RenameFieldsFunc(mapping, false))
+}
+
+// SetField sets the field name of each streamed map (with string keys) to
+// the value computed by fn from the map, adding or overwriting the field.
+// Other items are routed to the error path, to pass them through use:
+//   s.Transform(unary.SetFieldFunc(name, fn, true))
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/unary"#SetFieldFunc
+func (s *Stream) SetField(name string, fn func(interface{}) interface{}) *Stream {
+	if fn == nil {
+		s.drainErr(errors.New("set field function is nil"))
+		return s
+	}
+	return s.Transform(unary.SetFieldFunc(name, fn, false))
+}
+
+// BloomDedup drops streamed items whose key, as returned by keyFn (or the
+// item itself if keyFn is nil), was probably seen before, using a bloom
+// filter of bounded memory sized for expectedN keys with the false positive
+// rate falsePositiveRate (see sketch.BloomFilter for the sizing).  It scales
+// to streams too large for an exact set of keys, at a cost: all duplicates
+// are dropped, however a small fraction (about falsePositiveRate) of unique
+// items is also dropped.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/unary"#BloomDedupFunc
+func (s *Stream) BloomDedup(keyFn func(interface{}) interface{}, expectedN int, falsePositiveRate float64) *Stream {
+	return s.Transform(unary.BloomDedupFunc(keyFn, expectedN, falsePositiveRate))
+}
+
+// LimitBytes passes streamed items downstream until their cumulative size,
+// as returned by sizeFn, reaches max bytes (i.e. for quota-bounded jobs).
+// The stream then ends: the source is stopped and the items still in-flight
+// upstream are discarded.  The item that would exceed the budget is dropped,
+// see LimitBytesInclusive to keep it.
+func (s *Stream) LimitBytes(max int64, sizeFn func(interface{}) int64) *Stream {
+	return s.appendOp(unary.NewLimitBytes(max, sizeFn, false))
+}
+
+// LimitBytesInclusive is similar to LimitBytes, however the item that
+// exceeds the budget is passed downstream before the stream ends.
+func (s *Stream) LimitBytesInclusive(max int64, sizeFn func(interface{}) int64) *Stream {
+	return s.appendOp(unary.NewLimitBytes(max, sizeFn, true))
+}
+
+// Spread explodes each streamed map into one tuple.KV{key, value} item per
+// entry, and each slice or array into one tuple.KV{index, element} item per
+// element, which is useful to unpivot wide records.  Map entries are
+// streamed in no particular order, see SpreadSorted.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/unary"#SpreadFunc
+func (s *Stream) Spread() *Stream {
+	s.Transform(unary.SpreadFunc(false))
+	return s.ReStream()
+}
+
+// SpreadSorted is similar to Spread, map entries are streamed sorted by key
+func (s *Stream) SpreadSorted() *Stream {
+	s.Transform(unary.SpreadFunc(true))
+	return s.ReStream()
+}
+
 // FlatMap similar to Map, however, the user-defined function is expected to return
 // a slice of values (instead of just one mapped value) for downstream operators.
 // The FlatMap function flatten the slice, returned by the user-defined function,
@@ -70,3 +371,115 @@ func (s *Stream) FlatMap(f interface{}) *Stream {
 	s.ReStream()    // add streamop to unpack flatmap result
 	return s
 }
+
+// FlatMapCtx is similar to FlatMap for context-aware functions which may
+// fail, such as functions expanding items with I/O (i.e. fetching child
+// records).  The function f is applied by the specified number of concurrent
+// workers, the outputs of each item are streamed contiguously, however
+// items complete in no particular order (see FlatMapCtxOrdered).  Errors
+// returned by f are routed to the error path.
+func (s *Stream) FlatMapCtx(concurrency int, f func(ctx context.Context, item interface{}) ([]interface{}, error)) *Stream {
+	if f == nil {
+		s.drainErr(errors.New("flatmap function is nil"))
+		return s
+	}
+	operator := unary.New()
+	operator.SetOperation(unary.FlatMapCtxFunc(f))
+	operator.SetConcurrency(concurrency)
+	operator.SetLimiter(group.Semaphore(concurrency))
+	s.appendOp(operator)
+	return s.ReStream()
+}
+
+// FlatMapCtxOrdered is similar to FlatMapCtx, however the outputs of items
+// are streamed in the order of their incoming items.
+func (s *Stream) FlatMapCtxOrdered(concurrency int, f func(ctx context.Context, item interface{}) ([]interface{}, error)) *Stream {
+	if f == nil {
+		s.drainErr(errors.New("flatmap function is nil"))
+		return s
+	}
+	operator := unary.NewOrdered(concurrency, concurrency)
+	operator.SetOperation(unary.FlatMapCtxFunc(f))
+	s.appendOp(operator)
+	return s.ReStream()
+}
+
+// ConcatMap is similar to FlatMap: f returns the outputs of each streamed
+// item, which are streamed individually.  All outputs from an item are
+// streamed before any output from the next item, which matters when
+// downstream relies on grouped ordering.
+func (s *Stream) ConcatMap(f func(interface{}) []interface{}) *Stream {
+	return s.ConcatMapConcurrent(1, f)
+}
+
+// ConcatMapConcurrent is similar to ConcatMap, f is applied by the
+// specified number of concurrent workers while the outputs are still
+// streamed contiguously and in the order of their incoming items.
+func (s *Stream) ConcatMapConcurrent(concurrency int, f func(interface{}) []interface{}) *Stream {
+	if f == nil {
+		s.drainErr(errors.New("concatmap function is nil"))
+		return s
+	}
+	return s.FlatMapCtxOrdered(concurrency, func(_ context.Context, item interface{}) ([]interface{}, error) {
+		return f(item), nil
+	})
+}
+
+// ParDo applies f to each streamed item.  The function receives an emit
+// function used to send zero or more outputs downstream, each with a tag:
+// outputs with an empty tag continue on this stream, outputs with one of
+// the declared tags are sent to the sub-stream returned by Tagged(tag).
+// Outputs sent to undeclared tags are reported to the error path.
+//
+// All sub-streams are fed by the stream's execution: this stream and each
+// tagged sub-stream must be given a sink and opened, all outputs are closed
+// when the upstream closes.
+func (s *Stream) ParDo(f unary.ParDoFunc, tags ...string) *Stream {
+	if f == nil {
+		s.drainErr(errors.New("ParDo function is nil"))
+		return s
+	}
+	return s.appendOp(unary.NewParDo(f, tags...))
+}
+
+// Tagged returns a new stream that emits the outputs sent to tag by the
+// most recent ParDo operation of this stream.
+func (s *Stream) Tagged(tag string) *Stream {
+	for i := len(s.ops) - 1; i >= 0; i-- {
+		op, ok := s.ops[i].(*unary.ParDoOperator)
+		if !ok {
+			continue
+		}
+		if src := op.Tagged(tag); src != nil {
+			return New(src)
+		}
+		break
+	}
+	strm := New(nil)
+	strm.drainErr(fmt.Errorf("stream has no ParDo output tagged %q", tag))
+	return strm
+}
+
+// OnItemError sets a handler for the errors returned by the preceding
+// unary operation (i.e. Map, Process, etc), so that the stage handles its
+// own failures instead of sending them to the stream's error path.  If the
+// handler returns (value, true) the value is sent downstream in place of
+// the failed item, if it returns false the item is dropped.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/unary"#UnaryOperator.SetItemErrorHandler
+func (s *Stream) OnItemError(handler func(item interface{}, err error) (interface{}, bool)) *Stream {
+	if handler == nil {
+		s.drainErr(errors.New("item error handler is nil"))
+		return s
+	}
+	if len(s.ops) > 0 {
+		if op, ok := s.ops[len(s.ops)-1].(*unary.UnaryOperator); ok {
+			op.SetItemErrorHandler(handler)
+			return s
+		}
+	}
+	s.drainErr(errors.New("OnItemError must follow a unary operation"))
+	return s
+}
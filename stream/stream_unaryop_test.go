@@ -1,16 +1,23 @@
 package stream
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/vladimirvivien/automi/api"
+	"github.com/vladimirvivien/automi/api/tuple"
+	"github.com/vladimirvivien/automi/codec"
 	"github.com/vladimirvivien/automi/collectors"
 	"github.com/vladimirvivien/automi/emitters"
+	"github.com/vladimirvivien/automi/operators/unary"
 )
 
 func TestStream_UnaryOpertors(t *testing.T) {
@@ -339,3 +346,754 @@ func TestStream_UnaryOpertorsErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestStream_MapConcurrentOrdered(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5, 6})).MapConcurrentOrdered(3, 4, func(i int) int {
+		if i == 2 {
+			time.Sleep(5 * time.Millisecond)
+		}
+		return i * i
+	}).Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	for i, item := range snk.Get() {
+		if item.(int) != (i+1)*(i+1) {
+			t.Fatal("unexpected output order:", snk.Get())
+		}
+	}
+	if len(snk.Get()) != 6 {
+		t.Fatal("unexpected item count", len(snk.Get()))
+	}
+}
+
+func TestStream_CoerceTo(t *testing.T) {
+	snk := collectors.Slice()
+	var errs []api.StreamError
+	strm := New(emitters.Slice([]interface{}{1.0, 2.0, "three", 4.0})).
+		CoerceTo(reflect.TypeOf(0)).
+		Map(func(i int) int { return i * 2 }).
+		WithErrorFunc(func(err api.StreamError) {
+			errs = append(errs, err)
+		}).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	result := snk.Get()
+	if len(result) != 3 || result[0] != 2 || result[2] != 8 {
+		t.Fatal("unexpected coerced items", result)
+	}
+	if len(errs) != 1 {
+		t.Fatal("expecting one coercion error, got", len(errs))
+	}
+}
+
+func TestStream_MapValuesToType(t *testing.T) {
+	snk := collectors.Slice()
+	var errs []api.StreamError
+	strm := New(emitters.Slice([]tuple.KV{{"a", 1.0}, {"b", "two"}, {"c", 3.0}})).
+		MapValuesToType(reflect.TypeOf(0)).
+		Map(func(kv tuple.KV) int { return kv[1].(int) * 2 }).
+		WithErrorFunc(func(err api.StreamError) {
+			errs = append(errs, err)
+		}).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	result := snk.Get()
+	if len(result) != 2 || result[0] != 2 || result[1] != 6 {
+		t.Fatal("unexpected coerced values", result)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "key b") {
+		t.Fatal("expecting one coercion error for key b, got", errs)
+	}
+}
+
+func TestStream_CompressDecompress(t *testing.T) {
+	payloads := [][]byte{
+		[]byte(strings.Repeat("a", 1024)),
+		[]byte("hello world"),
+		{},
+	}
+	var compressed int
+	snk := collectors.Slice()
+	var errs []api.StreamError
+	strm := New(emitters.Slice([]interface{}{payloads[0], "not bytes", payloads[1], payloads[2]})).
+		Compress(codec.Gzip).
+		Process(func(data []byte) []byte {
+			compressed += len(data)
+			return data
+		}).
+		Decompress(codec.Gzip).
+		WithErrorFunc(func(err api.StreamError) {
+			errs = append(errs, err)
+		}).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	result := snk.Get()
+	if len(result) != len(payloads) {
+		t.Fatal("unexpected payloads", result)
+	}
+	for i, data := range result {
+		if !bytes.Equal(data.([]byte), payloads[i]) {
+			t.Fatalf("expecting payload %q, got %q", payloads[i], data)
+		}
+	}
+	if compressed >= len(payloads[0]) {
+		t.Fatal("expecting compressed payloads, got", compressed, "bytes")
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "[]byte") {
+		t.Fatal("expecting one error for non-byte item, got", errs)
+	}
+}
+
+func TestStream_Compress_UnknownCodec(t *testing.T) {
+	strm := New(emitters.Slice([][]byte{[]byte("a")})).Compress("lz77").Into(collectors.Null())
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error for unregistered codec")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
+
+func TestStream_LookupJoin(t *testing.T) {
+	type order struct {
+		ID      int
+		Product string
+	}
+	type enriched struct {
+		ID   int
+		Name string
+	}
+	products := map[interface{}]interface{}{
+		"p1": "keyboard",
+		"p2": "mouse",
+	}
+	orderProduct := func(item interface{}) interface{} { return item.(order).Product }
+	withName := func(item, ref interface{}) interface{} {
+		return enriched{ID: item.(order).ID, Name: ref.(string)}
+	}
+	orders := []order{{1, "p1"}, {2, "p3"}, {3, "p2"}}
+
+	tests := []struct {
+		name     string
+		policy   unary.LookupPolicy
+		expected []interface{}
+		errs     int
+	}{
+		{name: "drop", policy: unary.LookupDrop, expected: []interface{}{enriched{1, "keyboard"}, enriched{3, "mouse"}}},
+		{name: "pass", policy: unary.LookupPass, expected: []interface{}{enriched{1, "keyboard"}, order{2, "p3"}, enriched{3, "mouse"}}},
+		{name: "error", policy: unary.LookupError, expected: []interface{}{enriched{1, "keyboard"}, enriched{3, "mouse"}}, errs: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			snk := collectors.Slice()
+			var errs []api.StreamError
+			strm := New(emitters.Slice(orders)).
+				LookupJoinWithPolicy(products, orderProduct, withName, test.policy).
+				WithErrorFunc(func(err api.StreamError) {
+					errs = append(errs, err)
+				}).
+				Into(snk)
+
+			select {
+			case err := <-strm.Open():
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(50 * time.Millisecond):
+				t.Fatal("Waited too long ...")
+			}
+
+			if result := snk.Get(); !reflect.DeepEqual(result, test.expected) {
+				t.Fatalf("expecting %v, got %v", test.expected, result)
+			}
+			if len(errs) != test.errs {
+				t.Fatal("unexpected errors", errs)
+			}
+			if test.errs > 0 && errs[0].Item().Item != (order{2, "p3"}) {
+				t.Fatal("expecting missing order on the error path, got", errs[0].Item())
+			}
+		})
+	}
+}
+
+func TestStream_LookupJoinWithNormalizer(t *testing.T) {
+	// reference table decoded from JSON, numeric keys are float64
+	products := map[interface{}]interface{}{
+		float64(1): "keyboard",
+		float64(2): "mouse",
+	}
+	orders := []map[string]interface{}{
+		{"id": 1, "product": int64(1)},
+		{"id": 2, "product": int64(2)},
+		{"id": 3, "product": int64(3)},
+	}
+	product := func(item interface{}) interface{} { return item.(map[string]interface{})["product"] }
+	withName := func(item, ref interface{}) interface{} {
+		return fmt.Sprintf("%v:%v", item.(map[string]interface{})["id"], ref)
+	}
+
+	snk := collectors.Slice()
+	strm := New(emitters.Slice(orders)).
+		LookupJoinWithNormalizer(products, product, withName, unary.LookupPass, nil).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	result := snk.Get()
+	if len(result) != 3 || result[0] != "1:keyboard" || result[1] != "2:mouse" {
+		t.Fatal("expecting int64 keys to match float64 table keys, got", result)
+	}
+	if _, ok := result[2].(map[string]interface{}); !ok {
+		t.Fatal("expecting missing key passed through, got", result[2])
+	}
+}
+
+func TestStream_ParDo(t *testing.T) {
+	main := collectors.Slice()
+	evens := collectors.Slice()
+	odds := collectors.Slice()
+
+	strm := New(emitters.Slice([]int{1, 2, 3, 4})).ParDo(
+		func(ctx context.Context, item interface{}, emit unary.EmitFunc) {
+			n := item.(int)
+			if n%2 == 0 {
+				emit("even", n)
+			} else {
+				emit("odd", n)
+			}
+			emit("", n*10)
+		}, "even", "odd")
+	evenStrm := strm.Tagged("even").Into(evens)
+	oddStrm := strm.Tagged("odd").Into(odds)
+	strm.Into(main)
+
+	results := []<-chan error{evenStrm.Open(), oddStrm.Open(), strm.Open()}
+	for _, result := range results {
+		select {
+		case err := <-result:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("Took too long")
+		}
+	}
+
+	if len(evens.Get()) != 2 || evens.Get()[0] != 2 || evens.Get()[1] != 4 {
+		t.Fatal("unexpected even items", evens.Get())
+	}
+	if len(odds.Get()) != 2 || odds.Get()[0] != 1 || odds.Get()[1] != 3 {
+		t.Fatal("unexpected odd items", odds.Get())
+	}
+	if len(main.Get()) != 4 || main.Get()[3] != 40 {
+		t.Fatal("unexpected main items", main.Get())
+	}
+}
+
+func TestStream_Assert(t *testing.T) {
+	snk := collectors.Slice()
+	var errs []api.StreamError
+	strm := New(emitters.Slice([]int{1, 2, -3, 4})).
+		Assert(func(item interface{}) bool { return item.(int) > 0 }, "must be positive").
+		Map(func(i int) int { return i * 2 }).
+		WithErrorFunc(func(err api.StreamError) {
+			errs = append(errs, err)
+		}).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if result := snk.Get(); len(result) != 4 || result[2] != -6 {
+		t.Fatal("expecting all items to pass through, got", result)
+	}
+	if len(errs) != 1 || errs[0].Error() != "assertion failed: must be positive: -3" {
+		t.Fatal("unexpected assertion errors", errs)
+	}
+}
+
+func TestStream_FlattenMap(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]map[string]interface{}{
+		{"user": map[string]interface{}{"name": "ada", "tags": []string{"x", "y"}}},
+	})).FlattenMap("_").Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	row := snk.Get()[0].(map[string]interface{})
+	if row["user_name"] != "ada" || row["user_tags_1"] != "y" || len(row) != 3 {
+		t.Fatal("unexpected flattened row", row)
+	}
+}
+
+func TestStream_Spread(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]map[string]int{{"b": 2, "a": 1, "c": 3}})).
+		SpreadSorted().
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	result := snk.Get()
+	if len(result) != 3 {
+		t.Fatal("expecting 3 KV items, got", result)
+	}
+	for i, key := range []string{"a", "b", "c"} {
+		kv := result[i].(tuple.KV)
+		if kv[0] != key || kv[1] != i+1 {
+			t.Fatal("unexpected KV item", kv)
+		}
+	}
+}
+
+func TestStream_Schema(t *testing.T) {
+	var errs []api.StreamError
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]map[string]interface{}{
+		{"id": "1", "name": "ann"},
+		{"name": "bob"},
+		{"id": 3, "name": "cal"},
+	})).
+		WithErrorFunc(func(err api.StreamError) { errs = append(errs, err) }).
+		Schema(unary.SchemaSpec{
+			{Name: "id", Type: reflect.TypeOf(0), Required: true},
+			{Name: "name", Type: reflect.TypeOf("")},
+		}).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	result := snk.Get()
+	if len(result) != 2 || result[0].(map[string]interface{})["id"] != 1 {
+		t.Fatal("unexpected result", result)
+	}
+	if len(errs) != 1 {
+		t.Fatal("expecting 1 schema violation, got", errs)
+	}
+}
+
+func TestStream_RenameFields_SetField(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]map[string]interface{}{
+		{"fname": "ann", "lname": "lee"},
+		{"fname": "bob", "lname": "ray"},
+	})).
+		RenameFields(map[string]string{"fname": "first", "lname": "last"}).
+		SetField("full", func(item interface{}) interface{} {
+			m := item.(map[string]interface{})
+			return m["first"].(string) + " " + m["last"].(string)
+		}).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	result := snk.Get()
+	if len(result) != 2 {
+		t.Fatal("unexpected result", result)
+	}
+	rec := result[1].(map[string]interface{})
+	if rec["first"] != "bob" || rec["full"] != "bob ray" || rec["fname"] != nil {
+		t.Fatal("unexpected record", rec)
+	}
+}
+
+func TestStream_BloomDedup(t *testing.T) {
+	n, rate := 20000, 0.01
+	items := make([]int, 0, 2*n)
+	for i := 0; i < n; i++ {
+		items = append(items, i, i) // each unique item twice
+	}
+
+	snk := collectors.Slice()
+	strm := New(emitters.Slice(items)).BloomDedup(nil, n, rate).Into(snk)
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Waited too long ...")
+	}
+
+	result := snk.Get()
+	seen := make(map[interface{}]bool)
+	for _, item := range result {
+		if seen[item] {
+			t.Fatal("duplicate not dropped", item)
+		}
+		seen[item] = true
+	}
+	if dropped := float64(n-len(result)) / float64(n); dropped > 1.5*rate {
+		t.Fatalf("dropped %.4f of unique items, expecting at most %.4f", dropped, rate)
+	}
+}
+
+func TestStream_FlatMapCtx(t *testing.T) {
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	fetchChildren := func(ctx context.Context, item interface{}) ([]interface{}, error) {
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			active--
+			mu.Unlock()
+		}()
+
+		parent := item.(int)
+		if parent == 3 {
+			return nil, fmt.Errorf("fetch failed for %d", parent)
+		}
+		time.Sleep(time.Duration(5-parent) * time.Millisecond)
+		return []interface{}{parent*10 + 1, parent*10 + 2}, nil
+	}
+
+	var errs []api.StreamError
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4})).
+		WithErrorFunc(func(err api.StreamError) { errs = append(errs, err) }).
+		FlatMapCtxOrdered(2, fetchChildren).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	expected := []interface{}{11, 12, 21, 22, 41, 42}
+	result := snk.Get()
+	if len(result) != len(expected) {
+		t.Fatal("unexpected result", result)
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Fatal("unexpected result", result)
+		}
+	}
+	if maxActive > 2 {
+		t.Fatal("expecting at most 2 concurrent calls, got", maxActive)
+	}
+	if len(errs) != 1 {
+		t.Fatal("expecting 1 error, got", errs)
+	}
+}
+
+func TestStream_FlatMapCtx_Unordered(t *testing.T) {
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5, 6})).
+		FlatMapCtx(3, func(ctx context.Context, item interface{}) ([]interface{}, error) {
+			mu.Lock()
+			active++
+			if active > maxActive {
+				maxActive = active
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			active--
+			mu.Unlock()
+			return []interface{}{item, item}, nil
+		}).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if maxActive < 2 || maxActive > 3 {
+		t.Fatal("expecting between 2 and 3 concurrent calls, got", maxActive)
+	}
+	result := snk.Get()
+	if len(result) != 12 {
+		t.Fatal("unexpected result", result)
+	}
+	for i := 0; i < len(result); i += 2 {
+		if result[i] != result[i+1] {
+			t.Fatal("expecting outputs of each item to be contiguous", result)
+		}
+	}
+}
+
+func TestStream_LimitBytes(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"hello", "world", "of", "streams", "again"})).
+		LimitBytes(12, func(item interface{}) int64 { return int64(len(item.(string))) }).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	// hello(5) + world(5) + of(2) reaches 12 bytes
+	result := snk.Get()
+	if len(result) != 3 || result[2] != "of" {
+		t.Fatal("expecting stream to stop at the byte limit, got", result)
+	}
+}
+
+func TestStream_LimitBytes_StopsSource(t *testing.T) {
+	var sent int64
+	src := make(chan string)
+	done := make(chan struct{})
+	defer close(done)
+	go func() { // endless source
+		for {
+			select {
+			case src <- "abcd":
+				atomic.AddInt64(&sent, 1)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	snk := collectors.Slice()
+	strm := New(src).
+		LimitBytes(10, func(item interface{}) int64 { return int64(len(item.(string))) }).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("expecting the stream to end once the limit is reached")
+	}
+	if result := snk.Get(); len(result) != 2 {
+		t.Fatal("unexpected result", result)
+	}
+
+	// the source is no longer read
+	time.Sleep(10 * time.Millisecond)
+	count := atomic.LoadInt64(&sent)
+	time.Sleep(20 * time.Millisecond)
+	if atomic.LoadInt64(&sent) != count {
+		t.Fatal("expecting upstream to stop once the limit is reached")
+	}
+}
+
+func TestStream_ConcatMapConcurrent(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{5, 1, 4, 2, 3})).
+		ConcatMapConcurrent(4, func(item interface{}) []interface{} {
+			n := item.(int)
+			time.Sleep(time.Duration(n) * time.Millisecond) // later items finish first
+			outputs := make([]interface{}, n)
+			for i := range outputs {
+				outputs[i] = fmt.Sprintf("%d-%d", n, i)
+			}
+			return outputs
+		}).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	var expected []interface{}
+	for _, n := range []int{5, 1, 4, 2, 3} {
+		for i := 0; i < n; i++ {
+			expected = append(expected, fmt.Sprintf("%d-%d", n, i))
+		}
+	}
+	result := snk.Get()
+	if len(result) != len(expected) {
+		t.Fatal("unexpected outputs", result)
+	}
+	for i := range expected {
+		if result[i] != expected[i] {
+			t.Fatal("expecting contiguous outputs in input order, got", result)
+		}
+	}
+}
+
+func TestStream_MapIndexed(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"alpha", "beta", "gamma"})).
+		MapIndexed(func(i int64, item interface{}) interface{} {
+			return fmt.Sprintf("%d:%s", i+1, item)
+		}).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	result := snk.Get()
+	if len(result) != 3 || result[0] != "1:alpha" || result[1] != "2:beta" || result[2] != "3:gamma" {
+		t.Fatal("unexpected indexed items", result)
+	}
+}
+
+func TestStream_OnItemError(t *testing.T) {
+	var errCount int
+	var mutex sync.Mutex
+	snk := collectors.Slice()
+	strm := New([]string{"1", "two", "3", "four"}).
+		WithErrorFunc(func(api.StreamError) {
+			mutex.Lock()
+			errCount++
+			mutex.Unlock()
+		}).
+		Map(func(item string) interface{} {
+			var val int
+			if _, err := fmt.Sscanf(item, "%d", &val); err != nil {
+				return err
+			}
+			return val
+		}).
+		OnItemError(func(item interface{}, err error) (interface{}, bool) {
+			return -1, true
+		}).
+		Map(func(val int) interface{} {
+			if val == 3 {
+				return errors.New("three")
+			}
+			return val
+		}).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	result := snk.Get()
+	if len(result) != 3 || result[0] != 1 || result[1] != -1 || result[2] != -1 {
+		t.Fatal("expecting defaults substituted for failed items, got", result)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if errCount != 1 {
+		t.Fatal("expecting only the unhandled stage to report errors, got", errCount)
+	}
+}
+
+func TestStream_OnItemError_NotUnary(t *testing.T) {
+	strm := New([]int{1, 2}).Batch().OnItemError(func(item interface{}, err error) (interface{}, bool) {
+		return item, true
+	}).Into(collectors.Null())
+
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error when OnItemError does not follow a unary operation")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+}
@@ -0,0 +1,187 @@
+package stream
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/vladimirvivien/automi/operators/unary"
+	"github.com/vladimirvivien/automi/operators/window"
+)
+
+// AssignTimestamps stamps each streamed item with a synthetic, monotonically
+// increasing event time (as api.TimedItem) starting at start and increasing
+// by step for each item (one nanosecond tick per item if step <= 0).  It
+// allows event-time operators to be driven deterministically without a clock.
+//
+// See Also
+//
+// See the window operator function AssignTimestampsFunc in
+//   "github.com/vladimirvivien/automi/operators/window"
+func (s *Stream) AssignTimestamps(start time.Time, step time.Duration) *Stream {
+	operator := unary.New()
+	operator.SetOperation(window.AssignTimestampsFunc(start, step))
+	return s.appendOp(operator)
+}
+
+// WindowByTime groups streamed items into tumbling event-time windows of
+// the specified size.  Event times are taken from api.TimedItem values
+// (see AssignTimestamps), other items use their arrival time.  Each window
+// is sent downstream as []interface{} once an item for a later window
+// arrives or the upstream closes.
+func (s *Stream) WindowByTime(size time.Duration) *Stream {
+	return s.appendOp(window.New(size))
+}
+
+// ThrottleLatest samples streamed items: once per interval d, the most
+// recent item received during the interval is sent downstream and the
+// intermediate items are dropped (i.e. to rate-limit high-frequency updates).
+// A pending item is sent downstream when the upstream closes.
+func (s *Stream) ThrottleLatest(d time.Duration) *Stream {
+	return s.appendOp(window.NewThrottleLatest(d))
+}
+
+// WindowWhen groups streamed items into windows closed by the trigger
+// function: each item is added to the current window, which is sent
+// downstream as []interface{} when trigger returns true for the item and
+// the items accumulated so far (i.e. close on an "end of transaction"
+// record).  A trailing partial window is sent when the upstream closes.
+func (s *Stream) WindowWhen(trigger func(item interface{}, accumulated []interface{}) bool) *Stream {
+	return s.appendOp(window.NewTrigger(trigger))
+}
+
+// SplitOn groups streamed items into windows delimited by boundary items:
+// items accumulate until isBoundary returns true for an item, the group is
+// then sent downstream as []interface{} (without the boundary item).  The
+// trailing group is sent when the upstream closes.  Empty groups, between
+// consecutive boundaries, are not sent.
+func (s *Stream) SplitOn(isBoundary func(interface{}) bool) *Stream {
+	return s.appendOp(window.NewSplitOn(isBoundary))
+}
+
+// Throttled monitors how often sending streamed items downstream blocks
+// (the backpressure) and slows the upstream adaptively: before each item,
+// it waits for a delay proportional to the pressure, up to maxDelay (0 only
+// monitors).  If report is not nil, it is called with the current
+// window.PressureStats after each item is sent.
+func (s *Stream) Throttled(maxDelay time.Duration, report func(window.PressureStats)) *Stream {
+	return s.appendOp(window.NewPressure(maxDelay, report))
+}
+
+// UniqCount collapses consecutive streamed items with the same key, as
+// returned by keyFn, into a single window.Run{Value, Count} where Value is
+// the first item of the run (like uniq -c).  Only the current run is kept,
+// the final run is sent downstream when the upstream closes.
+func (s *Stream) UniqCount(keyFn func(interface{}) interface{}) *Stream {
+	return s.appendOp(window.NewUniq(keyFn))
+}
+
+// Shuffle randomizes the order of streamed items with bounded memory (like
+// the shuffle buffer of ML data loaders): items fill a reservoir of
+// bufferSize items, each new item then replaces a randomly selected item
+// which is sent downstream.  The remaining items are sent in random order
+// when the upstream closes.  The larger the buffer, the closer the output
+// is to a uniform shuffle.  Source positions are held back while earlier
+// items are still buffered, so checkpoints never skip a buffered item.
+func (s *Stream) Shuffle(bufferSize int) *Stream {
+	return s.ShuffleWith(bufferSize, nil)
+}
+
+// ShuffleWith is similar to Shuffle, items are selected with rng (i.e. a
+// seeded *rand.Rand for a reproducible order).
+func (s *Stream) ShuffleWith(bufferSize int, rng *rand.Rand) *Stream {
+	return s.appendOp(window.NewShuffle(bufferSize, rng))
+}
+
+// SessionWindow groups streamed items, per key returned by keyFn, into
+// sessions separated by periods of inactivity. Items for a key accumulate
+// until no new item with that key arrives for the gap duration, the
+// session is then sent downstream as []interface{}.  Open sessions are
+// flushed when the upstream closes.
+func (s *Stream) SessionWindow(keyFn func(interface{}) interface{}, gap time.Duration) *Stream {
+	return s.appendOp(window.NewSession(keyFn, gap))
+}
+
+// BatchByKey batches streamed items per key returned by keyFn and sends
+// each batch downstream, as window.KeyBatch{Key, Items}, when it holds
+// maxCount items or when maxWait elapses since its first item.  Batches of
+// different keys are flushed independently (i.e. per tenant batched
+// writes), open batches are flushed when the upstream closes.  A maxCount
+// (or maxWait) of zero disables flushing by count (or by time).
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/window"#NewKeyBatch
+func (s *Stream) BatchByKey(keyFn func(interface{}) interface{}, maxCount int, maxWait time.Duration) *Stream {
+	return s.appendOp(window.NewKeyBatch(keyFn, maxCount, maxWait))
+}
+
+// AggregateByKeyWindowed aggregates streamed items, per key returned by
+// keyFn, within tumbling event-time windows of the specified size (see
+// WindowByTime).  When a window closes, the final aggregate of each of its
+// keys is sent downstream as a window.Aggregate.
+func (s *Stream) AggregateByKeyWindowed(size time.Duration, keyFn func(interface{}) interface{}, aggFn window.AggregateFunc) *Stream {
+	return s.AggregateByKeyWindowedEmitting(size, keyFn, aggFn, window.EmitOnClose)
+}
+
+// AggregateByKeyWindowedEmitting is similar to AggregateByKeyWindowed with
+// the specified emit policy: with window.EmitOnUpdate, each aggregated item
+// also sends the updated partial aggregate of its key downstream (for live
+// updates), followed by the final aggregates when the window closes.
+// Aggregates are marked with Final accordingly.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/window"#AggregateOperator
+func (s *Stream) AggregateByKeyWindowedEmitting(
+	size time.Duration,
+	keyFn func(interface{}) interface{},
+	aggFn window.AggregateFunc,
+	policy window.EmitPolicy,
+) *Stream {
+	return s.appendOp(window.NewAggregate(size, keyFn, aggFn, policy))
+}
+
+// LateData returns a new stream that emits the late items of the most
+// recent windowed aggregation of this stream (i.e. AggregateByKeyWindowed,
+// CountByKeyPerWindow): items whose window had already closed, past the
+// allowed lateness, when they arrived.  Late items are then no longer
+// reported on the error path.  This stream and the late data stream must
+// both be given a sink and opened.  Late items carry no source position,
+// their positions are committed by the sink of this stream.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/window"#AggregateOperator.GetLateData
+func (s *Stream) LateData() *Stream {
+	for i := len(s.ops) - 1; i >= 0; i-- {
+		if op, ok := s.ops[i].(*window.AggregateOperator); ok {
+			return New(op.GetLateData())
+		}
+	}
+	strm := New(nil)
+	strm.drainErr(errors.New("stream has no windowed aggregation for late data"))
+	return strm
+}
+
+// CountByKeyPerWindow counts streamed items per key, as returned by keyFn,
+// within tumbling event-time windows of the specified size (see
+// WindowByTime), the count of each key is sent downstream as a
+// window.KeyCount{WindowStart, Key, Count} when its window closes.  Items
+// for a window that already closed are reported on the error path and
+// dropped, see CountByKeyPerWindowWithLateness to accept out-of-order items.
+func (s *Stream) CountByKeyPerWindow(keyFn func(interface{}) interface{}, windowSize time.Duration) *Stream {
+	return s.CountByKeyPerWindowWithLateness(keyFn, windowSize, 0)
+}
+
+// CountByKeyPerWindowWithLateness is similar to CountByKeyPerWindow,
+// windows are kept open for the allowed lateness past their end (in event
+// time) so that out-of-order items are counted in their window.
+//
+// See Also
+//
+//   "github.com/vladimirvivien/automi/operators/window"#AggregateOperator
+func (s *Stream) CountByKeyPerWindowWithLateness(keyFn func(interface{}) interface{}, windowSize, lateness time.Duration) *Stream {
+	s.appendOp(window.NewAggregate(windowSize, keyFn, window.CountAggregate, window.EmitOnClose).AllowedLateness(lateness))
+	return s.Transform(window.KeyCountFunc())
+}
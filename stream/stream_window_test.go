@@ -0,0 +1,512 @@
+package stream
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/vladimirvivien/automi/api"
+	"github.com/vladimirvivien/automi/collectors"
+	"github.com/vladimirvivien/automi/emitters"
+	"github.com/vladimirvivien/automi/operators/window"
+)
+
+func TestStream_AssignTimestamps_WindowByTime(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5, 6, 7})).
+		AssignTimestamps(time.Unix(0, 0), time.Second).
+		WindowByTime(3 * time.Second).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	expected := [][]int{{1, 2, 3}, {4, 5, 6}, {7}}
+	windows := snk.Get()
+	if len(windows) != len(expected) {
+		t.Fatal("unexpected windows", windows)
+	}
+	for i, win := range windows {
+		items := win.([]interface{})
+		if len(items) != len(expected[i]) {
+			t.Fatal("unexpected window content", items)
+		}
+		for j, item := range items {
+			if item.(int) != expected[i][j] {
+				t.Fatal("unexpected window content", items)
+			}
+		}
+	}
+}
+
+func TestStream_WindowByTime_DistinctByKey(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"a1", "b1", "a2", "a3", "b2", "b3", "c1"})).
+		AssignTimestamps(time.Unix(0, 0), time.Second).
+		WindowByTime(3 * time.Second).
+		DistinctByKey(func(item interface{}) interface{} { return item.(string)[:1] }).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	expected := [][]string{{"a1", "b1"}, {"a3", "b2"}, {"c1"}}
+	windows := snk.Get()
+	if len(windows) != len(expected) {
+		t.Fatal("unexpected windows", windows)
+	}
+	for i, win := range windows {
+		items := win.([]interface{})
+		if len(items) != len(expected[i]) {
+			t.Fatal("unexpected distinct items", windows)
+		}
+		for j, item := range items {
+			if item != expected[i][j] {
+				t.Fatal("unexpected distinct items", windows)
+			}
+		}
+	}
+}
+
+func TestStream_AggregateByKeyWindowedEmitting(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"a", "b", "a", "c", "a"})).
+		AssignTimestamps(time.Unix(0, 0), time.Second).
+		AggregateByKeyWindowedEmitting(
+			3*time.Second,
+			func(item interface{}) interface{} { return item },
+			func(acc, item interface{}) interface{} {
+				if acc == nil {
+					return 1
+				}
+				return acc.(int) + 1
+			},
+			window.EmitOnUpdate,
+		).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	// each window: partials for every item, then finals
+	expected := []string{"a:1", "b:1", "a:2", "a:2!", "b:1!", "c:1", "a:1", "c:1!", "a:1!"}
+	result := snk.Get()
+	if len(result) != len(expected) {
+		t.Fatal("unexpected aggregates", result)
+	}
+	for i, item := range result {
+		agg := item.(window.Aggregate)
+		got := fmt.Sprintf("%v:%v", agg.Key, agg.Value)
+		if agg.Final {
+			got += "!"
+		}
+		if got != expected[i] {
+			t.Fatalf("aggregate %d: expecting %s, got %s", i, expected[i], got)
+		}
+	}
+}
+
+func TestStream_ThrottleLatest(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5})).
+		ThrottleLatest(time.Hour).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	if result := snk.Get(); len(result) != 1 || result[0] != 5 {
+		t.Fatal("expecting only the latest item, got", result)
+	}
+}
+
+func TestStream_WindowWhen(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 2, 0, 3, 0, 4, 5})).
+		WindowWhen(func(item interface{}, _ []interface{}) bool { return item.(int) == 0 }).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	expected := [][]int{{1, 2, 0}, {3, 0}, {4, 5}}
+	windows := snk.Get()
+	if len(windows) != len(expected) {
+		t.Fatal("unexpected windows", windows)
+	}
+	for i, win := range windows {
+		items := win.([]interface{})
+		if len(items) != len(expected[i]) {
+			t.Fatal("unexpected window content", items)
+		}
+		for j, item := range items {
+			if item.(int) != expected[i][j] {
+				t.Fatal("unexpected window content", items)
+			}
+		}
+	}
+}
+
+func TestStream_Throttled(t *testing.T) {
+	var stats window.PressureStats
+	count := 0
+	snk := collectors.Func(func(interface{}) error {
+		time.Sleep(3 * time.Millisecond) // slow sink
+		count++
+		return nil
+	})
+	strm := New(emitters.Slice([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})).
+		Throttled(2*time.Millisecond, func(s window.PressureStats) { stats = s }).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if count != 10 || stats.Items != 10 {
+		t.Fatal("unexpected items count", count, stats.Items)
+	}
+	if stats.Blocked < 8 || stats.Pressure < 0.5 || stats.Delay <= 0 {
+		t.Fatalf("expecting pressure to rise, got %+v", stats)
+	}
+}
+
+func TestStream_UniqCount(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]int{1, 1, 2, 3, 3, 3, 1})).
+		UniqCount(nil).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	expected := []window.Run{{Value: 1, Count: 2}, {Value: 2, Count: 1}, {Value: 3, Count: 3}, {Value: 1, Count: 1}}
+	runs := snk.Get()
+	if len(runs) != len(expected) {
+		t.Fatal("unexpected runs", runs)
+	}
+	for i, run := range runs {
+		if run.(window.Run) != expected[i] {
+			t.Fatal("unexpected run", run)
+		}
+	}
+}
+
+func TestStream_ShuffleWith(t *testing.T) {
+	input := make([]int, 50)
+	for i := range input {
+		input[i] = i
+	}
+	snk := collectors.Slice()
+	strm := New(emitters.Slice(input)).
+		ShuffleWith(8, rand.New(rand.NewSource(1))).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	result := snk.Get()
+	seen := make(map[int]bool)
+	moved := false
+	for i, item := range result {
+		seen[item.(int)] = true
+		if item.(int) != i {
+			moved = true
+		}
+	}
+	if len(result) != len(input) || len(seen) != len(input) || !moved {
+		t.Fatal("expecting a shuffled permutation of the input, got", result)
+	}
+}
+
+func TestStream_ShuffleWith_Checkpoint(t *testing.T) {
+	input := make([]int, 50)
+	for i := range input {
+		input[i] = i
+	}
+	delivered := make(map[int]bool)
+	committed := -1
+	snk := collectors.Func(func(item interface{}) error {
+		delivered[item.(int)] = true
+		return nil
+	}).Checkpointer(api.CheckpointFunc(func(pos interface{}) error {
+		// a committed position never passes an item still in the reservoir
+		for i := 0; i <= pos.(int); i++ {
+			if !delivered[i] {
+				t.Errorf("position %v committed before item %d was delivered", pos, i)
+			}
+		}
+		if pos.(int) <= committed {
+			t.Errorf("position %v committed after %d", pos, committed)
+		}
+		committed = pos.(int)
+		return nil
+	}))
+	strm := New(emitters.Slice(input).Positioned()).
+		ShuffleWith(8, rand.New(rand.NewSource(1))).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	if len(delivered) != len(input) || committed != len(input)-1 {
+		t.Fatal("expecting all items delivered and committed, got", len(delivered), committed)
+	}
+}
+
+func TestStream_WithSeed(t *testing.T) {
+	run := func(seed int64) []interface{} {
+		input := make([]int, 30)
+		for i := range input {
+			input[i] = i
+		}
+		snk := collectors.Slice()
+		strm := New(emitters.Slice(input)).WithSeed(seed).Shuffle(10).Into(snk)
+		select {
+		case err := <-strm.Open():
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(50 * time.Millisecond):
+			t.Fatal("Waited too long ...")
+		}
+		return snk.Get()
+	}
+
+	first, second, other := run(7), run(7), run(8)
+	same := func(a, b []interface{}) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+	if !same(first, second) {
+		t.Fatal("expecting identical output for the same seed", first, second)
+	}
+	if same(first, other) {
+		t.Fatal("expecting different output for another seed", first, other)
+	}
+}
+
+func TestStream_CountByKeyPerWindow(t *testing.T) {
+	start := time.Unix(0, 0)
+	event := func(sec int, key string) api.TimedItem {
+		return api.TimedItem{Time: start.Add(time.Duration(sec) * time.Second), Item: key}
+	}
+	var late []api.StreamError
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]api.TimedItem{
+		event(0, "a"), event(1, "b"), event(2, "a"),
+		event(3, "b"), event(1, "a"), // out of order, kept open by lateness
+		event(11, "a"), event(4, "b"), // too late
+	})).
+		WithErrorFunc(func(err api.StreamError) { late = append(late, err) }).
+		CountByKeyPerWindowWithLateness(func(item interface{}) interface{} { return item }, 3*time.Second, time.Second).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	expected := []window.KeyCount{
+		{WindowStart: start, Key: "a", Count: 3},
+		{WindowStart: start, Key: "b", Count: 1},
+		{WindowStart: start.Add(3 * time.Second), Key: "b", Count: 1},
+		{WindowStart: start.Add(9 * time.Second), Key: "a", Count: 1},
+	}
+	counts := snk.Get()
+	if len(counts) != len(expected) {
+		t.Fatal("unexpected counts", counts)
+	}
+	for i, count := range counts {
+		kc := count.(window.KeyCount)
+		if kc.Key != expected[i].Key || kc.Count != expected[i].Count || !kc.WindowStart.Equal(expected[i].WindowStart) {
+			t.Fatalf("expecting count %v, got %v", expected[i], kc)
+		}
+	}
+	if len(late) != 1 {
+		t.Fatal("expecting one late item, got", late)
+	}
+}
+
+func TestStream_SplitOn(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]string{"GET", "/", "END", "POST", "/users", "body", "END", "PUT"})).
+		SplitOn(func(item interface{}) bool { return item == "END" }).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	expected := [][]string{{"GET", "/"}, {"POST", "/users", "body"}, {"PUT"}}
+	groups := snk.Get()
+	if len(groups) != len(expected) {
+		t.Fatal("unexpected groups", groups)
+	}
+	for i, group := range groups {
+		items := group.([]interface{})
+		if fmt.Sprint(items) != fmt.Sprint(expected[i]) {
+			t.Fatalf("expecting group %v, got %v", expected[i], items)
+		}
+	}
+}
+
+func TestStream_BatchByKey(t *testing.T) {
+	snk := collectors.Slice()
+	strm := New([]string{"a:1", "b:1", "a:2", "a:3"}).
+		BatchByKey(func(item interface{}) interface{} { return item.(string)[:1] }, 2, time.Second).
+		Into(snk)
+
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("Took too long")
+	}
+
+	result := snk.Get()
+	if len(result) != 3 {
+		t.Fatal("expecting 3 batches, got", result)
+	}
+	first := result[0].(window.KeyBatch)
+	if first.Key != "a" || len(first.Items) != 2 || first.Items[1] != "a:2" {
+		t.Fatal("expecting batch of a flushed by count, got", first)
+	}
+	for _, batch := range result[1:] {
+		if len(batch.(window.KeyBatch).Items) != 1 {
+			t.Fatal("expecting remaining batches flushed on close, got", result)
+		}
+	}
+}
+
+func TestStream_LateData(t *testing.T) {
+	start := time.Unix(0, 0)
+	event := func(sec int, key string) api.TimedItem {
+		return api.TimedItem{Time: start.Add(time.Duration(sec) * time.Second), Item: key}
+	}
+	var errs []api.StreamError
+	snk := collectors.Slice()
+	strm := New(emitters.Slice([]api.TimedItem{
+		event(0, "a"), event(2, "b"), event(11, "a"),
+		event(1, "b"), // too late
+	})).
+		WithErrorFunc(func(err api.StreamError) { errs = append(errs, err) }).
+		CountByKeyPerWindowWithLateness(func(item interface{}) interface{} { return item }, 3*time.Second, time.Second).
+		Into(snk)
+	lateSnk := collectors.Slice()
+	late := strm.LateData().Into(lateSnk)
+
+	lateDone := late.Open()
+	select {
+	case err := <-strm.Open():
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+	select {
+	case err := <-lateDone:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+
+	if len(snk.Get()) != 3 {
+		t.Fatal("unexpected counts", snk.Get())
+	}
+	lateItems := lateSnk.Get()
+	if len(lateItems) != 1 {
+		t.Fatal("expecting one late item, got", lateItems)
+	}
+	if item := lateItems[0].(api.TimedItem); item.Item != "b" || !item.Time.Equal(start.Add(time.Second)) {
+		t.Fatal("unexpected late item", item)
+	}
+	if len(errs) != 0 {
+		t.Fatal("expecting no late item on error path, got", errs)
+	}
+}
+
+func TestStream_LateData_NoWindow(t *testing.T) {
+	strm := New(emitters.Slice([]int{1, 2})).Map(func(i int) int { return i }).LateData().Into(collectors.Null())
+	select {
+	case err := <-strm.Open():
+		if err == nil {
+			t.Fatal("expecting error for missing windowed aggregation")
+		}
+	case <-time.After(50 * time.Millisecond):
+		t.Fatal("Waited too long ...")
+	}
+}
@@ -0,0 +1,22 @@
+package testutil
+
+import (
+	"runtime"
+	"time"
+)
+
+// WaitGoroutines waits, up to timeout, for the number of running
+// goroutines to drop back to baseline.  It returns false if goroutines
+// are still running after the timeout (i.e. they leaked).
+func WaitGoroutines(baseline int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		if runtime.NumGoroutine() <= baseline {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -1,6 +1,12 @@
 package util
 
-import "reflect"
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
 
 func IsNumericValue(val reflect.Value) bool {
 	return (IsIntValue(val) || IsFloatValue(val))
@@ -57,3 +63,173 @@ func IsLess(itemI, itemJ reflect.Value) bool {
 	}
 	return false
 }
+
+// Coerce attempts to convert val to type target. Values assignable to
+// target are returned as is.  Numeric values are converted between numeric
+// types (conversions that would lose precision, i.e. 1.5 to int, fail),
+// strings are parsed into numeric or bool values, and numeric or bool values
+// are formatted into strings.  Other conversions supported by package reflect
+// (i.e. between types of the same underlying type) are also applied.
+func Coerce(val interface{}, target reflect.Type) (interface{}, error) {
+	if val == nil {
+		return nil, fmt.Errorf("cannot coerce nil to %s", target)
+	}
+	value := reflect.ValueOf(val)
+	if value.Type().AssignableTo(target) {
+		return val, nil
+	}
+
+	switch {
+	case IsNumericValue(value) && isNumericKind(target.Kind()):
+		converted, ok := convertNumber(value, target)
+		if !ok {
+			return nil, fmt.Errorf("cannot coerce %v to %s without loss", val, target)
+		}
+		return converted.Interface(), nil
+	case value.Kind() == reflect.String && (isNumericKind(target.Kind()) || target.Kind() == reflect.Bool):
+		return parseString(value.String(), target)
+	case target.Kind() == reflect.String && (IsNumericValue(value) || value.Kind() == reflect.Bool):
+		return reflect.ValueOf(fmt.Sprint(val)).Convert(target).Interface(), nil
+	case value.Kind() == reflect.Slice && target.Kind() == reflect.Array:
+		// conversion panics on length mismatch
+	case value.Type().ConvertibleTo(target):
+		return value.Convert(target).Interface(), nil
+	}
+	return nil, fmt.Errorf("cannot coerce %v of type %T to %s", val, val, target)
+}
+
+// convertNumber converts the numeric value to the numeric type target, it
+// returns false if the value is out of the range of target (i.e. a negative
+// value to an unsigned type) or would lose precision
+func convertNumber(value reflect.Value, target reflect.Type) (reflect.Value, bool) {
+	result := reflect.New(target).Elem()
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := value.Int()
+		switch {
+		case isIntKind(target.Kind()):
+			if result.OverflowInt(i) {
+				return result, false
+			}
+			result.SetInt(i)
+		case isUintKind(target.Kind()):
+			if i < 0 || result.OverflowUint(uint64(i)) {
+				return result, false
+			}
+			result.SetUint(uint64(i))
+		default:
+			result.SetFloat(float64(i))
+			if f := result.Float(); f >= maxInt64Float || int64(f) != i {
+				return result, false
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := value.Uint()
+		switch {
+		case isIntKind(target.Kind()):
+			if u > math.MaxInt64 || result.OverflowInt(int64(u)) {
+				return result, false
+			}
+			result.SetInt(int64(u))
+		case isUintKind(target.Kind()):
+			if result.OverflowUint(u) {
+				return result, false
+			}
+			result.SetUint(u)
+		default:
+			result.SetFloat(float64(u))
+			if f := result.Float(); f >= maxUint64Float || uint64(f) != u {
+				return result, false
+			}
+		}
+	default:
+		f := value.Float()
+		switch {
+		case isIntKind(target.Kind()):
+			if f != math.Trunc(f) || f < -maxInt64Float || f >= maxInt64Float || result.OverflowInt(int64(f)) {
+				return result, false
+			}
+			result.SetInt(int64(f))
+		case isUintKind(target.Kind()):
+			if f != math.Trunc(f) || f < 0 || f >= maxUint64Float || result.OverflowUint(uint64(f)) {
+				return result, false
+			}
+			result.SetUint(uint64(f))
+		default:
+			if result.OverflowFloat(f) {
+				return result, false
+			}
+			result.SetFloat(f)
+			if result.Float() != f && !math.IsNaN(f) {
+				return result, false
+			}
+		}
+	}
+	return result, true
+}
+
+// float64 values of 2^63 and 2^64, the first values out of range
+// of int64 and uint64
+const (
+	maxInt64Float  = float64(1 << 63)
+	maxUint64Float = float64(1 << 64)
+)
+
+func isIntKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+func isUintKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func isNumericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// parseString parses str into a value of type target (numeric or bool)
+func parseString(str string, target reflect.Type) (interface{}, error) {
+	str = strings.TrimSpace(str)
+	result := reflect.New(target).Elem()
+	switch target.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return nil, fmt.Errorf("cannot coerce %q to %s", str, target)
+		}
+		result.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(str, 10, target.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("cannot coerce %q to %s", str, target)
+		}
+		result.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(str, 10, target.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("cannot coerce %q to %s", str, target)
+		}
+		result.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(str, target.Bits())
+		if err != nil {
+			return nil, fmt.Errorf("cannot coerce %q to %s", str, target)
+		}
+		result.SetFloat(f)
+	}
+	return result.Interface(), nil
+}